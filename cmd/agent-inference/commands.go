@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/agent"
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/compute"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgmock"
+)
+
+// subcommands are diagnostic commands that run instead of the agent.
+var subcommands = map[string]func(ctx context.Context, args []string, stdout io.Writer) error{
+	"models": runModels,
+	"infer":  runInfer,
+}
+
+// loadCLIConfig loads the agent configuration for a subcommand. Subcommands
+// do not join HCS, so INFERENCE_AGENT_ID is defaulted when unset.
+func loadCLIConfig(configFile string) (*agent.Config, error) {
+	if configFile != "" {
+		if err := loadEnvFile(configFile); err != nil {
+			return nil, err
+		}
+	}
+	if os.Getenv("INFERENCE_AGENT_ID") == "" {
+		os.Setenv("INFERENCE_AGENT_ID", "cli")
+	}
+	cfg, err := agent.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.SetVersion(version); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// newCLIBroker builds a compute broker from cfg, honoring ZG_MOCK_MODE.
+// The chain key is optional; without one, provider sessions are not set up.
+func newCLIBroker(ctx context.Context, cfg *agent.Config) (compute.ComputeBroker, error) {
+	if os.Getenv("ZG_MOCK_MODE") == "true" {
+		return zgmock.NewComputeBroker(), nil
+	}
+
+	chainClient, err := zerog.DialClient(ctx, cfg.Compute.ChainRPC, cfg.HTTPTransport)
+	if err != nil {
+		return nil, err
+	}
+	if err := zerog.VerifyChainID(ctx, chainClient, cfg.Compute.ChainID); err != nil {
+		return nil, err
+	}
+
+	var key *ecdsa.PrivateKey
+	if cfg.Compute.PrivateKey != "" || cfg.ChainMnemonic != "" {
+		if key, err = loadChainKey(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return compute.NewBroker(cfg.Compute, chainClient, key), nil
+}
+
+// runModels prints the models discoverable on 0G Compute.
+func runModels(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("models", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path to a KEY=VALUE env file loaded before reading the environment")
+	asJSON := fs.Bool("json", false, "print models as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadCLIConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	broker, err := newCLIBroker(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer broker.Close()
+
+	res, err := broker.ListModelsWithTotal(ctx)
+	if err != nil {
+		return err
+	}
+	models := res.Models
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(models)
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tNAME\tPROVIDER\tTYPE\tURL")
+	for _, m := range models {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.ID, m.Name, m.Provider, m.ServiceType, m.URL)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if res.Truncated {
+		fmt.Fprintf(stdout, "\nshowing %d of %d registered services\n", len(models), res.Total)
+	}
+	return nil
+}
+
+// runInfer submits a single prompt to 0G Compute and prints the result.
+// With no --prompt, the prompt is read from stdin.
+func runInfer(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("infer", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path to a KEY=VALUE env file loaded before reading the environment")
+	model := fs.String("model", "", "model ID to run (required)")
+	prompt := fs.String("prompt", "", "prompt text; read from stdin when empty")
+	maxTokens := fs.Int("max-tokens", 0, "maximum tokens to generate (0 = provider default)")
+	asJSON := fs.Bool("json", false, "print the full result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *model == "" {
+		return fmt.Errorf("--model is required")
+	}
+
+	input := *prompt
+	if input == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read prompt from stdin: %w", err)
+		}
+		input = strings.TrimSpace(string(data))
+	}
+	if input == "" {
+		return fmt.Errorf("empty prompt: pass --prompt or pipe it on stdin")
+	}
+
+	cfg, err := loadCLIConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	broker, err := newCLIBroker(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer broker.Close()
+
+	start := time.Now()
+	jobID, err := broker.SubmitJob(ctx, compute.JobRequest{
+		ModelID:   *model,
+		Input:     input,
+		MaxTokens: *maxTokens,
+	})
+	if err != nil {
+		return err
+	}
+	result, err := broker.GetResult(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	latency := time.Since(start)
+	if result.Duration == 0 {
+		result.Duration = latency
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	fmt.Fprintln(stdout, result.Output)
+	fmt.Fprintf(stdout, "\njob: %s  model: %s  tokens: %d  latency: %s",
+		result.JobID, result.ModelID, result.TokensUsed, latency.Round(time.Millisecond))
+	if result.FinishReason != "" {
+		fmt.Fprintf(stdout, "  finish: %s", result.FinishReason)
+	}
+	fmt.Fprintln(stdout)
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"strings"
+	"syscall"
+)
+
+// version is overridden at build time via -ldflags "-X main.version=...".
+var version = "dev"
+
+// cliOptions holds command-line flags. Flags take precedence over
+// environment variables, which take precedence over the --config file.
+type cliOptions struct {
+	configFile  string
+	agentID     string
+	daemonAddr  string
+	logLevel    string
+	tasksFile   string
+	resultsFile string
+	dryRun      bool
+	showVersion bool
+}
+
+func parseFlags(args []string) (*cliOptions, error) {
+	opts := &cliOptions{}
+	fs := flag.NewFlagSet("agent-inference", flag.ContinueOnError)
+	fs.StringVar(&opts.configFile, "config", "", "path to a KEY=VALUE env file loaded before reading the environment")
+	fs.StringVar(&opts.agentID, "agent-id", "", "agent identifier (overrides INFERENCE_AGENT_ID)")
+	fs.StringVar(&opts.daemonAddr, "daemon-addr", "", "daemon gRPC address (overrides INFERENCE_DAEMON_ADDR)")
+	fs.StringVar(&opts.logLevel, "log-level", "", "log level: debug, info, warn, error (overrides INFERENCE_LOG_LEVEL)")
+	fs.StringVar(&opts.tasksFile, "tasks-file", "", "replay newline-delimited task envelopes from a file instead of HCS (overrides INFERENCE_TASKS_FILE)")
+	fs.StringVar(&opts.resultsFile, "results-file", "", "capture published messages to a file in replay or offline mode (overrides INFERENCE_RESULTS_FILE)")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "load and validate configuration, then exit without starting the agent")
+	fs.BoolVar(&opts.showVersion, "version", false, "print version information and exit")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// applyEnv loads the config file and maps flag overrides onto the
+// environment variables read by agent.LoadConfig.
+func (o *cliOptions) applyEnv() error {
+	if o.configFile != "" {
+		if err := loadEnvFile(o.configFile); err != nil {
+			return err
+		}
+	}
+	if o.agentID != "" {
+		os.Setenv("INFERENCE_AGENT_ID", o.agentID)
+	}
+	if o.daemonAddr != "" {
+		os.Setenv("INFERENCE_DAEMON_ADDR", o.daemonAddr)
+	}
+	if o.logLevel != "" {
+		os.Setenv("INFERENCE_LOG_LEVEL", o.logLevel)
+	}
+	if o.tasksFile != "" {
+		os.Setenv("INFERENCE_TASKS_FILE", o.tasksFile)
+	}
+	if o.resultsFile != "" {
+		os.Setenv("INFERENCE_RESULTS_FILE", o.resultsFile)
+	}
+	return nil
+}
+
+// loadEnvFile sets environment variables from a KEY=VALUE file.
+// Blank lines and # comments are skipped; variables already present in
+// the environment are not overwritten.
+func loadEnvFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("config file %s:%d: expected KEY=VALUE", path, lineNum)
+		}
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		val = parseEnvValue(val)
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, val)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseEnvValue strips surrounding quotes, or for unquoted values trailing
+// " #" comments, and expands ${VAR} references.
+func parseEnvValue(raw string) string {
+	val := strings.TrimSpace(raw)
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		return val[1 : len(val)-1]
+	}
+	if strings.HasPrefix(val, "#") {
+		return ""
+	}
+	if i := strings.Index(val, " #"); i >= 0 {
+		val = strings.TrimSpace(val[:i])
+	}
+	return os.ExpandEnv(val)
+}
+
+// newLogger builds the process logger in the configured format. The level is
+// held in a LevelVar so it can be changed while running.
+func newLogger(format string, level *slog.LevelVar) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
+
+// watchLogLevel toggles between the configured level and debug on SIGHUP
+// until ctx is cancelled.
+func watchLogLevel(ctx context.Context, log *slog.Logger, level *slog.LevelVar) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	configured := level.Level()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			next := slog.LevelDebug
+			if level.Level() == slog.LevelDebug {
+				next = configured
+			}
+			level.Set(next)
+			log.Info("log level changed", "level", next.String())
+		}
+	}
+}
+
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "agent-inference %s\n", version)
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	fmt.Fprintf(w, "go: %s\n", info.GoVersion)
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified":
+			fmt.Fprintf(w, "%s: %s\n", s.Key, s.Value)
+		}
+	}
+}
@@ -4,11 +4,15 @@ package main
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/ethereum/go-ethereum/common"
 	hiero "github.com/hiero-ledger/hiero-sdk-go/v2/sdk"
 
 	"github.com/lancekrogers/agent-coordinator-ethden-2026/pkg/daemon"
@@ -23,24 +27,72 @@ import (
 )
 
 func main() {
-	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			err := cmd(ctx, os.Args[2:], os.Stdout)
+			cancel()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	opts, err := parseFlags(os.Args[1:])
+	if err != nil {
+		os.Exit(2)
+	}
+	if opts.showVersion {
+		printVersion(os.Stdout)
+		return
+	}
+
+	// Bootstrap logger for errors before the configured logger exists.
+	log := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	if err := opts.applyEnv(); err != nil {
+		log.Error("failed to apply command-line options", "error", err)
+		os.Exit(1)
+	}
 
 	cfg, err := agent.LoadConfig()
 	if err != nil {
 		log.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	if err := cfg.SetVersion(version); err != nil {
+		log.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.LogLevel)
+	log = newLogger(cfg.LogFormat, logLevel)
+
+	if opts.dryRun {
+		log.Info("configuration valid (dry run)",
+			"agent_id", cfg.AgentID,
+			"daemon_addr", cfg.DaemonAddr,
+			"chain_rpc", cfg.INFT.ChainRPC,
+			"task_topic", cfg.HCSTaskTopic,
+			"result_topic", cfg.HCSResultTopic,
+			"version", version)
+		return
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
+	go watchLogLevel(ctx, log, logLevel)
 
 	// Initialize 0G dependencies — mock or real based on ZG_MOCK_MODE.
 	var comp compute.ComputeBroker
 	var store storage.StorageClient
 	var mint inft.INFTMinter
 	var aud da.AuditPublisher
+	var chainCheck *agent.ReadinessCheck
+	var provenanceKey *ecdsa.PrivateKey
 
 	if os.Getenv("ZG_MOCK_MODE") == "true" {
 		log.Info("0G MOCK MODE ENABLED - no real 0G chain connections")
@@ -49,33 +101,88 @@ func main() {
 		mint = zgmock.NewINFTMinter()
 		aud = zgmock.NewAuditPublisher()
 	} else {
-		chainClient, err := zerog.DialClient(ctx, cfg.INFT.ChainRPC)
+		chainClient, err := zerog.DialClient(ctx, cfg.INFT.ChainRPC, cfg.HTTPTransport)
 		if err != nil {
 			log.Error("failed to connect to 0G Chain", "error", err)
 			os.Exit(1)
 		}
+		if err := zerog.VerifyChainID(ctx, chainClient, cfg.INFT.ChainID); err != nil {
+			log.Error("0G Chain RPC does not match configured chain", "rpc", cfg.INFT.ChainRPC, "error", err)
+			os.Exit(1)
+		}
+		if err := zerog.VerifyContracts(ctx, chainClient, configuredContracts(cfg)); err != nil {
+			log.Error("configured contract address is not usable on this chain", "error", err)
+			os.Exit(1)
+		}
 
-		chainKey, err := zerog.LoadKey(cfg.INFT.PrivateKey)
+		chainSigner, chainKey, err := initChainSigner(ctx, cfg)
 		if err != nil {
-			log.Error("failed to load chain private key", "error", err)
+			log.Error("failed to initialize chain signer", "error", err)
 			os.Exit(1)
 		}
+		// Deferred before closeClients, so it runs after the clients that
+		// sign through it are closed.
+		if rs, ok := chainSigner.(*zerog.RemoteSigner); ok {
+			defer rs.Close()
+		}
 
 		comp = compute.NewBroker(cfg.Compute, chainClient, chainKey)
-		store = storage.NewClient(cfg.Storage, chainClient, chainKey)
-		mint = inft.NewMinter(cfg.INFT, chainClient, chainKey)
-		aud = da.NewPublisher(cfg.DA, chainClient, chainKey)
+		provenanceKey = chainKey
+		store = storage.NewClient(cfg.Storage, chainClient, chainSigner)
+		mint = inft.NewMinter(cfg.INFT, chainClient, chainSigner)
+		aud = da.NewPublisher(cfg.DA, chainClient, chainSigner)
+		chainCheck = &agent.ReadinessCheck{Name: "chain", Check: func(ctx context.Context) error {
+			_, err := chainClient.BlockNumber(ctx)
+			return err
+		}}
 	}
 
-	// Initialize HCS transport with Hedera SDK
-	transport := initHCSTransport(log)
-	handler := hcs.NewHandler(cfg.HCSHandler(transport))
+	aud, err = withAuditSinks(log, cfg, aud)
+	if err != nil {
+		log.Error("failed to open audit sink", "error", err)
+		os.Exit(1)
+	}
+
+	defer closeClients(log, comp, store, mint, aud)
+
+	// Initialize HCS transport with Hedera SDK, or replay tasks from a file.
+	var transport hcs.Transport
+	if cfg.TasksFile != "" {
+		log.Info("replaying tasks from file", "tasks_file", cfg.TasksFile, "results_file", cfg.ResultsFile)
+		transport = hcs.NewFileTransport(hcs.FileTransportConfig{
+			TasksFile:   cfg.TasksFile,
+			ResultsFile: cfg.ResultsFile,
+		})
+	} else {
+		transport = initHCSTransport(log, cfg.ResultsFile)
+	}
+	handlerCfg := cfg.HCSHandler(transport)
+	handlerCfg.Log = log
+	handler := hcs.NewHandler(handlerCfg)
 
 	// Connect to daemon runtime (optional — agent works standalone if unavailable).
 	daemonClient := connectDaemon(log, cfg.DaemonAddr)
 	defer daemonClient.Close()
 
 	a := agent.New(*cfg, log, daemonClient, comp, store, mint, aud, handler)
+	if provenanceKey != nil {
+		a.SetProvenanceKey(provenanceKey)
+	}
+	if chainCheck != nil {
+		a.SetChainCheck(chainCheck.Check)
+	}
+
+	if cfg.HealthAddr != "" {
+		checks := a.ReadinessChecks()
+		if chainCheck != nil {
+			checks = append(checks, *chainCheck)
+		}
+		go func() {
+			if err := agent.ServeHealth(ctx, cfg.HealthAddr, log, checks, a.Stats); err != nil {
+				log.Error("health server failed", "error", err)
+			}
+		}()
+	}
 
 	log.Info("inference agent starting", "agent_id", cfg.AgentID)
 	if err := a.Run(ctx); err != nil && err != context.Canceled {
@@ -85,46 +192,121 @@ func main() {
 	log.Info("inference agent stopped gracefully")
 }
 
-func initHCSTransport(log *slog.Logger) hcs.Transport {
+// closeClients releases the 0G clients, logging rather than failing on
+// errors since this runs during shutdown.
+func closeClients(log *slog.Logger, clients ...io.Closer) {
+	for _, c := range clients {
+		if err := c.Close(); err != nil {
+			log.Warn("failed to close 0G client", "error", err)
+		}
+	}
+}
+
+// withAuditSinks tees audit events to the local sinks enabled in cfg.
+func withAuditSinks(log *slog.Logger, cfg *agent.Config, aud da.AuditPublisher) (da.AuditPublisher, error) {
+	var sinks []da.AuditSink
+	if cfg.AuditFile != "" {
+		sink, err := da.NewFileSink(cfg.AuditFile)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if cfg.AuditStdout {
+		sinks = append(sinks, da.NewWriterSink(os.Stdout))
+	}
+	return da.WithSinks(aud, func(event da.AuditEvent, err error) {
+		log.Warn("audit sink write failed", "type", event.Type, "task_id", event.TaskID, "error", err)
+	}, sinks...), nil
+}
+
+// configuredContracts lists the contract addresses the agent will call,
+// for the startup code-presence check.
+func configuredContracts(cfg *agent.Config) []zerog.Contract {
+	return []zerog.Contract{
+		{Name: "ZG_SERVING_CONTRACT", Address: cfg.Compute.ServingContractAddress},
+		{Name: "ZG_FLOW_CONTRACT", Address: cfg.Storage.FlowContractAddress},
+		{Name: "ZG_INFT_CONTRACT", Address: cfg.INFT.ContractAddress},
+		{Name: "ZG_DA_CONTRACT", Address: cfg.DA.DAContractAddress},
+	}
+}
+
+// loadChainKey returns the 0G Chain signing key from either a BIP-39
+// mnemonic or a raw hex private key, depending on configuration.
+func loadChainKey(cfg *agent.Config) (*ecdsa.PrivateKey, error) {
+	if cfg.ChainMnemonic != "" {
+		return zerog.LoadKeyFromMnemonic(cfg.ChainMnemonic, cfg.ChainDerivationPath)
+	}
+	return zerog.LoadKey(cfg.INFT.PrivateKey)
+}
+
+// initChainSigner builds the transaction signer for storage, iNFT, and DA.
+// With a remote signer configured, the local key is optional and only used
+// by the compute broker to sign provider session tokens. A returned
+// *zerog.RemoteSigner must be closed by the caller.
+func initChainSigner(ctx context.Context, cfg *agent.Config) (zerog.Signer, *ecdsa.PrivateKey, error) {
+	if cfg.RemoteSignerURL == "" {
+		key, err := loadChainKey(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zerog.NewLocalSigner(key), key, nil
+	}
+
+	signer, err := zerog.NewRemoteSigner(ctx, cfg.RemoteSignerURL, common.HexToAddress(cfg.RemoteSignerAddress))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var key *ecdsa.PrivateKey
+	if cfg.INFT.PrivateKey != "" || cfg.ChainMnemonic != "" {
+		if key, err = loadChainKey(cfg); err != nil {
+			signer.Close()
+			return nil, nil, err
+		}
+	}
+	return signer, key, nil
+}
+
+// initHCSTransport connects to Hedera, or falls back to a recording
+// transport (capturing to resultsFile, if set) when credentials are missing.
+func initHCSTransport(log *slog.Logger, resultsFile string) hcs.Transport {
+	fallback := func() hcs.Transport {
+		return hcs.NewRecordingTransport(hcs.RecordingTransportConfig{Log: log, File: resultsFile})
+	}
+
 	accountIDStr := os.Getenv("HEDERA_ACCOUNT_ID")
-	privateKeyStr := os.Getenv("HEDERA_PRIVATE_KEY")
+	privateKeyStr, err := agent.SecretEnv("HEDERA_PRIVATE_KEY")
+	if err != nil {
+		log.Error("failed to read Hedera key", "error", err)
+		return fallback()
+	}
 
 	if accountIDStr == "" || privateKeyStr == "" {
-		log.Warn("HEDERA_ACCOUNT_ID or HEDERA_PRIVATE_KEY not set, HCS transport disabled")
-		return &fallbackTransport{log: log}
+		log.Warn("HEDERA_ACCOUNT_ID or HEDERA_PRIVATE_KEY not set, recording HCS messages locally", "results_file", resultsFile)
+		return fallback()
 	}
 
 	accountID, err := hiero.AccountIDFromString(accountIDStr)
 	if err != nil {
 		log.Error("failed to parse HEDERA_ACCOUNT_ID", "error", err)
-		return &fallbackTransport{log: log}
+		return fallback()
 	}
 
 	privateKey, err := hiero.PrivateKeyFromString(privateKeyStr)
 	if err != nil {
 		log.Error("failed to parse HEDERA_PRIVATE_KEY", "error", err)
-		return &fallbackTransport{log: log}
+		return fallback()
 	}
 
 	hederaClient := hiero.ClientForTestnet()
 	hederaClient.SetOperator(accountID, privateKey)
 
 	log.Info("HCS transport initialized", "account_id", accountIDStr)
-	return hcs.NewHCSTransport(hcs.HCSTransportConfig{Client: hederaClient})
-}
-
-// fallbackTransport is a no-op HCS transport used when Hedera credentials are unavailable.
-type fallbackTransport struct {
-	log *slog.Logger
-}
-
-func (f *fallbackTransport) Publish(_ context.Context, topicID string, data []byte) error {
-	f.log.Debug("fallback HCS publish", "topic", topicID, "bytes", len(data))
-	return nil
-}
-
-func (f *fallbackTransport) Subscribe(_ context.Context, _ string) (<-chan []byte, <-chan error) {
-	return make(chan []byte), make(chan error)
+	return hcs.NewHCSTransport(hcs.HCSTransportConfig{
+		Client:       hederaClient,
+		FetchRecords: os.Getenv("HCS_FETCH_RECORDS") == "true",
+	})
 }
 
 func connectDaemon(log *slog.Logger, addr string) daemon.DaemonClient {
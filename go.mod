@@ -8,6 +8,8 @@ require (
 	github.com/ethereum/go-ethereum v1.17.0
 	github.com/hiero-ledger/hiero-sdk-go/v2 v2.75.0
 	github.com/lancekrogers/agent-coordinator-ethden-2026 v0.0.0-20260221224746-0059b418ef82
+	golang.org/x/crypto v0.47.0
+	google.golang.org/grpc v1.79.1
 )
 
 require (
@@ -46,12 +48,10 @@ require (
 	go.opentelemetry.io/otel v1.39.0 // indirect
 	go.opentelemetry.io/otel/metric v1.39.0 // indirect
 	go.opentelemetry.io/otel/trace v1.39.0 // indirect
-	golang.org/x/crypto v0.47.0 // indirect
 	golang.org/x/net v0.48.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.40.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20251222181119-0a764e51fe1b // indirect
-	google.golang.org/grpc v1.79.1 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
 )
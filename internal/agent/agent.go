@@ -17,12 +17,16 @@
 //	→ Mint iNFT with result metadata on 0G Chain
 //	→ Publish audit event to 0G DA
 //	→ Report TaskResult back via HCS
+//	→ Record a signed provenance record on 0G Storage
 package agent
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -47,9 +51,48 @@ type Agent struct {
 	handler *hcs.Handler
 
 	daemonReg      *daemon.RegisterResponse
-	startTime      time.Time
+	startTime      atomic.Pointer[time.Time] // set by Run, read by Stats
 	completedTasks atomic.Int64
 	failedTasks    atomic.Int64
+	activeTasks    atomic.Int64
+	tokensUsed     atomic.Int64
+	subscribed     atomic.Bool
+	inflight       sync.Map // taskID → struct{}, tasks in the pipeline
+	succeeded      *recent[struct{}]
+
+	provenanceKey *ecdsa.PrivateKey
+	provenance    *recent[ProvenanceRecord] // taskID → record, newest tasks only
+
+	outcomes   outcomeWindow
+	chainCheck func(ctx context.Context) error
+}
+
+// Stats is a point-in-time snapshot of agent activity.
+type Stats struct {
+	Completed   int64          `json:"completed"`
+	Failed      int64          `json:"failed"`
+	ActiveTasks int64          `json:"active_tasks"`
+	TokensUsed  int64          `json:"tokens_used"`
+	Uptime      time.Duration  `json:"uptime_ns"`
+	TaskQueue   hcs.QueueStats `json:"task_queue"`
+	// InflightTx is the number of on-chain transactions awaiting receipts.
+	InflightTx int64 `json:"inflight_tx"`
+}
+
+// Stats returns a snapshot of the agent's task counters and uptime.
+func (a *Agent) Stats() Stats {
+	st := Stats{
+		Completed:   a.completedTasks.Load(),
+		Failed:      a.failedTasks.Load(),
+		ActiveTasks: a.activeTasks.Load(),
+		TokensUsed:  a.tokensUsed.Load(),
+		TaskQueue:   a.handler.QueueStats(),
+		InflightTx:  a.cfg.TxLimiter.InFlight(),
+	}
+	if started := a.startTime.Load(); started != nil {
+		st.Uptime = time.Since(*started)
+	}
+	return st
 }
 
 // New creates an Agent with all required dependencies.
@@ -63,6 +106,15 @@ func New(
 	aud da.AuditPublisher,
 	h *hcs.Handler,
 ) *Agent {
+	if cfg.HealthWeights == (HealthWeights{}) {
+		cfg.HealthWeights = DefaultHealthWeights
+	}
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = DefaultHealthInterval
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
 	return &Agent{
 		cfg:     cfg,
 		log:     log,
@@ -72,144 +124,264 @@ func New(
 		minter:  mint,
 		audit:   aud,
 		handler: h,
+
+		succeeded:  newRecent[struct{}](recentTasks),
+		provenance: newRecent[ProvenanceRecord](recentTasks),
 	}
 }
 
-// Run starts the agent and blocks until the context is cancelled.
+// Run starts the agent and blocks until the context is cancelled. If the
+// HCS subscription dies and Config.ExitOnSubscriptionFailure is set, Run
+// stops the agent and returns an error wrapping hcs.ErrSubscriptionFailed.
 func (a *Agent) Run(ctx context.Context) error {
-	a.startTime = time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	started := time.Now()
+	a.startTime.Store(&started)
 	a.log.Info("starting inference agent", "agent_id", a.cfg.AgentID)
 
-	// Register with daemon runtime (optional).
-	reg, regErr := a.daemon.Register(ctx, daemon.RegisterRequest{
+	// A mistyped topic would otherwise leave the agent healthy but idle.
+	if err := a.handler.CheckTopics(ctx); err != nil {
+		return fmt.Errorf("agent: %w", err)
+	}
+	// A corrupt sequence file would otherwise fail every publish.
+	if err := a.handler.CheckSequence(); err != nil {
+		return fmt.Errorf("agent: %w", err)
+	}
+
+	a.register(ctx)
+
+	// Audit: agent online. Best-effort and asynchronous so a DA outage
+	// cannot delay startup.
+	go a.publishLifecycle(ctx, da.EventTypeAgentStarted, nil)
+
+	if a.cfg.WarmModelCache {
+		a.warmModelCache(ctx)
+		go a.modelCacheLoop(ctx)
+	}
+
+	// The subscription, the health loop, and task work each get their own
+	// context so shutdown can stop them in order rather than all at once
+	// on the run context.
+	stopCtx, releaseStop := a.shutdownContext(ctx)
+	defer releaseStop()
+
+	// Collect tasks a previous run accepted but never finished. This must
+	// happen before subscribing, or newly accepted tasks would be both
+	// queued and replayed.
+	replay := a.pendingTasks()
+
+	loops, subFailed := a.startLoops(ctx)
+	defer loops.stopSub()
+	defer loops.stopHealth()
+
+	for _, task := range replay {
+		if ctx.Err() != nil {
+			break
+		}
+		a.log.Info("replaying unfinished task", "task_id", task.TaskID)
+		a.handleTask(stopCtx, task)
+	}
+
+	return a.serve(ctx, cancel, stopCtx, loops, subFailed)
+}
+
+// shutdownContext returns the context task work and the shutdown sequence
+// share. It ends ShutdownTimeout after ctx does: draining the in-flight
+// task spends the same budget shutdown then runs on. Call release once
+// the agent has stopped.
+func (a *Agent) shutdownContext(ctx context.Context) (stopCtx context.Context, release func()) {
+	stopCtx, cancelStop := context.WithCancel(context.WithoutCancel(ctx))
+	stopTimer := context.AfterFunc(ctx, func() {
+		time.AfterFunc(a.cfg.ShutdownTimeout, cancelStop)
+	})
+	return stopCtx, func() {
+		stopTimer()
+		cancelStop()
+	}
+}
+
+// register registers the agent with the daemon runtime. Registration is
+// optional: on failure the agent runs standalone.
+func (a *Agent) register(ctx context.Context) {
+	reg, err := a.daemon.Register(ctx, daemon.RegisterRequest{
 		AgentName:    a.cfg.AgentID,
 		AgentType:    "inference",
 		Capabilities: []string{"0g-compute", "0g-storage", "0g-inft", "0g-da"},
 	})
-	if regErr != nil {
-		a.log.Warn("daemon registration failed, running standalone", "error", regErr)
+	if err != nil {
+		a.log.Warn("daemon registration failed, running standalone", "error", err)
 		a.daemon = daemon.Noop()
-	} else {
-		a.daemonReg = reg
-		a.log.Info("registered with daemon", "agent_id", reg.AgentID, "session_id", reg.SessionID)
+		return
 	}
+	a.daemonReg = reg
+	a.log.Info("registered with daemon", "agent_id", reg.AgentID, "session_id", reg.SessionID)
+}
 
-	// Start HCS subscription in background
+// startLoops starts the HCS subscription and the health reporter in the
+// background, each on its own context so shutdown can stop them in order;
+// the caller must call both stop functions in loops. The returned channel receives the error if the subscription ends while
+// the agent is still running.
+func (a *Agent) startLoops(ctx context.Context) (runLoops, <-chan error) {
+	// Start HCS subscription in background. Its end while the agent is
+	// still running means no more tasks will arrive.
+	subCtx, stopSub := context.WithCancel(context.WithoutCancel(ctx))
+	subDone := make(chan struct{})
+	a.subscribed.Store(true)
+	subFailed := make(chan error, 1)
 	go func() {
-		if err := a.handler.StartSubscription(ctx); err != nil && ctx.Err() == nil {
-			a.log.Error("HCS subscription failed", "error", err)
+		defer close(subDone)
+		defer a.subscribed.Store(false)
+		err := a.handler.StartSubscription(subCtx)
+		if subCtx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("hcs: subscription closed: %w", hcs.ErrSubscriptionFailed)
 		}
+		a.log.Error("HCS subscription failed", "error", err)
+		subFailed <- err
 	}()
 
 	// Start health reporter in background
-	go a.healthLoop(ctx)
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	healthDone := make(chan struct{})
+	go func() {
+		defer close(healthDone)
+		a.healthLoop(healthCtx)
+	}()
 
-	// Process tasks from HCS
+	return runLoops{
+		stopHealth: stopHealth,
+		healthDone: healthDone,
+		stopSub:    stopSub,
+		subDone:    subDone,
+	}, subFailed
+}
+
+// serve processes tasks until ctx ends or, with ExitOnSubscriptionFailure,
+// the subscription fails, then shuts down. cancel ends ctx; stopCtx is the
+// shutdown budget task work and the shutdown sequence share.
+func (a *Agent) serve(ctx context.Context, cancel context.CancelFunc, stopCtx context.Context, loops runLoops, subFailed <-chan error) error {
+	// Process tasks from HCS. Tasks run one at a time on this goroutine,
+	// so by the time the loop sees ctx end the in-flight task has drained.
 	for {
+		// Once ctx ends, select would still pick a queued task at random;
+		// check first so no new task starts during shutdown.
+		if err := ctx.Err(); err != nil {
+			a.shutdown(stopCtx, err, loops)
+			return err
+		}
 		select {
 		case <-ctx.Done():
-			a.log.Info("shutting down inference agent",
-				"completed", a.completedTasks.Load(),
-				"failed", a.failedTasks.Load(),
-				"uptime", time.Since(a.startTime))
-			return ctx.Err()
+			// Handled at the top of the loop.
+		case err := <-subFailed:
+			if !a.cfg.ExitOnSubscriptionFailure {
+				a.log.Warn("continuing without HCS task subscription")
+				continue
+			}
+			cancel() // starts the shutdown deadline
+			a.shutdown(stopCtx, err, loops)
+			return fmt.Errorf("agent: %w", err)
 		case task := <-a.handler.Tasks():
-			if err := a.processTask(ctx, task); err != nil {
-				a.log.Error("task processing failed", "task_id", task.TaskID, "error", err)
-				a.reportFailure(ctx, task, err)
-				a.failedTasks.Add(1)
+			if err := ctx.Err(); err != nil {
+				// Dequeued in the same instant ctx ended; leave it
+				// unstarted for the report and the WAL.
+				a.shutdown(stopCtx, err, loops, task)
+				return err
 			}
+			a.handleTask(stopCtx, task)
 		}
 	}
 }
 
-// processTask executes the full inference pipeline for a single task.
-func (a *Agent) processTask(ctx context.Context, task hcs.TaskAssignment) error {
-	a.log.Info("processing task", "task_id", task.TaskID, "model", task.ModelID)
-	start := time.Now()
-
-	// 1. Audit: task received
-	a.audit.Publish(ctx, da.AuditEvent{
-		Type:      da.EventTypeTaskReceived,
-		AgentID:   a.cfg.AgentID,
-		TaskID:    task.TaskID,
-		Timestamp: time.Now(),
-	})
+// handleTask processes a task taken from the queue or the task WAL,
+// reports a failure back over HCS, and clears the task from the WAL.
+//
+// A task that already succeeded in this run, such as one replayed from
+// the WAL and then redelivered over HCS, is not processed again. A task
+// cut short because ctx ended stays in the WAL so the next run replays
+// it.
+func (a *Agent) handleTask(ctx context.Context, task hcs.TaskAssignment) {
+	if a.succeeded.has(task.TaskID) {
+		a.log.Info("skipping task already completed in this run", "task_id", task.TaskID)
+		a.walDone(task.TaskID)
+		return
+	}
+	err := a.processTask(ctx, task)
+	if err == nil {
+		a.succeeded.add(task.TaskID, struct{}{})
+		a.walDone(task.TaskID)
+		return
+	}
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		a.log.Warn("task interrupted by shutdown, left for replay", "task_id", task.TaskID, "error", err)
+		return
+	}
+	a.log.Error("task processing failed", "task_id", task.TaskID, "error", err)
+	a.reportFailure(ctx, task, err)
+	a.walDone(task.TaskID)
+}
 
-	// 2. Submit inference job to 0G Compute
-	jobID, err := a.compute.SubmitJob(ctx, compute.JobRequest{
-		ModelID:   task.ModelID,
-		Input:     task.Input,
-		MaxTokens: task.MaxTokens,
-	})
-	if err != nil {
-		return fmt.Errorf("agent: compute submit failed for task %s: %w", task.TaskID, err)
+// walDone clears taskID from the task WAL, if one is configured.
+func (a *Agent) walDone(taskID string) {
+	if a.cfg.TaskWALDir == "" {
+		return
 	}
+	if err := (taskWAL{dir: a.cfg.TaskWALDir}).done(taskID); err != nil {
+		a.log.Warn("task wal update failed", "task_id", taskID, "error", err)
+	}
+}
 
-	// 3. Poll for result
-	result, err := a.compute.GetResult(ctx, jobID)
+// pendingTasks returns the tasks left unfinished in the task WAL, if one
+// is configured.
+func (a *Agent) pendingTasks() []hcs.TaskAssignment {
+	if a.cfg.TaskWALDir == "" {
+		return nil
+	}
+	tasks, err := taskWAL{dir: a.cfg.TaskWALDir}.pending()
 	if err != nil {
-		return fmt.Errorf("agent: compute result failed for job %s: %w", jobID, err)
+		a.log.Warn("task wal read failed", "dir", a.cfg.TaskWALDir, "error", err)
 	}
+	return tasks
+}
 
-	// 4. Store result on 0G Storage
-	contentID, err := a.storage.Upload(ctx, []byte(result.Output), storage.Metadata{
-		Name:        fmt.Sprintf("inference-%s", task.TaskID),
-		ContentType: "application/json",
-		Tags:        map[string]string{"task_id": task.TaskID, "model": task.ModelID},
-	})
+// processTask runs a task received over HCS and publishes its result. The
+// task counts as completed only once the result is published; a failed
+// publish counts it as failed, matching the failure the coordinator is
+// then sent.
+func (a *Agent) processTask(ctx context.Context, task hcs.TaskAssignment) error {
+	result, rec, err := a.execute(ctx, task)
 	if err != nil {
-		return fmt.Errorf("agent: storage upload failed for task %s: %w", task.TaskID, err)
+		return err
 	}
-
-	// 5. Mint iNFT with encrypted metadata
-	tokenID, err := a.minter.Mint(ctx, inft.MintRequest{
-		Name:             fmt.Sprintf("Inference Result: %s", task.TaskID),
-		InferenceJobID:   jobID,
-		StorageContentID: contentID,
-		PlaintextMeta: map[string]string{
-			"task_id":  task.TaskID,
-			"model_id": task.ModelID,
-			"agent_id": a.cfg.AgentID,
-		},
-	})
+	receipt, err := a.handler.PublishResultReceipt(ctx, result)
 	if err != nil {
-		return fmt.Errorf("agent: iNFT mint failed for task %s: %w", task.TaskID, err)
+		a.fail()
+		return &StageError{Stage: StageReport, Err: fmt.Errorf("agent: result publish failed for task %s: %w", task.TaskID, err)}
 	}
+	a.recordResultReceipt(ctx, task, receipt)
+	a.complete(ctx, result, rec)
+	return nil
+}
 
-	// 6. Audit: inference completed
-	auditID, _ := a.audit.Publish(ctx, da.AuditEvent{
-		Type:       da.EventTypeJobCompleted,
-		AgentID:    a.cfg.AgentID,
-		TaskID:     task.TaskID,
-		JobID:      jobID,
-		StorageRef: contentID,
-		INFTRef:    tokenID,
-		Timestamp:  time.Now(),
-	})
-
-	// 7. Report result back via HCS (includes CRE signal fields)
-	duration := time.Since(start)
-	confidence, riskScore := a.deriveSignalMetrics(result)
-	err = a.handler.PublishResult(ctx, hcs.TaskResult{
-		TaskID:            task.TaskID,
-		Status:            "completed",
-		Output:            result.Output,
-		DurationMs:        duration.Milliseconds(),
-		TokensUsed:        result.TokensUsed,
-		StorageContentID:  contentID,
-		INFTTokenID:       tokenID,
-		AuditSubmissionID: auditID,
-		SignalConfidence:  confidence,
-		RiskScore:         riskScore,
-	})
+// ProcessTask runs the full inference pipeline for a single task — compute,
+// storage, iNFT mint, and DA audit — and returns the result instead of
+// publishing it, so the agent can be embedded without HCS. On failure the
+// returned result has status "failed" and carries the error message.
+//
+// With Config.MaxTaskDuration set, the pipeline runs under that deadline;
+// a task that overruns it is abandoned at whatever step it reached,
+// recorded as a job_failed audit event, and returned with status
+// "timeout" and an error wrapping ErrTaskTimeout.
+func (a *Agent) ProcessTask(ctx context.Context, task hcs.TaskAssignment) (hcs.TaskResult, error) {
+	result, rec, err := a.execute(ctx, task)
 	if err != nil {
-		return fmt.Errorf("agent: result publish failed for task %s: %w", task.TaskID, err)
+		return result, err
 	}
-
-	a.completedTasks.Add(1)
-	a.log.Info("task completed", "task_id", task.TaskID, "duration", duration)
-	return nil
+	a.complete(ctx, result, rec)
+	return result, nil
 }
 
 // deriveSignalMetrics extracts CRE-compatible signal confidence and risk score
@@ -238,16 +410,46 @@ func (a *Agent) deriveSignalMetrics(result *compute.JobResult) (confidence float
 	return confidence, riskScore
 }
 
+// cancelJob tells the provider to stop a submitted job abandoned by a
+// cancelled task while awaiting its result. A request cancelled during
+// SubmitJob is already cancelled by the broker. It runs on a fresh context
+// because the task context is already done.
+func (a *Agent) cancelJob(jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := a.compute.CancelJob(ctx, jobID); err != nil {
+		a.log.Warn("provider job cancellation failed", "job_id", jobID, "error", err)
+	}
+}
+
 func (a *Agent) reportFailure(ctx context.Context, task hcs.TaskAssignment, taskErr error) {
+	status := "failed"
+	if errors.Is(taskErr, ErrTaskTimeout) {
+		status = "timeout"
+	}
 	a.handler.PublishResult(ctx, hcs.TaskResult{
 		TaskID: task.TaskID,
-		Status: "failed",
+		Status: status,
+		Stage:  failedStage(taskErr),
 		Error:  taskErr.Error(),
 	})
 }
 
-func (a *Agent) healthLoop(ctx context.Context) {
-	ticker := time.NewTicker(a.cfg.HealthInterval)
+// modelCacheRefresh is how often the model cache is re-warmed: early
+// enough that it never expires between refreshes.
+const modelCacheRefresh = compute.ModelCacheTTL * 4 / 5
+
+// warmModelCache fills the compute model cache. Failure is logged, not
+// fatal: tasks fall back to discovering providers on demand.
+func (a *Agent) warmModelCache(ctx context.Context) {
+	if err := a.compute.WarmCache(ctx); err != nil {
+		a.log.Warn("model cache warm-up failed", "error", err)
+	}
+}
+
+// modelCacheLoop re-warms the model cache before it expires.
+func (a *Agent) modelCacheLoop(ctx context.Context) {
+	ticker := time.NewTicker(modelCacheRefresh)
 	defer ticker.Stop()
 
 	for {
@@ -255,23 +457,7 @@ func (a *Agent) healthLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			a.handler.PublishHealth(ctx, hcs.HealthStatus{
-				AgentID:        a.cfg.AgentID,
-				Status:         "idle",
-				UptimeSeconds:  int64(time.Since(a.startTime).Seconds()),
-				CompletedTasks: int(a.completedTasks.Load()),
-				FailedTasks:    int(a.failedTasks.Load()),
-			})
-
-			// Daemon heartbeat on the same tick.
-			hbReq := daemon.HeartbeatRequest{Timestamp: time.Now()}
-			if a.daemonReg != nil {
-				hbReq.AgentID = a.daemonReg.AgentID
-				hbReq.SessionID = a.daemonReg.SessionID
-			}
-			if err := a.daemon.Heartbeat(ctx, hbReq); err != nil {
-				a.log.Warn("daemon heartbeat failed", "error", err)
-			}
+			a.warmModelCache(ctx)
 		}
 	}
 }
@@ -2,13 +2,26 @@ package agent
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
 	"github.com/lancekrogers/agent-coordinator-ethden-2026/pkg/daemon"
 	"github.com/lancekrogers/agent-inference/internal/hcs"
 	"github.com/lancekrogers/agent-inference/internal/zerog/compute"
@@ -24,55 +37,136 @@ type mockCompute struct {
 	resultErr error
 	jobID     string
 	result    *compute.JobResult
+	models    []compute.Model
+	cancelled []string
+	submitted int
+	warmed    atomic.Int32
 }
 
 func (m *mockCompute) SubmitJob(_ context.Context, _ compute.JobRequest) (string, error) {
+	m.submitted++
 	return m.jobID, m.submitErr
 }
 func (m *mockCompute) GetResult(_ context.Context, _ string) (*compute.JobResult, error) {
 	return m.result, m.resultErr
 }
 func (m *mockCompute) ListModels(_ context.Context) ([]compute.Model, error) {
-	return nil, nil
+	return m.models, nil
+}
+func (m *mockCompute) ListModelsWithTotal(_ context.Context) (compute.ListModelsResult, error) {
+	return compute.ListModelsResult{Models: m.models, Total: len(m.models)}, nil
+}
+func (m *mockCompute) Close() error { return nil }
+
+func (m *mockCompute) CancelJob(_ context.Context, jobID string) error {
+	m.cancelled = append(m.cancelled, jobID)
+	return nil
+}
+func (m *mockCompute) EstimateCost(_ context.Context, _ compute.JobRequest) (compute.CostEstimate, error) {
+	return compute.CostEstimate{}, nil
+}
+
+func (m *mockCompute) WarmCache(_ context.Context) error {
+	m.warmed.Add(1)
+	return nil
+}
+
+func (m *mockCompute) SubmitRaw(_ context.Context, _ string, _ json.RawMessage) (*compute.JobResult, error) {
+	return m.result, m.resultErr
 }
 
 type mockStorage struct {
 	uploadErr error
 	contentID string
+	txHash    string
+	block     uint64
+	uploads   map[string][]byte
 }
 
-func (m *mockStorage) Upload(_ context.Context, _ []byte, _ storage.Metadata) (string, error) {
+func (m *mockStorage) Upload(_ context.Context, data []byte, meta storage.Metadata) (string, error) {
+	if m.uploads == nil {
+		m.uploads = make(map[string][]byte)
+	}
+	m.uploads[meta.Name] = data
 	return m.contentID, m.uploadErr
 }
+func (m *mockStorage) UploadWithReceipt(ctx context.Context, data []byte, meta storage.Metadata) (storage.UploadReceipt, error) {
+	id, err := m.Upload(ctx, data, meta)
+	return storage.UploadReceipt{ContentID: id, TxHash: m.txHash, BlockNumber: m.block}, err
+}
 func (m *mockStorage) Download(_ context.Context, _ string) ([]byte, error) { return nil, nil }
+func (m *mockStorage) Close() error                                         { return nil }
 func (m *mockStorage) List(_ context.Context, _ string) ([]storage.Metadata, error) {
 	return nil, nil
 }
+func (m *mockStorage) ListByTag(_ context.Context, _, _ string) ([]storage.Metadata, error) {
+	return nil, nil
+}
+func (m *mockStorage) ListMulti(_ context.Context, _ []string) ([]storage.Metadata, error) {
+	return nil, nil
+}
 
 type mockMinter struct {
 	mintErr error
 	tokenID string
+	lastReq inft.MintRequest
 }
 
-func (m *mockMinter) Mint(_ context.Context, _ inft.MintRequest) (string, error) {
+func (m *mockMinter) Mint(_ context.Context, req inft.MintRequest) (string, error) {
+	m.lastReq = req
 	return m.tokenID, m.mintErr
 }
 func (m *mockMinter) UpdateMetadata(_ context.Context, _ string, _ inft.EncryptedMeta) error {
 	return nil
 }
+func (m *mockMinter) Close() error { return nil }
+
 func (m *mockMinter) GetStatus(_ context.Context, _ string) (*inft.INFTStatus, error) {
 	return nil, nil
 }
 
+func (m *mockMinter) VerifyProvenance(_ context.Context, _ string, _ storage.StorageClient, _ storage.HashAlgorithm) (bool, error) {
+	return true, nil
+}
+
 type mockAudit struct {
 	publishErr error
 	subID      string
+
+	mu     sync.Mutex
+	events []da.AuditEvent
 }
 
-func (m *mockAudit) Publish(_ context.Context, _ da.AuditEvent) (string, error) {
+func (m *mockAudit) Publish(_ context.Context, e da.AuditEvent) (string, error) {
+	m.mu.Lock()
+	m.events = append(m.events, e)
+	m.mu.Unlock()
 	return m.subID, m.publishErr
 }
+
+func (m *mockAudit) eventsOf(t da.EventType) []da.AuditEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []da.AuditEvent
+	for _, e := range m.events {
+		if e.Type == t {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+func (m *mockAudit) PublishWithReceipt(_ context.Context, e da.AuditEvent) (da.Submission, error) {
+	m.mu.Lock()
+	m.events = append(m.events, e)
+	m.mu.Unlock()
+	return da.Submission{ID: m.subID, EventType: e.Type, BlockHeight: 7}, m.publishErr
+}
 func (m *mockAudit) Verify(_ context.Context, _ string) (bool, error) { return true, nil }
+func (m *mockAudit) SubmissionsForTask(_ string) ([]string, error)    { return nil, nil }
+func (m *mockAudit) EstimateSubmission(events []da.AuditEvent) (da.SubmissionEstimate, error) {
+	return da.SubmissionEstimate{Events: len(events)}, nil
+}
+func (m *mockAudit) Close() error { return nil }
 
 type mockTransport struct {
 	published [][]byte
@@ -119,7 +213,7 @@ func TestProcessTask_Success(t *testing.T) {
 		testLogger(),
 		daemon.Noop(),
 		&mockCompute{jobID: "job-1", result: &compute.JobResult{
-			JobID: "job-1", Status: compute.JobStatusCompleted, Output: "hello",
+			JobID: "job-1", Status: compute.JobStatusCompleted, Output: "hello", TokensUsed: 42,
 		}},
 		&mockStorage{contentID: "cid-123"},
 		&mockMinter{tokenID: "token-456"},
@@ -135,8 +229,12 @@ func TestProcessTask_Success(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if a.completedTasks.Load() != 1 {
-		t.Errorf("expected 1 completed task, got %d", a.completedTasks.Load())
+	st := a.Stats()
+	if st.Completed != 1 {
+		t.Errorf("expected 1 completed task, got %d", st.Completed)
+	}
+	if st.TokensUsed != 42 || st.ActiveTasks != 0 {
+		t.Errorf("expected 42 tokens and no active tasks, got %d/%d", st.TokensUsed, st.ActiveTasks)
 	}
 	// Verify result was published (2 audit + 1 result = at least 1 published)
 	if len(mt.published) < 1 {
@@ -144,6 +242,125 @@ func TestProcessTask_Success(t *testing.T) {
 	}
 }
 
+// receiptTransport is a mockTransport that reports HCS receipts.
+type receiptTransport struct {
+	*mockTransport
+}
+
+func (r receiptTransport) PublishReceipt(ctx context.Context, topicID string, data []byte) (hcs.Receipt, error) {
+	r.Publish(ctx, topicID, data)
+	return hcs.Receipt{
+		TopicID:            topicID,
+		TransactionID:      "0.0.1234@1700000000.000000001",
+		SequenceNumber:     7,
+		ConsensusTimestamp: time.Unix(1700000001, 0),
+	}, nil
+}
+
+func TestProcessTask_RecordsResultReceipt(t *testing.T) {
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport:     receiptTransport{newMockTransport()},
+		ResultTopicID: "result-topic",
+		AgentID:       "test-agent",
+	})
+	aud := &mockAudit{}
+
+	a := New(testConfig(), testLogger(),
+		daemon.Noop(),
+		&mockCompute{jobID: "job-1", result: &compute.JobResult{
+			JobID: "job-1", Status: compute.JobStatusCompleted, Output: "hello",
+		}},
+		&mockStorage{contentID: "cid"}, &mockMinter{tokenID: "tok"}, aud, handler,
+	)
+
+	if err := a.processTask(context.Background(), hcs.TaskAssignment{TaskID: "task-1", ModelID: "m"}); err != nil {
+		t.Fatalf("processTask: %v", err)
+	}
+	events := aud.eventsOf(da.EventTypeResultReport)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 result_reported event, got %d", len(events))
+	}
+	d := events[0].Details
+	if events[0].TaskID != "task-1" ||
+		d["hcs_topic_id"] != "result-topic" ||
+		d["hcs_transaction_id"] != "0.0.1234@1700000000.000000001" ||
+		d["hcs_sequence_number"] != "7" ||
+		d["hcs_consensus_timestamp"] != "2023-11-14T22:13:21Z" {
+		t.Errorf("result_reported = %+v", events[0])
+	}
+}
+
+func TestProcessTask_NoReceiptNoResultReport(t *testing.T) {
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport: newMockTransport(), ResultTopicID: "r", AgentID: "a",
+	})
+	aud := &mockAudit{}
+
+	a := New(testConfig(), testLogger(),
+		daemon.Noop(),
+		&mockCompute{jobID: "job-1", result: &compute.JobResult{
+			JobID: "job-1", Status: compute.JobStatusCompleted, Output: "hello",
+		}},
+		&mockStorage{contentID: "cid"}, &mockMinter{tokenID: "tok"}, aud, handler,
+	)
+
+	if err := a.processTask(context.Background(), hcs.TaskAssignment{TaskID: "task-1", ModelID: "m"}); err != nil {
+		t.Fatalf("processTask: %v", err)
+	}
+	if n := len(aud.eventsOf(da.EventTypeResultReport)); n != 0 {
+		t.Errorf("expected no result_reported event without a receipt, got %d", n)
+	}
+}
+
+func TestProcessTask_RecordsStorageAnchor(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, ResultTopicID: "r", AgentID: "test-agent"})
+	aud := &mockAudit{}
+	a := New(testConfig(), testLogger(), daemon.Noop(),
+		&mockCompute{jobID: "job-1", result: &compute.JobResult{JobID: "job-1", Status: compute.JobStatusCompleted, Output: "hello"}},
+		&mockStorage{contentID: "cid-1", txHash: "0xabc", block: 77}, &mockMinter{tokenID: "tok-1"}, aud, handler)
+
+	if _, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "task-1", ModelID: "m"}); err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+	completed := aud.eventsOf(da.EventTypeJobCompleted)
+	if len(completed) != 1 {
+		t.Fatalf("expected 1 job_completed event, got %d", len(completed))
+	}
+	if got := completed[0].Details["storage_tx_hash"]; got != "0xabc" {
+		t.Errorf("storage_tx_hash = %q, want 0xabc", got)
+	}
+	if got := completed[0].Details["storage_block"]; got != "77" {
+		t.Errorf("storage_block = %q, want 77", got)
+	}
+}
+
+// failingTransport is a mockTransport whose publishes fail.
+type failingTransport struct{ *mockTransport }
+
+func (failingTransport) Publish(_ context.Context, _ string, _ []byte) error {
+	return errors.New("hedera down")
+}
+
+func TestProcessTask_PublishFailureCountsAsFailed(t *testing.T) {
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport: failingTransport{newMockTransport()}, ResultTopicID: "r", AgentID: "test-agent",
+	})
+	a := New(testConfig(), testLogger(), daemon.Noop(),
+		&mockCompute{jobID: "job-1", result: &compute.JobResult{JobID: "job-1", Status: compute.JobStatusCompleted, Output: "hello"}},
+		&mockStorage{contentID: "cid-1"}, &mockMinter{tokenID: "tok-1"}, &mockAudit{}, handler)
+
+	if err := a.processTask(context.Background(), hcs.TaskAssignment{TaskID: "task-1", ModelID: "m"}); err == nil {
+		t.Fatal("expected error when the result cannot be published")
+	}
+	if st := a.Stats(); st.Completed != 0 || st.Failed != 1 {
+		t.Errorf("completed = %d, failed = %d; want 0 and 1", st.Completed, st.Failed)
+	}
+	if _, err := a.ExportProvenance(context.Background(), "task-1"); !errors.Is(err, ErrProvenanceNotFound) {
+		t.Errorf("expected no provenance for an undelivered task, got %v", err)
+	}
+}
+
 func TestProcessTask_ComputeFails(t *testing.T) {
 	mt := newMockTransport()
 	handler := hcs.NewHandler(hcs.HandlerConfig{
@@ -163,6 +380,65 @@ func TestProcessTask_ComputeFails(t *testing.T) {
 	}
 }
 
+func TestProcessTaskDirect_ReturnsResultWithoutPublishing(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport: mt, ResultTopicID: "r", AgentID: "a",
+	})
+
+	aud := &mockAudit{subID: "sub-1"}
+
+	a := New(
+		testConfig(), testLogger(),
+		daemon.Noop(),
+		&mockCompute{jobID: "job-1", result: &compute.JobResult{
+			JobID: "job-1", Status: compute.JobStatusCompleted, Output: "hello", TokensUsed: 7, Provider: "0xprovider",
+			Verifiability: "TeeML",
+		}},
+		&mockStorage{contentID: "cid-1"}, &mockMinter{tokenID: "tok-1"}, aud, handler,
+	)
+
+	res, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "t1", ModelID: "m"})
+	if err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+	if res.Status != "completed" || res.Output != "hello" || res.StorageContentID != "cid-1" ||
+		res.INFTTokenID != "tok-1" || res.AuditSubmissionID != "sub-1" || res.Provider != "0xprovider" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if completed := aud.eventsOf(da.EventTypeJobCompleted); len(completed) != 1 || completed[0].Details["provider"] != "0xprovider" ||
+		completed[0].Details["verifiability"] != "TeeML" {
+		t.Errorf("expected provider and verifiability in the job_completed audit event, got %+v", completed)
+	}
+	if len(mt.published) != 0 {
+		t.Errorf("ProcessTask published %d messages, want none", len(mt.published))
+	}
+	if got := a.Stats().Completed; got != 1 {
+		t.Errorf("Completed = %d, want 1", got)
+	}
+}
+
+func TestProcessTaskDirect_Failure(t *testing.T) {
+	a := New(
+		testConfig(), testLogger(),
+		daemon.Noop(),
+		&mockCompute{submitErr: errors.New("compute down")},
+		&mockStorage{}, &mockMinter{}, &mockAudit{},
+		hcs.NewHandler(hcs.HandlerConfig{Transport: newMockTransport(), AgentID: "a"}),
+	)
+
+	res, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "t1"})
+	if err == nil {
+		t.Fatal("expected error when compute fails")
+	}
+	if res.Status != "failed" || res.TaskID != "t1" || res.Error == "" || res.Stage != StageCompute {
+		t.Errorf("unexpected failure result: %+v", res)
+	}
+	if got := a.Stats().Failed; got != 1 {
+		t.Errorf("Failed = %d, want 1", got)
+	}
+}
+
 func TestProcessTask_StorageFails(t *testing.T) {
 	mt := newMockTransport()
 	handler := hcs.NewHandler(hcs.HandlerConfig{
@@ -185,6 +461,111 @@ func TestProcessTask_StorageFails(t *testing.T) {
 	}
 }
 
+func TestProcessTask_StoragelessPublishesResult(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport: mt, ResultTopicID: "r", AgentID: "a",
+	})
+	aud := &mockAudit{subID: "aud"}
+	cfg := testConfig()
+	cfg.AllowStorageless = true
+
+	a := New(
+		cfg, testLogger(),
+		daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{
+			Status: compute.JobStatusCompleted, Output: "inline output",
+		}},
+		&mockStorage{uploadErr: errors.New("storage down")},
+		&mockMinter{tokenID: "tok"}, aud, handler,
+	)
+
+	if err := a.processTask(context.Background(), hcs.TaskAssignment{TaskID: "t1"}); err != nil {
+		t.Fatalf("processTask: %v", err)
+	}
+	if len(mt.published) != 1 {
+		t.Fatalf("published %d messages, want 1", len(mt.published))
+	}
+	env, err := hcs.UnmarshalEnvelope(mt.published[0])
+	if err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	var res hcs.TaskResult
+	if err := json.Unmarshal(env.Payload, &res); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if res.Status != "completed" || res.Output != "inline output" || res.StorageContentID != "" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	completed := aud.eventsOf(da.EventTypeJobCompleted)
+	if len(completed) != 1 || completed[0].Details["degraded"] != "storage_unavailable" {
+		t.Errorf("expected a degraded job_completed event, got %+v", completed)
+	}
+}
+
+func TestProcessTask_ResultProcessor(t *testing.T) {
+	store := &mockStorage{}
+	cfg := testConfig()
+	cfg.ResultProcessor = func(_ context.Context, task hcs.TaskAssignment, r compute.JobResult) (compute.JobResult, error) {
+		if task.TaskID != "t1" {
+			t.Errorf("processor got task %q", task.TaskID)
+		}
+		r.Output = strings.ReplaceAll(r.Output, "alice@example.com", "[redacted]")
+		return r, nil
+	}
+
+	a := New(
+		cfg, testLogger(),
+		daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{
+			Status: compute.JobStatusCompleted, Output: "mail alice@example.com",
+		}},
+		store, &mockMinter{tokenID: "tok"}, &mockAudit{},
+		hcs.NewHandler(hcs.HandlerConfig{Transport: newMockTransport(), AgentID: "a"}),
+	)
+
+	res, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "t1"})
+	if err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+	if res.Output != "mail [redacted]" {
+		t.Errorf("result output = %q, want redacted", res.Output)
+	}
+	for _, data := range store.uploads {
+		if strings.Contains(string(data), "alice") {
+			t.Errorf("unredacted output stored: %q", data)
+		}
+	}
+}
+
+func TestProcessTask_ResultProcessorFails(t *testing.T) {
+	store := &mockStorage{}
+	cfg := testConfig()
+	cfg.ResultProcessor = func(context.Context, hcs.TaskAssignment, compute.JobResult) (compute.JobResult, error) {
+		return compute.JobResult{}, errors.New("redactor unavailable")
+	}
+
+	a := New(
+		cfg, testLogger(),
+		daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{Status: compute.JobStatusCompleted, Output: "x"}},
+		store, &mockMinter{}, &mockAudit{},
+		hcs.NewHandler(hcs.HandlerConfig{Transport: newMockTransport(), AgentID: "a"}),
+	)
+
+	res, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "t1"})
+	var stageErr *StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != StagePostprocess {
+		t.Fatalf("expected postprocess stage error, got %v", err)
+	}
+	if res.Stage != StagePostprocess {
+		t.Errorf("result stage = %q, want %q", res.Stage, StagePostprocess)
+	}
+	if len(store.uploads) != 0 {
+		t.Error("nothing should be stored when the processor fails")
+	}
+}
+
 func TestProcessTask_ContextCancelled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -251,8 +632,8 @@ func TestRun_ReceivesAndProcesses(t *testing.T) {
 	if err != nil && err != context.Canceled {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if a.completedTasks.Load() != 1 {
-		t.Errorf("expected 1 completed task, got %d", a.completedTasks.Load())
+	if st := a.Stats(); st.Completed != 1 {
+		t.Errorf("expected 1 completed task, got %d", st.Completed)
 	}
 }
 
@@ -287,28 +668,1004 @@ func TestRun_GracefulShutdown(t *testing.T) {
 	}
 }
 
-func TestLoadConfig_RequiredFields(t *testing.T) {
-	os.Unsetenv("INFERENCE_AGENT_ID")
-	_, err := LoadConfig()
-	if err == nil {
-		t.Fatal("expected error when INFERENCE_AGENT_ID is missing")
+func TestRun_PublishesStoppingHealthOnShutdown(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport: mt, TaskTopicID: "t", ResultTopicID: "r", AgentID: "test-agent",
+	})
+
+	a := New(testConfig(), testLogger(),
+		daemon.Noop(),
+		&mockCompute{}, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if len(mt.published) == 0 {
+		t.Fatal("expected a final health message")
+	}
+	env, err := hcs.UnmarshalEnvelope(mt.published[len(mt.published)-1])
+	if err != nil {
+		t.Fatalf("unmarshal envelope: %v", err)
+	}
+	if env.Type != hcs.MessageTypeHeartbeat {
+		t.Fatalf("last message type = %q, want %q", env.Type, hcs.MessageTypeHeartbeat)
+	}
+	var status hcs.HealthStatus
+	if err := json.Unmarshal(env.Payload, &status); err != nil {
+		t.Fatalf("unmarshal health: %v", err)
+	}
+	if status.Status != "stopping" || status.AgentID != "test-agent" {
+		t.Errorf("final health = %+v, want status stopping for test-agent", status)
 	}
 }
 
-func TestLoadConfig_Defaults(t *testing.T) {
-	t.Setenv("INFERENCE_AGENT_ID", "test-123")
+func TestRun_ReplaysUnfinishedTaskFromWAL(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig()
+	cfg.TaskWALDir = dir
 
-	cfg, err := LoadConfig()
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	// A previous run accepted two tasks and finished one before crashing.
+	wal := taskWAL{dir: dir}
+	for _, id := range []string{"task-done", "task-unfinished"} {
+		if err := wal.record(hcs.TaskAssignment{TaskID: id, ModelID: "m1", Input: "hi"}); err != nil {
+			t.Fatal(err)
+		}
 	}
-	if cfg.AgentID != "test-123" {
-		t.Errorf("expected test-123, got %s", cfg.AgentID)
+	if err := wal.done("task-done"); err != nil {
+		t.Fatal(err)
 	}
-	if cfg.DaemonAddr != "localhost:50051" {
-		t.Errorf("expected localhost:50051, got %s", cfg.DaemonAddr)
+
+	mt := newMockTransport()
+	handler := hcs.NewHandler(cfg.HCSHandler(mt))
+	comp := &mockCompute{jobID: "j1", result: &compute.JobResult{
+		Status: compute.JobStatusCompleted, Output: "out",
+	}}
+	aud := &mockAudit{}
+	a := New(cfg, testLogger(), daemon.Noop(),
+		comp, &mockStorage{contentID: "cid"}, &mockMinter{tokenID: "tok"}, aud, handler,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for a.completedTasks.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
 	}
-	if cfg.HealthInterval != 30*time.Second {
-		t.Errorf("expected 30s, got %v", cfg.HealthInterval)
+	cancel()
+	<-done
+
+	if st := a.Stats(); st.Completed != 1 {
+		t.Fatalf("expected the unfinished task to be replayed once, completed = %d", st.Completed)
+	}
+	received := aud.eventsOf(da.EventTypeTaskReceived)
+	if comp.submitted != 1 || len(received) != 1 || received[0].TaskID != "task-unfinished" {
+		t.Errorf("submitted %d job(s) for %+v, want one for task-unfinished", comp.submitted, received)
+	}
+	pending, err := wal.pending()
+	if err != nil || len(pending) != 0 {
+		t.Errorf("WAL after replay = %v, %v; want empty", pending, err)
+	}
+}
+
+func TestRun_KeepsTaskInterruptedByShutdownInWAL(t *testing.T) {
+	cfg := testConfig()
+	cfg.TaskWALDir = t.TempDir()
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+	handler := hcs.NewHandler(cfg.HCSHandler(newMockTransport()))
+	a := New(cfg, testLogger(), daemon.Noop(),
+		&slowCompute{mockCompute{jobID: "j1"}}, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+	if err := handler.HandleTask(ctx, hcs.TaskAssignment{TaskID: "t-1", ModelID: "m", Input: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for a.activeTasks.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	pending, err := taskWAL{dir: cfg.TaskWALDir}.pending()
+	if err != nil || len(pending) != 1 || pending[0].TaskID != "t-1" {
+		t.Errorf("WAL after interrupted task = %v, %v; want t-1 kept for replay", pending, err)
+	}
+}
+
+func TestRun_SkipsRedeliveredReplayedTask(t *testing.T) {
+	cfg := testConfig()
+	cfg.TaskWALDir = t.TempDir()
+	task := hcs.TaskAssignment{TaskID: "t-1", ModelID: "m", Input: "hi"}
+	if err := (taskWAL{dir: cfg.TaskWALDir}).record(task); err != nil {
+		t.Fatal(err)
+	}
+	handler := hcs.NewHandler(cfg.HCSHandler(newMockTransport()))
+	aud := &mockAudit{}
+	a := New(cfg, testLogger(), daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{Status: compute.JobStatusCompleted, Output: "out"}},
+		&mockStorage{}, &mockMinter{}, aud, handler)
+
+	// The coordinator redelivers the task the WAL is about to replay.
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := handler.HandleTask(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+	deadline := time.Now().Add(time.Second)
+	for (a.completedTasks.Load() == 0 || handler.QueueStats().Depth > 0) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+
+	if n := len(aud.eventsOf(da.EventTypeTaskReceived)); n != 1 {
+		t.Errorf("task processed %d times, want once", n)
+	}
+}
+
+func TestHCSHandler_RecordsAcceptedTasksInWAL(t *testing.T) {
+	cfg := testConfig()
+	cfg.TaskWALDir = t.TempDir()
+	handler := hcs.NewHandler(cfg.HCSHandler(newMockTransport()))
+
+	for _, id := range []string{"first", "second"} {
+		if err := handler.HandleTask(context.Background(), hcs.TaskAssignment{TaskID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending, err := taskWAL{dir: cfg.TaskWALDir}.pending()
+	if err != nil {
+		t.Fatal(err)
 	}
+	if len(pending) != 2 || pending[0].TaskID != "first" || pending[1].TaskID != "second" {
+		t.Errorf("pending = %+v, want first then second", pending)
+	}
+}
+
+// topicTransport is a mockTransport that knows no topics.
+type topicTransport struct{ *mockTransport }
+
+func (topicTransport) TopicInfo(_ context.Context, _ string) (hcs.TopicInfo, error) {
+	return hcs.TopicInfo{}, hcs.ErrTopicNotFound
+}
+
+func TestRun_FailsFastOnMissingTopic(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport: topicTransport{mt}, TaskTopicID: "0.0.404", ResultTopicID: "0.0.2", AgentID: "test-agent",
+	})
+	a := New(testConfig(), testLogger(), daemon.Noop(), &mockCompute{}, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Run(ctx); !errors.Is(err, hcs.ErrTopicNotFound) {
+		t.Fatalf("Run() = %v, want ErrTopicNotFound", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("Run should fail before the context ends")
+	}
+}
+
+func TestRun_ExitsOnSubscriptionFailure(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport: mt, TaskTopicID: "t", ResultTopicID: "r", AgentID: "a",
+	})
+	aud := &mockAudit{}
+	cfg := testConfig()
+	cfg.ExitOnSubscriptionFailure = true
+
+	a := New(cfg, testLogger(),
+		daemon.Noop(),
+		&mockCompute{}, &mockStorage{}, &mockMinter{}, aud, handler,
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Run(context.Background())
+	}()
+	mt.subErr <- fmt.Errorf("mirror node gone: %w", hcs.ErrSubscriptionFailed)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, hcs.ErrSubscriptionFailed) {
+			t.Errorf("expected ErrSubscriptionFailed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not exit after subscription failure")
+	}
+	if len(aud.eventsOf(da.EventTypeAgentStopped)) != 1 {
+		t.Error("expected an agent_stopped audit event")
+	}
+}
+
+func TestRun_SurvivesSubscriptionFailureByDefault(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport: mt, TaskTopicID: "t", ResultTopicID: "r", AgentID: "a",
+	})
+
+	a := New(testConfig(), testLogger(),
+		daemon.Noop(),
+		&mockCompute{}, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	mt.subErr <- fmt.Errorf("mirror node gone: %w", hcs.ErrSubscriptionFailed)
+
+	select {
+	case err := <-done:
+		t.Fatalf("Run exited early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLoadConfig_RequiredFields(t *testing.T) {
+	os.Unsetenv("INFERENCE_AGENT_ID")
+	_, err := LoadConfig()
+	if err == nil {
+		t.Fatal("expected error when INFERENCE_AGENT_ID is missing")
+	}
+}
+
+func TestRun_WarmsModelCache(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{
+		Transport:     mt,
+		ResultTopicID: "result-topic",
+		AgentID:       "test-agent",
+	})
+	comp := &mockCompute{}
+	cfg := testConfig()
+	cfg.WarmModelCache = true
+	a := New(cfg, testLogger(), daemon.Noop(), comp, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.Run(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := comp.warmed.Load(); n != 1 {
+		t.Errorf("expected one startup warm-up, got %d", n)
+	}
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	t.Setenv("INFERENCE_AGENT_ID", "test-123")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AgentID != "test-123" {
+		t.Errorf("expected test-123, got %s", cfg.AgentID)
+	}
+	if cfg.DaemonAddr != "localhost:50051" {
+		t.Errorf("expected localhost:50051, got %s", cfg.DaemonAddr)
+	}
+	if cfg.HealthInterval != 30*time.Second {
+		t.Errorf("expected 30s, got %v", cfg.HealthInterval)
+	}
+	if cfg.LogLevel != slog.LevelInfo || cfg.LogFormat != "json" {
+		t.Errorf("expected info/json logging, got %v/%s", cfg.LogLevel, cfg.LogFormat)
+	}
+}
+
+func TestConfig_SetVersionUserAgent(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"default", nil, "agent-inference/v1.2.3"},
+		{"with agent ID", map[string]string{"ZG_HTTP_USER_AGENT_INCLUDE_AGENT_ID": "true"}, "agent-inference/v1.2.3 (agent test-123)"},
+		{"override", map[string]string{"ZG_HTTP_USER_AGENT": "custom/1"}, "custom/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INFERENCE_AGENT_ID", "test-123")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := cfg.SetVersion("v1.2.3"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.HTTP.UserAgent != tt.expected {
+				t.Errorf("expected user agent %q, got %q", tt.expected, cfg.HTTP.UserAgent)
+			}
+			if cfg.Compute.Transport != cfg.HTTPTransport || cfg.Storage.Transport != cfg.HTTPTransport {
+				t.Error("compute and storage transports not updated")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RejectsNonPositiveHealthInterval(t *testing.T) {
+	for _, v := range []string{"0s", "-5s"} {
+		t.Run(v, func(t *testing.T) {
+			t.Setenv("INFERENCE_AGENT_ID", "test-123")
+			t.Setenv("INFERENCE_HEALTH_INTERVAL", v)
+			_, err := LoadConfig()
+			if err == nil || !strings.Contains(err.Error(), "INFERENCE_HEALTH_INTERVAL must be positive") {
+				t.Fatalf("expected a positive-interval error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNew_ZeroHealthIntervalUsesDefault(t *testing.T) {
+	cfg := testConfig()
+	cfg.HealthInterval = 0
+	a := New(cfg, testLogger(), daemon.Noop(), &mockCompute{}, &mockStorage{}, &mockMinter{}, &mockAudit{}, nil)
+	if a.cfg.HealthInterval != DefaultHealthInterval {
+		t.Errorf("expected %v, got %v", DefaultHealthInterval, a.cfg.HealthInterval)
+	}
+}
+
+func TestLoadConfig_LogSettings(t *testing.T) {
+	t.Setenv("INFERENCE_AGENT_ID", "test-123")
+	t.Setenv("INFERENCE_LOG_LEVEL", "debug")
+	t.Setenv("INFERENCE_LOG_FORMAT", "text")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LogLevel != slog.LevelDebug {
+		t.Errorf("expected debug, got %v", cfg.LogLevel)
+	}
+	if cfg.LogFormat != "text" {
+		t.Errorf("expected text, got %s", cfg.LogFormat)
+	}
+
+	t.Setenv("INFERENCE_LOG_FORMAT", "xml")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("expected error for unknown log format")
+	}
+}
+
+func TestLoadConfig_KeyAndMnemonicConflict(t *testing.T) {
+	t.Setenv("INFERENCE_AGENT_ID", "test-123")
+	t.Setenv("ZG_CHAIN_PRIVATE_KEY", "0xabc")
+	t.Setenv("ZG_CHAIN_MNEMONIC", "test test test test test test test test test test test junk")
+
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected error when both private key and mnemonic are set")
+	}
+}
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, AgentID: "test-agent"})
+	comp := &mockCompute{}
+	a := New(testConfig(), testLogger(), daemon.Noop(), comp, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler)
+	srv := HealthHandler(a.ReadinessChecks(), a.Stats)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if code := get("/livez"); code != http.StatusOK {
+		t.Errorf("livez: expected 200, got %d", code)
+	}
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz before start: expected 503, got %d", code)
+	}
+
+	a.subscribed.Store(true)
+	comp.models = []compute.Model{{ID: "m1"}}
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Errorf("readyz when ready: expected 200, got %d", code)
+	}
+
+	a.subscribed.Store(false)
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz after subscription loss: expected 503, got %d", code)
+	}
+	if code := get("/stats"); code != http.StatusOK {
+		t.Errorf("stats: expected 200, got %d", code)
+	}
+}
+
+func TestLoadConfig_ChainID(t *testing.T) {
+	t.Setenv("INFERENCE_AGENT_ID", "test-123")
+	t.Setenv("ZG_CHAIN_ID", "16661")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Compute.ChainID != 16661 || cfg.Storage.ChainID != 16661 || cfg.INFT.ChainID != 16661 || cfg.DA.ChainID != 16661 {
+		t.Errorf("expected chain ID 16661 on all services, got compute=%d storage=%d inft=%d da=%d",
+			cfg.Compute.ChainID, cfg.Storage.ChainID, cfg.INFT.ChainID, cfg.DA.ChainID)
+	}
+
+	t.Setenv("ZG_CHAIN_ID", "galileo")
+	if _, err := LoadConfig(); err == nil {
+		t.Error("expected error for non-numeric ZG_CHAIN_ID")
+	}
+}
+
+func TestProcessTask_CancelsProviderJob(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, AgentID: "test-agent"})
+	comp := &mockCompute{jobID: "job-9", resultErr: context.Canceled}
+	a := New(testConfig(), testLogger(), daemon.Noop(), comp, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := a.processTask(ctx, hcs.TaskAssignment{TaskID: "task-9", ModelID: "m"}); err == nil {
+		t.Fatal("expected error for cancelled task")
+	}
+	if len(comp.cancelled) != 1 || comp.cancelled[0] != "job-9" {
+		t.Errorf("expected provider cancellation for job-9, got %v", comp.cancelled)
+	}
+}
+
+// slowCompute never finishes a job on its own; GetResult returns only when
+// the task context ends.
+type slowCompute struct{ mockCompute }
+
+func (m *slowCompute) GetResult(ctx context.Context, _ string) (*compute.JobResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestProcessTask_MaxTaskDurationTimesOut(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, ResultTopicID: "r", AgentID: "test-agent"})
+	comp := &slowCompute{mockCompute{jobID: "job-slow"}}
+	store := &mockStorage{contentID: "cid-1"}
+	aud := &mockAudit{}
+	cfg := testConfig()
+	cfg.MaxTaskDuration = 20 * time.Millisecond
+	a := New(cfg, testLogger(), daemon.Noop(), comp, store, &mockMinter{}, aud, handler)
+
+	res, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "task-slow", ModelID: "m"})
+	if !errors.Is(err, ErrTaskTimeout) {
+		t.Fatalf("expected ErrTaskTimeout, got %v", err)
+	}
+	if res.Status != "timeout" {
+		t.Errorf("status = %q, want timeout", res.Status)
+	}
+	if len(comp.cancelled) != 1 || comp.cancelled[0] != "job-slow" {
+		t.Errorf("expected provider cancellation for job-slow, got %v", comp.cancelled)
+	}
+	if len(store.uploads) != 0 {
+		t.Error("storage upload should not run after the deadline")
+	}
+	failed := aud.eventsOf(da.EventTypeJobFailed)
+	if len(failed) != 1 {
+		t.Fatalf("expected 1 job_failed event, got %d", len(failed))
+	}
+	if failed[0].Details["reason"] != "timeout" || failed[0].Details["max_duration"] != "20ms" {
+		t.Errorf("unexpected job_failed details: %v", failed[0].Details)
+	}
+
+	a.handleTask(context.Background(), hcs.TaskAssignment{TaskID: "task-slow-2", ModelID: "m"})
+	if len(mt.published) != 1 {
+		t.Fatalf("expected 1 published result, got %d", len(mt.published))
+	}
+	env, err := hcs.UnmarshalEnvelope(mt.published[0])
+	if err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	var published hcs.TaskResult
+	if err := json.Unmarshal(env.Payload, &published); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if published.Status != "timeout" {
+		t.Errorf("published status = %q, want timeout", published.Status)
+	}
+}
+
+func TestProcessTask_ResultHash(t *testing.T) {
+	output := "hash me"
+	sum := sha256.Sum256([]byte(output))
+	want := hex.EncodeToString(sum[:])
+
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, AgentID: "test-agent"})
+	minter := &mockMinter{tokenID: "token-1"}
+	a := New(testConfig(), testLogger(), daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{JobID: "j1", Output: output}},
+		&mockStorage{contentID: want}, minter, &mockAudit{}, handler)
+
+	if err := a.processTask(context.Background(), hcs.TaskAssignment{TaskID: "t1", ModelID: "m"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if minter.lastReq.ResultHash != want {
+		t.Errorf("expected ResultHash %s, got %s", want, minter.lastReq.ResultHash)
+	}
+}
+
+func TestProcessTask_ResultHashMismatch(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, AgentID: "test-agent"})
+	otherSum := sha256.Sum256([]byte("something else"))
+	a := New(testConfig(), testLogger(), daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{JobID: "j1", Output: "hash me"}},
+		&mockStorage{contentID: hex.EncodeToString(otherSum[:])}, &mockMinter{}, &mockAudit{}, handler)
+
+	err := a.processTask(context.Background(), hcs.TaskAssignment{TaskID: "t1", ModelID: "m"})
+	if !errors.Is(err, storage.ErrIntegrity) {
+		t.Fatalf("expected ErrIntegrity, got %v", err)
+	}
+}
+
+func TestProcessTask_ContentIDUsesStorageHashAlgorithm(t *testing.T) {
+	output := "hash me"
+	contentID, err := storage.ContentID(storage.HashKeccak256, []byte(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.Storage.HashAlgorithm = storage.HashKeccak256
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: newMockTransport(), AgentID: "test-agent"})
+	minter := &mockMinter{tokenID: "token-1"}
+	a := New(cfg, testLogger(), daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{JobID: "j1", Output: output}},
+		&mockStorage{contentID: contentID}, minter, &mockAudit{}, handler)
+
+	if err := a.processTask(context.Background(), hcs.TaskAssignment{TaskID: "t1", ModelID: "m"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The iNFT result hash is the content ID, whatever the algorithm.
+	if minter.lastReq.ResultHash != contentID {
+		t.Errorf("expected ResultHash %s, got %s", contentID, minter.lastReq.ResultHash)
+	}
+	rec, err := a.ExportProvenance(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("ExportProvenance: %v", err)
+	}
+	if rec.ResultHash != contentID {
+		t.Errorf("expected provenance ResultHash %s, got %s", contentID, rec.ResultHash)
+	}
+}
+
+func TestExportProvenance(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, AgentID: "test-agent"})
+	store := &mockStorage{contentID: "cid-1"}
+	a := New(testConfig(), testLogger(), daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{JobID: "j1", Output: "out"}},
+		store, &mockMinter{tokenID: "token-1"}, &mockAudit{subID: "sub-1"}, handler)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.SetProvenanceKey(key)
+
+	if _, err := a.ExportProvenance(context.Background(), "t1"); !errors.Is(err, ErrProvenanceNotFound) {
+		t.Fatalf("expected ErrProvenanceNotFound before processing, got %v", err)
+	}
+	if err := a.processTask(context.Background(), hcs.TaskAssignment{TaskID: "t1", ModelID: "m"}); err != nil {
+		t.Fatalf("processTask: %v", err)
+	}
+
+	rec, err := a.ExportProvenance(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("ExportProvenance: %v", err)
+	}
+	if rec.JobID != "j1" || rec.StorageContentID != "cid-1" || rec.INFTTokenID != "token-1" || rec.ResultHash != sha256Hex("out") {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	if rec.DASubmissionID != "sub-1" || rec.DABlockHeight != 7 {
+		t.Errorf("expected DA submission in record, got %+v", rec)
+	}
+
+	digest, err := rec.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := hexutil.Decode(rec.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := crypto.PubkeyToAddress(*pub).Hex(); got != rec.Signer {
+		t.Errorf("signature recovers %s, want %s", got, rec.Signer)
+	}
+
+	stored, ok := store.uploads["provenance-t1"]
+	if !ok {
+		t.Fatal("expected provenance uploaded to storage")
+	}
+	var decoded ProvenanceRecord
+	if err := json.Unmarshal(stored, &decoded); err != nil {
+		t.Fatalf("decode stored provenance: %v", err)
+	}
+	if decoded.Signature != rec.Signature {
+		t.Error("stored provenance differs from exported record")
+	}
+}
+
+func TestParseContextWindows(t *testing.T) {
+	got, err := parseContextWindows("qwen2.5:7b=32768, meta-llama/Llama-3.3-70B-Instruct=131072")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["qwen2.5:7b"] != 32768 || got["meta-llama/Llama-3.3-70B-Instruct"] != 131072 {
+		t.Errorf("unexpected windows: %v", got)
+	}
+	for _, bad := range []string{"m", "=10", "m=abc", "m=0"} {
+		if _, err := parseContextWindows(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestHealthScore(t *testing.T) {
+	w := DefaultHealthWeights
+	tests := []struct {
+		name        string
+		failureRate float64
+		subscribed  bool
+		chainOK     bool
+		want        int
+	}{
+		{"healthy", 0, true, true, 100},
+		{"half failing", 0.5, true, true, 75},
+		{"unsubscribed", 0, false, true, 75},
+		{"chain down", 0, true, false, 75},
+		{"everything down", 1, false, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.score(tt.failureRate, tt.subscribed, tt.chainOK); got != tt.want {
+				t.Errorf("score = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgent_HealthScore(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, AgentID: "test-agent"})
+	a := New(testConfig(), testLogger(), daemon.Noop(), &mockCompute{}, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler)
+	a.subscribed.Store(true)
+	a.SetChainCheck(func(context.Context) error { return errors.New("rpc down") })
+
+	a.outcomes.record(false)
+	a.outcomes.record(true)
+
+	// failure 50*(1-0.5) + subscription 25 + chain 0 = 50
+	if got := a.HealthScore(context.Background()); got != 50 {
+		t.Errorf("HealthScore = %d, want 50", got)
+	}
+}
+
+func TestParseHealthWeights(t *testing.T) {
+	w, err := parseHealthWeights("failure=80, chain=0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w != (HealthWeights{Failure: 80, Subscription: 25, Chain: 0}) {
+		t.Errorf("unexpected weights: %+v", w)
+	}
+	for _, bad := range []string{"failure", "failure=-1", "latency=10"} {
+		if _, err := parseHealthWeights(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestRun_LifecycleAuditEvents(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, AgentID: "a"})
+	aud := &mockAudit{publishErr: errors.New("DA down")}
+	cfg := testConfig()
+	cfg.Version = "v1.2.3"
+	a := New(cfg, testLogger(), daemon.Noop(),
+		&mockCompute{}, &mockStorage{}, &mockMinter{}, aud, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for len(aud.eventsOf(da.EventTypeAgentStarted)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for agent_started event")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected context.Canceled despite DA failure, got %v", err)
+	}
+
+	stopped := aud.eventsOf(da.EventTypeAgentStopped)
+	if len(stopped) != 1 {
+		t.Fatalf("expected one agent_stopped event, got %d", len(stopped))
+	}
+	if stopped[0].Details["version"] != "v1.2.3" || stopped[0].Details["config_fingerprint"] != cfg.Fingerprint() {
+		t.Errorf("unexpected details: %v", stopped[0].Details)
+	}
+}
+
+func TestProcessTask_InlineStorageThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantInline bool
+	}{
+		{"small output embedded", "tiny", true},
+		{"large output stored", strings.Repeat("x", 64), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := hcs.NewHandler(hcs.HandlerConfig{Transport: newMockTransport(), AgentID: "a"})
+			store := &mockStorage{contentID: sha256Hex(tt.output)}
+			mint := &mockMinter{tokenID: "nft-1"}
+			aud := &mockAudit{}
+			cfg := testConfig()
+			cfg.InlineStorageThreshold = 16
+			a := New(cfg, testLogger(), daemon.Noop(),
+				&mockCompute{jobID: "j1", result: &compute.JobResult{
+					Status: compute.JobStatusCompleted, Output: tt.output,
+				}},
+				store, mint, aud, handler)
+
+			res, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "t1", ModelID: "m", Input: "hi"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, uploaded := store.uploads["inference-t1"]; uploaded == tt.wantInline {
+				t.Errorf("result uploaded = %v, want %v", uploaded, !tt.wantInline)
+			}
+			if tt.wantInline {
+				if res.StorageContentID != "" || mint.lastReq.StorageContentID != "" {
+					t.Errorf("expected empty content ID, got %q / %q", res.StorageContentID, mint.lastReq.StorageContentID)
+				}
+				if mint.lastReq.PlaintextMeta["output"] != tt.output {
+					t.Errorf("expected output in iNFT metadata, got %v", mint.lastReq.PlaintextMeta)
+				}
+				if d := aud.eventsOf(da.EventTypeJobCompleted)[0].Details; d["storage"] != "inline" {
+					t.Errorf("expected storage=inline audit detail, got %v", d)
+				}
+			} else {
+				if res.StorageContentID != sha256Hex(tt.output) {
+					t.Errorf("expected storage content ID, got %q", res.StorageContentID)
+				}
+				if _, ok := mint.lastReq.PlaintextMeta["output"]; ok {
+					t.Error("stored output should not be embedded in iNFT metadata")
+				}
+			}
+			if mint.lastReq.ResultHash != sha256Hex(tt.output) {
+				t.Errorf("result hash mismatch: %s", mint.lastReq.ResultHash)
+			}
+		})
+	}
+}
+
+func TestRun_WritesShutdownReport(t *testing.T) {
+	mt := newMockTransport()
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: mt, AgentID: "a"})
+	aud := &mockAudit{}
+	cfg := testConfig()
+	cfg.ShutdownReportFile = filepath.Join(t.TempDir(), "report.json")
+	a := New(cfg, testLogger(), daemon.Noop(),
+		&mockCompute{jobID: "j1", result: &compute.JobResult{
+			Status: compute.JobStatusCompleted, Output: "out", TokensUsed: 7,
+		}}, &mockStorage{}, &mockMinter{}, aud, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+	if err := handler.HandleTask(ctx, hcs.TaskAssignment{TaskID: "t-1", ModelID: "m", Input: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for a.Stats().Completed == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for task completion")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	data, err := os.ReadFile(cfg.ShutdownReportFile)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var report ShutdownReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unmarshal report: %v", err)
+	}
+	if report.AgentID != cfg.AgentID || report.Completed != 1 || report.Failed != 0 || report.TokensUsed != 7 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.Reason != context.Canceled.Error() || report.StartedAt.IsZero() {
+		t.Errorf("unexpected reason or start time: %+v", report)
+	}
+
+	stopped := aud.eventsOf(da.EventTypeAgentStopped)
+	if len(stopped) != 1 || stopped[0].Details["completed"] != "1" {
+		t.Errorf("expected agent_stopped with completed=1, got %v", stopped)
+	}
+}
+
+// gateCompute holds each submission until release is closed, signalling
+// started as one begins.
+type gateCompute struct {
+	mockCompute
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *gateCompute) SubmitJob(ctx context.Context, req compute.JobRequest) (string, error) {
+	m.started <- struct{}{}
+	<-m.release
+	return m.mockCompute.SubmitJob(ctx, req)
+}
+
+func TestRun_StartsNoTaskAfterShutdownBegins(t *testing.T) {
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: newMockTransport(), AgentID: "a"})
+	aud := &mockAudit{}
+	comp := &gateCompute{
+		mockCompute: mockCompute{jobID: "j1", result: &compute.JobResult{Status: compute.JobStatusCompleted, Output: "out"}},
+		started:     make(chan struct{}, 3),
+		release:     make(chan struct{}),
+	}
+	a := New(testConfig(), testLogger(), daemon.Noop(), comp, &mockStorage{}, &mockMinter{}, aud, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+	for _, id := range []string{"t-1", "t-2", "t-3"} {
+		if err := handler.HandleTask(ctx, hcs.TaskAssignment{TaskID: id, ModelID: "m", Input: "hi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-comp.started
+	cancel()
+	close(comp.release)
+	<-done
+
+	if comp.submitted != 1 {
+		t.Errorf("submitted %d jobs, want only the in-flight one", comp.submitted)
+	}
+	stopped := aud.eventsOf(da.EventTypeAgentStopped)
+	if len(stopped) != 1 || stopped[0].Details["abandoned_tasks"] != "t-2,t-3" {
+		t.Errorf("expected t-2 and t-3 abandoned, got %v", stopped)
+	}
+}
+
+func TestShutdownReport_ListsAbandonedTasks(t *testing.T) {
+	handler := hcs.NewHandler(hcs.HandlerConfig{Transport: newMockTransport(), AgentID: "a"})
+	a := New(testConfig(), testLogger(), daemon.Noop(),
+		&mockCompute{}, &mockStorage{}, &mockMinter{}, &mockAudit{}, handler)
+
+	a.inflight.Store("running", struct{}{})
+	for _, id := range []string{"queued-1", "queued-2"} {
+		if err := handler.HandleTask(context.Background(), hcs.TaskAssignment{TaskID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := a.shutdownReport(context.Canceled)
+	want := []string{"running", "queued-1", "queued-2"}
+	if !slices.Equal(report.Abandoned, want) {
+		t.Errorf("abandoned = %v, want %v", report.Abandoned, want)
+	}
+	if handler.QueueStats().Depth != 0 {
+		t.Error("expected queued tasks to be drained")
+	}
+	if report.details()["abandoned_tasks"] != "running,queued-1,queued-2" {
+		t.Errorf("unexpected details: %v", report.details())
+	}
+}
+
+func TestConfig_FingerprintExcludesSecrets(t *testing.T) {
+	cfg := testConfig()
+	base := cfg.Fingerprint()
+
+	cfg.INFT.PrivateKey = "deadbeef"
+	cfg.ChainMnemonic = "test test test"
+	if cfg.Fingerprint() != base {
+		t.Error("fingerprint changed with secret fields")
+	}
+	cfg.HCSTaskTopic = "0.0.999"
+	if cfg.Fingerprint() == base {
+		t.Error("fingerprint did not change with task topic")
+	}
+}
+
+func TestLoadConfig_PrivateKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("  0xabc123\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INFERENCE_AGENT_ID", "test-123")
+	t.Setenv("ZG_CHAIN_PRIVATE_KEY_FILE", path)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.INFT.PrivateKey != "0xabc123" || cfg.Compute.PrivateKey != "0xabc123" {
+		t.Errorf("expected trimmed key from file, got %q", cfg.INFT.PrivateKey)
+	}
+}
+
+func TestSecretEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(path, []byte("s3cret\n"), 0o600)
+
+	t.Setenv("TEST_SECRET", "")
+	t.Setenv("TEST_SECRET_FILE", path)
+	if got, err := SecretEnv("TEST_SECRET"); err != nil || got != "s3cret" {
+		t.Errorf("SecretEnv = %q, %v; want s3cret", got, err)
+	}
+
+	t.Setenv("TEST_SECRET", "inline")
+	if _, err := SecretEnv("TEST_SECRET"); err == nil {
+		t.Error("expected error when both value and file are set")
+	}
+
+	t.Setenv("TEST_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("TEST_SECRET", "")
+	if _, err := SecretEnv("TEST_SECRET"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestRecent_EvictsOldest(t *testing.T) {
+	r := newRecent[ProvenanceRecord](2)
+	for _, id := range []string{"t1", "t2", "t3"} {
+		r.add(id, ProvenanceRecord{TaskID: id})
+	}
+	if r.has("t1") {
+		t.Error("oldest record should have been evicted")
+	}
+	if rec, ok := r.get("t3"); !ok || rec.TaskID != "t3" {
+		t.Errorf("get(t3) = %+v, %v", rec, ok)
+	}
+}
+
+// sha256Hex returns the result hash of output under the default storage
+// hash algorithm.
+func sha256Hex(output string) string {
+	sum := sha256.Sum256([]byte(output))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/hcs"
+	"github.com/lancekrogers/agent-inference/internal/zerog/compute"
+	"github.com/lancekrogers/agent-inference/internal/zerog/da"
+)
+
+// auditCompleted records a finished run as a job_completed audit event,
+// leaving the submission in run. A failed publish is logged, not fatal.
+func (a *Agent) auditCompleted(ctx context.Context, run *taskRun) {
+	task := run.task
+	sub, err := a.audit.PublishWithReceipt(ctx, da.AuditEvent{
+		Type:       da.EventTypeJobCompleted,
+		AgentID:    a.cfg.AgentID,
+		TaskID:     task.TaskID,
+		JobID:      run.jobID,
+		OutputHash: run.resultHash,
+		StorageRef: run.stored.contentID,
+		INFTRef:    run.tokenID,
+		Details:    completionDetails(run.result, run.stored),
+		Timestamp:  time.Now(),
+	})
+	if err != nil {
+		a.log.Warn("audit publish failed", "task_id", task.TaskID, "error", err)
+		return
+	}
+	a.log.Info("audit event recorded",
+		"task_id", task.TaskID,
+		"submission_id", sub.ID,
+		"block_height", sub.BlockHeight)
+	run.auditSub = sub
+}
+
+// completionDetails builds the job_completed event details, or nil when
+// there are none.
+func completionDetails(result *compute.JobResult, stored storedOutput) map[string]string {
+	details := make(map[string]string, 6)
+	if result.FinishReason != "" {
+		details["finish_reason"] = result.FinishReason
+	}
+	if result.Provider != "" {
+		details["provider"] = result.Provider
+	}
+	if result.Verifiability != "" {
+		details["verifiability"] = result.Verifiability
+	}
+	if stored.inline {
+		details["storage"] = "inline"
+	}
+	if stored.upload.TxHash != "" {
+		details["storage_tx_hash"] = stored.upload.TxHash
+		details["storage_block"] = strconv.FormatUint(stored.upload.BlockNumber, 10)
+	}
+	if stored.err != nil {
+		details["degraded"] = "storage_unavailable"
+		details["storage_error"] = stored.err.Error()
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// auditTimeout records a task that overran MaxTaskDuration as a job_failed
+// audit event.
+func (a *Agent) auditTimeout(ctx context.Context, task hcs.TaskAssignment, err error) {
+	a.log.Warn("task timed out", "task_id", task.TaskID, "max_duration", a.cfg.MaxTaskDuration)
+	if _, auditErr := a.audit.Publish(ctx, da.AuditEvent{
+		Type:    da.EventTypeJobFailed,
+		AgentID: a.cfg.AgentID,
+		TaskID:  task.TaskID,
+		Details: map[string]string{
+			"reason":       "timeout",
+			"max_duration": a.cfg.MaxTaskDuration.String(),
+			"error":        err.Error(),
+		},
+		Timestamp: time.Now(),
+	}); auditErr != nil {
+		a.log.Warn("audit publish failed", "task_id", task.TaskID, "error", auditErr)
+	}
+}
+
+// recordResultReceipt logs where on HCS a result landed and records it as
+// a result_reported audit event. Transports that give no receipt are
+// skipped, as there is nothing to trace.
+func (a *Agent) recordResultReceipt(ctx context.Context, task hcs.TaskAssignment, receipt hcs.Receipt) {
+	if receipt.TransactionID == "" {
+		return
+	}
+	details := map[string]string{
+		"hcs_topic_id":        receipt.TopicID,
+		"hcs_transaction_id":  receipt.TransactionID,
+		"hcs_sequence_number": strconv.FormatUint(receipt.SequenceNumber, 10),
+	}
+	if !receipt.ConsensusTimestamp.IsZero() {
+		details["hcs_consensus_timestamp"] = receipt.ConsensusTimestamp.UTC().Format(time.RFC3339Nano)
+	}
+	a.log.Info("result published",
+		"task_id", task.TaskID,
+		"transaction_id", receipt.TransactionID,
+		"sequence_number", receipt.SequenceNumber)
+	if _, err := a.audit.Publish(ctx, da.AuditEvent{
+		Type:      da.EventTypeResultReport,
+		AgentID:   a.cfg.AgentID,
+		TaskID:    task.TaskID,
+		Details:   details,
+		Timestamp: time.Now(),
+	}); err != nil {
+		a.log.Warn("audit publish failed", "task_id", task.TaskID, "error", err)
+	}
+}
+
+// publishLifecycle records an agent lifecycle event on DA, logging rather
+// than returning failures.
+func (a *Agent) publishLifecycle(ctx context.Context, eventType da.EventType, extra map[string]string) {
+	details := map[string]string{
+		"version":            a.cfg.Version,
+		"config_fingerprint": a.cfg.Fingerprint(),
+	}
+	for k, v := range extra {
+		details[k] = v
+	}
+	_, err := a.audit.Publish(ctx, da.AuditEvent{
+		Type:      eventType,
+		AgentID:   a.cfg.AgentID,
+		Details:   details,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		a.log.Warn("lifecycle audit publish failed", "event", eventType, "error", err)
+	}
+}
@@ -1,39 +1,178 @@
 package agent
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lancekrogers/agent-inference/internal/hcs"
+	"github.com/lancekrogers/agent-inference/internal/zerog"
 	"github.com/lancekrogers/agent-inference/internal/zerog/compute"
 	"github.com/lancekrogers/agent-inference/internal/zerog/da"
 	"github.com/lancekrogers/agent-inference/internal/zerog/inft"
 	"github.com/lancekrogers/agent-inference/internal/zerog/storage"
 )
 
+// ResultProcessor rewrites an inference result before it is stored,
+// minted, and reported — for example to redact or truncate the output.
+type ResultProcessor func(ctx context.Context, task hcs.TaskAssignment, result compute.JobResult) (compute.JobResult, error)
+
+// DefaultHealthInterval is the health report period when none is set.
+const DefaultHealthInterval = 30 * time.Second
+
+// DefaultShutdownTimeout bounds shutdown when Config.ShutdownTimeout is unset.
+const DefaultShutdownTimeout = 10 * time.Second
+
 // Config holds all configuration for the inference agent.
 type Config struct {
-	AgentID        string
-	DaemonAddr     string
+	AgentID    string
+	DaemonAddr string
+	// HealthInterval is the period between health reports. New replaces
+	// a non-positive value with DefaultHealthInterval.
 	HealthInterval time.Duration
+	// HealthWeights weighs the signals in the reported health score.
+	HealthWeights  HealthWeights
+	LogLevel       slog.Level
+	LogFormat      string
 	Compute        compute.BrokerConfig
 	Storage        storage.ClientConfig
 	INFT           inft.MinterConfig
 	DA             da.PublisherConfig
 	HCSTaskTopic   string
 	HCSResultTopic string
-}
 
-// HCSHandler builds an HCS handler config from the agent config.
-func (c *Config) HCSHandler(transport hcs.Transport) hcs.HandlerConfig {
-	return hcs.HandlerConfig{
-		Transport:     transport,
-		TaskTopicID:   c.HCSTaskTopic,
-		ResultTopicID: c.HCSResultTopic,
-		AgentID:       c.AgentID,
-	}
+	// ChainMnemonic is a BIP-39 mnemonic used to derive the 0G Chain signing
+	// key, as an alternative to a raw ZG_CHAIN_PRIVATE_KEY.
+	ChainMnemonic string
+	// ChainDerivationPath is the BIP-32 path applied to ChainMnemonic.
+	ChainDerivationPath string
+
+	// HTTP configures the proxy, trusted CAs, certificate pins, and
+	// User-Agent for all outbound HTTP: compute providers, the storage
+	// node, and chain RPC.
+	HTTP zerog.HTTPConfig
+	// HTTPTransport is built from HTTP by LoadConfig (and SetVersion) and
+	// already set on the compute and storage configs. Nil means
+	// http.DefaultTransport.
+	HTTPTransport http.RoundTripper
+
+	// MaxInflightTx caps concurrent on-chain transactions across iNFT
+	// minting, storage anchoring, and DA submission. Zero means no limit.
+	MaxInflightTx int
+	// TxLimiter enforces MaxInflightTx. LoadConfig builds it and sets it
+	// on the iNFT, storage, and DA configs.
+	TxLimiter *zerog.TxLimiter
+
+	// RemoteSignerURL is a clef-compatible JSON-RPC signer endpoint. When
+	// set, storage, iNFT, and DA transactions are signed remotely.
+	RemoteSignerURL string
+	// RemoteSignerAddress is the account the remote signer signs for.
+	RemoteSignerAddress string
+
+	// Groups are the coordinator groups this agent belongs to; tasks
+	// addressed to "group:<name>" are accepted for any listed name.
+	Groups []string
+
+	// HealthAddr is the listen address for the /livez and /readyz HTTP
+	// server. Empty disables it.
+	HealthAddr string
+
+	// TasksFile replaces the live HCS transport with a file replay: each
+	// line is a task envelope emitted in order. Empty uses HCS.
+	TasksFile string
+	// ResultsFile captures published messages, one per line, in replay mode
+	// or when HCS falls back to the recording transport.
+	ResultsFile string
+
+	// SequenceFile persists the HCS envelope sequence number across restarts.
+	SequenceFile string
+	// TaskWALDir, if set, holds a write-ahead log of accepted tasks. Each
+	// task is recorded before it is queued and removed once processed;
+	// Run replays whatever a crash left behind before taking new tasks.
+	TaskWALDir string
+
+	// TaskBuffer is the HCS task queue size. Zero uses the handler default.
+	TaskBuffer int
+
+	// TaskReorderWindow is how long HCS task messages are held for
+	// consensus-order delivery. Zero uses the handler default.
+	TaskReorderWindow time.Duration
+
+	// MaxInlineResultBytes caps the output carried in HCS result messages;
+	// longer outputs are truncated with a marker (or without one, if the
+	// cap is too small for it) when the full output is in storage. Zero
+	// means no truncation.
+	MaxInlineResultBytes int
+
+	// InlineStorageThreshold is the output size in bytes below which the
+	// result skips 0G Storage and is embedded in the encrypted iNFT
+	// metadata under "output", with an empty storage content ID. Zero
+	// always uses storage.
+	InlineStorageThreshold int
+
+	// ShutdownReportFile receives a JSON ShutdownReport when Run returns.
+	// Empty skips the file; the report's counters are still attached to
+	// the agent_stopped audit event.
+	ShutdownReportFile string
+
+	// ShutdownTimeout bounds the ordered shutdown: draining the in-flight
+	// task, publishing the final "stopping" health status, and tearing
+	// down the subscription. New replaces a non-positive value with
+	// DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+
+	// MaxTaskDuration caps how long one task may run, from input
+	// validation through the audit event. A task that overruns it is
+	// cancelled and reported with status "timeout". Zero means no limit.
+	MaxTaskDuration time.Duration
+
+	// WarmModelCache fills the compute model cache before the agent
+	// accepts tasks and refreshes it ahead of compute.ModelCacheTTL, so
+	// no task waits on provider discovery.
+	WarmModelCache bool
+
+	// AuditFile receives a JSON line for every audit event, in addition
+	// to the DA submission. Empty disables the file.
+	AuditFile string
+
+	// AuditStdout writes every audit event to stdout as a JSON line, in
+	// addition to the DA submission.
+	AuditStdout bool
+
+	// AllowStorageless keeps a task alive when the 0G Storage upload fails:
+	// the result is published with its inline output and no content ID, and
+	// the completion audit event is marked degraded.
+	AllowStorageless bool
+
+	// ExitOnSubscriptionFailure makes Run return an error when the HCS task
+	// subscription dies, so the process exits non-zero and its supervisor
+	// restarts it. Otherwise the agent keeps running without new tasks.
+	ExitOnSubscriptionFailure bool
+
+	// InputValidators maps model IDs to checks run on task input before it
+	// is submitted to compute. Models without an entry are not checked.
+	InputValidators map[string]InputValidator
+
+	// ResultProcessor, when set, is applied to every inference result
+	// before storage. An error fails the task. Set in code, not the
+	// environment.
+	ResultProcessor ResultProcessor
+
+	// Version is the agent build version, recorded in lifecycle audit
+	// events and the default User-Agent. Set by the binary via SetVersion,
+	// not the environment.
+	Version string
+
+	// UserAgentIncludeAgentID appends the agent ID to the default
+	// User-Agent.
+	UserAgentIncludeAgentID bool
 }
 
 // LoadConfig reads configuration from environment variables.
@@ -44,70 +183,147 @@ func LoadConfig() (*Config, error) {
 	if cfg.AgentID == "" {
 		return nil, fmt.Errorf("config: INFERENCE_AGENT_ID is required")
 	}
+	for _, load := range []func() error{cfg.loadAgentEnv, cfg.loadRuntimeEnv, cfg.loadHCSEnv, cfg.loadHTTPEnv} {
+		if err := load(); err != nil {
+			return nil, err
+		}
+	}
+	chain, err := cfg.loadChainEnv()
+	if err != nil {
+		return nil, err
+	}
+	for _, load := range []func(chainEnv) error{cfg.loadComputeEnv, cfg.loadStorageEnv, cfg.loadINFTEnv, cfg.loadDAEnv} {
+		if err := load(chain); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// loadAgentEnv reads the agent's own INFERENCE_* settings: addresses,
+// state files, and how results are handled.
+func (c *Config) loadAgentEnv() error {
+	c.DaemonAddr = envOr("INFERENCE_DAEMON_ADDR", "localhost:50051")
+	c.HealthAddr = os.Getenv("INFERENCE_HEALTH_ADDR")
+	c.TasksFile = os.Getenv("INFERENCE_TASKS_FILE")
+	c.ResultsFile = os.Getenv("INFERENCE_RESULTS_FILE")
+	c.TaskWALDir = os.Getenv("INFERENCE_TASK_WAL_DIR")
+	c.ShutdownReportFile = os.Getenv("INFERENCE_SHUTDOWN_REPORT_FILE")
+	c.AuditFile = os.Getenv("INFERENCE_AUDIT_FILE")
+	c.WarmModelCache = os.Getenv("INFERENCE_WARM_MODEL_CACHE") == "true"
+	c.AuditStdout = os.Getenv("INFERENCE_AUDIT_STDOUT") == "true"
 
-	cfg.DaemonAddr = envOr("INFERENCE_DAEMON_ADDR", "localhost:50051")
+	c.AllowStorageless = os.Getenv("INFERENCE_ALLOW_STORAGELESS") == "true"
+	if v := os.Getenv("INFERENCE_MAX_INLINE_RESULT_BYTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("config: INFERENCE_MAX_INLINE_RESULT_BYTES must be a non-negative integer, got %q", v)
+		}
+		c.MaxInlineResultBytes = n
+	}
+	if v := os.Getenv("INFERENCE_INLINE_STORAGE_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("config: INFERENCE_INLINE_STORAGE_THRESHOLD must be a non-negative integer, got %q", v)
+		}
+		c.InlineStorageThreshold = n
+	}
+	validators, err := parseInputFormats(os.Getenv("INFERENCE_INPUT_FORMATS"))
+	if err != nil {
+		return fmt.Errorf("config: invalid INFERENCE_INPUT_FORMATS: %w", err)
+	}
+	c.InputValidators = validators
+	c.ExitOnSubscriptionFailure = os.Getenv("INFERENCE_EXIT_ON_SUBSCRIPTION_FAILURE") == "true"
+	return nil
+}
 
+// loadRuntimeEnv reads health reporting, timeout, and logging settings.
+func (c *Config) loadRuntimeEnv() error {
 	healthStr := os.Getenv("INFERENCE_HEALTH_INTERVAL")
 	if healthStr == "" {
-		cfg.HealthInterval = 30 * time.Second
+		c.HealthInterval = DefaultHealthInterval
 	} else {
 		dur, err := time.ParseDuration(healthStr)
 		if err != nil {
-			return nil, fmt.Errorf("config: invalid INFERENCE_HEALTH_INTERVAL: %w", err)
+			return fmt.Errorf("config: invalid INFERENCE_HEALTH_INTERVAL: %w", err)
 		}
-		cfg.HealthInterval = dur
-	}
-
-	chainRPC := envOr("ZG_CHAIN_RPC", "https://evmrpc-testnet.0g.ai")
-	chainPrivKey := os.Getenv("ZG_CHAIN_PRIVATE_KEY")
-	var chainID int64 = 16602
-
-	// 0G Compute
-	cfg.Compute.ChainRPC = chainRPC
-	cfg.Compute.ChainID = chainID
-	cfg.Compute.PrivateKey = chainPrivKey
-	cfg.Compute.ServingContractAddress = os.Getenv("ZG_SERVING_CONTRACT")
-	cfg.Compute.Endpoint = os.Getenv("ZG_COMPUTE_ENDPOINT")
-	cfg.Compute.PollInterval = 2 * time.Second
-	cfg.Compute.PollTimeout = 5 * time.Minute
-
-	// 0G Storage
-	cfg.Storage.ChainRPC = chainRPC
-	cfg.Storage.ChainID = chainID
-	cfg.Storage.PrivateKey = chainPrivKey
-	cfg.Storage.FlowContractAddress = envOr("ZG_FLOW_CONTRACT", "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296")
-	cfg.Storage.StorageNodeEndpoint = os.Getenv("ZG_STORAGE_NODE_ENDPOINT")
-	cfg.Storage.Endpoint = os.Getenv("ZG_STORAGE_ENDPOINT")
-
-	// 0G iNFT
-	cfg.INFT.ChainRPC = chainRPC
-	cfg.INFT.ChainID = chainID
-	cfg.INFT.ContractAddress = os.Getenv("ZG_INFT_CONTRACT")
-	cfg.INFT.PrivateKey = chainPrivKey
-	cfg.INFT.EncryptionKeyID = envOr("ZG_ENCRYPTION_KEY_ID", "default")
-
-	encKeyHex := os.Getenv("ZG_ENCRYPTION_KEY")
-	if encKeyHex != "" {
-		key, err := hex.DecodeString(encKeyHex)
-		if err != nil {
-			return nil, fmt.Errorf("config: invalid ZG_ENCRYPTION_KEY hex: %w", err)
+		if dur <= 0 {
+			return fmt.Errorf("config: INFERENCE_HEALTH_INTERVAL must be positive, got %q", healthStr)
 		}
-		cfg.INFT.EncryptionKey = key
+		c.HealthInterval = dur
 	}
+	if v := os.Getenv("INFERENCE_SHUTDOWN_TIMEOUT"); v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil || dur <= 0 {
+			return fmt.Errorf("config: INFERENCE_SHUTDOWN_TIMEOUT must be a positive duration, got %q", v)
+		}
+		c.ShutdownTimeout = dur
+	}
+	if v := os.Getenv("INFERENCE_MAX_TASK_DURATION"); v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil || dur <= 0 {
+			return fmt.Errorf("config: INFERENCE_MAX_TASK_DURATION must be a positive duration, got %q", v)
+		}
+		c.MaxTaskDuration = dur
+	}
+	weights, err := parseHealthWeights(os.Getenv("INFERENCE_HEALTH_WEIGHTS"))
+	if err != nil {
+		return fmt.Errorf("config: invalid INFERENCE_HEALTH_WEIGHTS: %w", err)
+	}
+	c.HealthWeights = weights
 
-	// 0G DA
-	cfg.DA.ChainRPC = chainRPC
-	cfg.DA.ChainID = chainID
-	cfg.DA.PrivateKey = chainPrivKey
-	cfg.DA.DAContractAddress = envOr("ZG_DA_CONTRACT", "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B")
-	cfg.DA.Namespace = envOr("ZG_DA_NAMESPACE", "inference-audit")
-	cfg.DA.Endpoint = os.Getenv("ZG_DA_ENDPOINT")
+	if err := c.LogLevel.UnmarshalText([]byte(envOr("INFERENCE_LOG_LEVEL", "info"))); err != nil {
+		return fmt.Errorf("config: invalid INFERENCE_LOG_LEVEL: %w", err)
+	}
+	c.LogFormat = envOr("INFERENCE_LOG_FORMAT", "json")
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		return fmt.Errorf("config: INFERENCE_LOG_FORMAT must be \"json\" or \"text\", got %q", c.LogFormat)
+	}
+	return nil
+}
 
-	// HCS
-	cfg.HCSTaskTopic = os.Getenv("HCS_TASK_TOPIC")
-	cfg.HCSResultTopic = os.Getenv("HCS_RESULT_TOPIC")
+// Fingerprint returns a short digest of the non-secret settings that
+// determine where the agent reads and writes: identity, topics, chain, and
+// contract and endpoint addresses. Keys, mnemonics, and headers are
+// excluded so the value is safe to publish.
+func (c *Config) Fingerprint() string {
+	parts := []string{
+		c.AgentID,
+		c.HCSTaskTopic,
+		c.HCSResultTopic,
+		strconv.FormatInt(c.INFT.ChainID, 10),
+		c.INFT.ChainRPC,
+		c.Compute.ServingContractAddress,
+		c.Compute.Endpoint,
+		c.Storage.FlowContractAddress,
+		c.Storage.StorageNodeEndpoint,
+		c.INFT.ContractAddress,
+		c.INFT.EncryptionKeyID,
+		c.DA.DAContractAddress,
+		c.DA.Namespace,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
 
-	return cfg, nil
+// SecretEnv returns the secret named by key, read either from the key
+// itself or from the file named by key+"_FILE" (e.g. a Docker or
+// Kubernetes secret mount), with surrounding whitespace trimmed. Setting
+// both is an error.
+func SecretEnv(key string) (string, error) {
+	val := os.Getenv(key)
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return val, nil
+	}
+	if val != "" {
+		return "", fmt.Errorf("%s and %s_FILE are mutually exclusive", key, key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), nil
 }
 
 func envOr(key, defaultVal string) string {
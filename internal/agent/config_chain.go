@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/inft"
+)
+
+// chainEnv is the 0G Chain connection shared by every 0G client.
+type chainEnv struct {
+	rpc string
+	id  int64
+	key string
+}
+
+// loadChainEnv reads the chain connection and signing settings.
+func (c *Config) loadChainEnv() (chainEnv, error) {
+	chain := chainEnv{rpc: envOr("ZG_CHAIN_RPC", "https://evmrpc-testnet.0g.ai")}
+	var err error
+	if chain.key, err = SecretEnv("ZG_CHAIN_PRIVATE_KEY"); err != nil {
+		return chainEnv{}, fmt.Errorf("config: %w", err)
+	}
+	c.ChainMnemonic = os.Getenv("ZG_CHAIN_MNEMONIC")
+	c.ChainDerivationPath = envOr("ZG_CHAIN_DERIVATION_PATH", zerog.DefaultDerivationPath)
+	if chain.key != "" && c.ChainMnemonic != "" {
+		return chainEnv{}, fmt.Errorf("config: ZG_CHAIN_PRIVATE_KEY and ZG_CHAIN_MNEMONIC are mutually exclusive")
+	}
+
+	c.RemoteSignerURL = os.Getenv("ZG_REMOTE_SIGNER_URL")
+	c.RemoteSignerAddress = os.Getenv("ZG_REMOTE_SIGNER_ADDRESS")
+	if c.RemoteSignerURL != "" && !common.IsHexAddress(c.RemoteSignerAddress) {
+		return chainEnv{}, fmt.Errorf("config: ZG_REMOTE_SIGNER_ADDRESS must be a valid address when ZG_REMOTE_SIGNER_URL is set")
+	}
+
+	if v := os.Getenv("ZG_MAX_INFLIGHT_TX"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return chainEnv{}, fmt.Errorf("config: ZG_MAX_INFLIGHT_TX must be a non-negative integer, got %q", v)
+		}
+		c.MaxInflightTx = n
+	}
+	c.TxLimiter = zerog.NewTxLimiter(c.MaxInflightTx)
+	if chain.id, err = strconv.ParseInt(envOr("ZG_CHAIN_ID", "16602"), 10, 64); err != nil {
+		return chainEnv{}, fmt.Errorf("config: invalid ZG_CHAIN_ID: %w", err)
+	}
+	return chain, nil
+}
+
+// loadINFTEnv reads the 0G iNFT minter settings.
+func (c *Config) loadINFTEnv(chain chainEnv) error {
+	c.INFT.ChainRPC = chain.rpc
+	c.INFT.ChainID = chain.id
+	c.INFT.ContractAddress = os.Getenv("ZG_INFT_CONTRACT")
+	c.INFT.PrivateKey = chain.key
+	c.INFT.TxLimiter = c.TxLimiter
+	c.INFT.EncryptionKeyID = envOr("ZG_ENCRYPTION_KEY_ID", "default")
+	c.INFT.EncryptionAlgorithm = envOr("ZG_INFT_ENCRYPTION_ALGORITHM", "AES-256-GCM")
+	if !inft.SupportedAlgorithm(c.INFT.EncryptionAlgorithm) {
+		return fmt.Errorf("config: unsupported ZG_INFT_ENCRYPTION_ALGORITHM %q", c.INFT.EncryptionAlgorithm)
+	}
+	c.INFT.CompressMetadata = os.Getenv("ZG_INFT_COMPRESS_METADATA") == "true"
+	c.INFT.DeriveKeys = os.Getenv("ZG_INFT_DERIVE_KEYS") == "true"
+	recipients, err := inft.ParseRecipients(os.Getenv("ZG_INFT_RECIPIENTS"))
+	if err != nil {
+		return fmt.Errorf("config: invalid ZG_INFT_RECIPIENTS: %w", err)
+	}
+	c.INFT.Recipients = recipients
+
+	encKeyHex := os.Getenv("ZG_ENCRYPTION_KEY")
+	if encKeyHex != "" {
+		key, err := hex.DecodeString(encKeyHex)
+		if err != nil {
+			return fmt.Errorf("config: invalid ZG_ENCRYPTION_KEY hex: %w", err)
+		}
+		c.INFT.EncryptionKey = key
+	}
+	return nil
+}
+
+// loadDAEnv reads the 0G DA audit publisher settings.
+func (c *Config) loadDAEnv(chain chainEnv) error {
+	c.DA.ChainRPC = chain.rpc
+	c.DA.ChainID = chain.id
+	c.DA.PrivateKey = chain.key
+	c.DA.TxLimiter = c.TxLimiter
+	c.DA.DAContractAddress = envOr("ZG_DA_CONTRACT", "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B")
+	c.DA.Namespace = envOr("ZG_DA_NAMESPACE", "inference-audit")
+	c.DA.PerAgentNamespace = os.Getenv("ZG_DA_PER_AGENT_NAMESPACE") == "true"
+	c.DA.Compress = os.Getenv("ZG_DA_COMPRESS") == "true"
+	c.DA.IndexFile = os.Getenv("ZG_DA_INDEX_FILE")
+	if v := os.Getenv("ZG_DA_PRICE_PER_BYTE"); v != "" {
+		price, ok := new(big.Int).SetString(v, 10)
+		if !ok || price.Sign() < 0 {
+			return fmt.Errorf("config: ZG_DA_PRICE_PER_BYTE must be a non-negative integer (wei), got %q", v)
+		}
+		c.DA.PricePerByte = price
+	}
+	c.DA.Endpoint = os.Getenv("ZG_DA_ENDPOINT")
+	return nil
+}
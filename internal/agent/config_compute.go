@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/compute"
+)
+
+// loadComputeEnv reads the 0G Compute settings.
+func (c *Config) loadComputeEnv(chain chainEnv) error {
+	var err error
+	c.Compute.ChainRPC = chain.rpc
+	c.Compute.ChainID = chain.id
+	c.Compute.PrivateKey = chain.key
+	c.Compute.ServingContractAddress = os.Getenv("ZG_SERVING_CONTRACT")
+	c.Compute.Endpoint = os.Getenv("ZG_COMPUTE_ENDPOINT")
+	c.Compute.PollInterval = 2 * time.Second
+	c.Compute.PollTimeout = 5 * time.Minute
+	c.Compute.Debug = os.Getenv("ZG_COMPUTE_DEBUG") == "true"
+	c.Compute.ProviderSelection = compute.ProviderSelection(envOr("ZG_COMPUTE_PROVIDER_SELECTION", string(compute.ProviderSelectionFirst)))
+	c.Compute.AutoClampTokens = os.Getenv("ZG_COMPUTE_AUTO_CLAMP_TOKENS") == "true"
+	c.Compute.HedgeListModels = os.Getenv("ZG_COMPUTE_HEDGE_LIST_MODELS") == "true"
+	c.Compute.RequireVerifiability = os.Getenv("ZG_COMPUTE_REQUIRE_VERIFIABILITY")
+	if v := os.Getenv("ZG_COMPUTE_MODEL_DEFAULTS"); v != "" {
+		if err := json.Unmarshal([]byte(v), &c.Compute.ModelDefaults); err != nil {
+			return fmt.Errorf("config: invalid ZG_COMPUTE_MODEL_DEFAULTS: %w", err)
+		}
+	}
+	if v := os.Getenv("ZG_COMPUTE_MAX_STALE_MODELS"); v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil || dur < 0 {
+			return fmt.Errorf("config: ZG_COMPUTE_MAX_STALE_MODELS must be a non-negative duration, got %q", v)
+		}
+		c.Compute.MaxStaleModels = dur
+	}
+	if v := os.Getenv("ZG_COMPUTE_CLOCK_SKEW_TOLERANCE"); v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("config: invalid ZG_COMPUTE_CLOCK_SKEW_TOLERANCE: %w", err)
+		}
+		c.Compute.ClockSkewTolerance = dur
+	}
+	if c.Compute.ContextWindows, err = parseContextWindows(os.Getenv("ZG_COMPUTE_CONTEXT_WINDOWS")); err != nil {
+		return fmt.Errorf("config: invalid ZG_COMPUTE_CONTEXT_WINDOWS: %w", err)
+	}
+	return nil
+}
+
+// parseContextWindows parses "model=tokens" pairs separated by commas.
+func parseContextWindows(s string) (map[string]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	windows := make(map[string]int)
+	for _, pair := range strings.Split(s, ",") {
+		model, size, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || model == "" {
+			return nil, fmt.Errorf("expected model=tokens, got %q", pair)
+		}
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid token count for %s: %q", model, size)
+		}
+		windows[model] = n
+	}
+	return windows, nil
+}
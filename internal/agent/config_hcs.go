@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/hcs"
+)
+
+// loadHCSEnv reads the HCS topics, groups, sequence file, and task queue
+// settings.
+func (c *Config) loadHCSEnv() error {
+	c.HCSTaskTopic = os.Getenv("HCS_TASK_TOPIC")
+	c.HCSResultTopic = os.Getenv("HCS_RESULT_TOPIC")
+	c.SequenceFile = os.Getenv("INFERENCE_SEQ_FILE")
+	for _, g := range strings.Split(os.Getenv("INFERENCE_GROUPS"), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			c.Groups = append(c.Groups, g)
+		}
+	}
+	if v := os.Getenv("INFERENCE_TASK_BUFFER"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("config: INFERENCE_TASK_BUFFER must be a positive integer, got %q", v)
+		}
+		c.TaskBuffer = n
+	}
+	if v := os.Getenv("INFERENCE_TASK_REORDER_WINDOW"); v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil || dur <= 0 {
+			return fmt.Errorf("config: INFERENCE_TASK_REORDER_WINDOW must be a positive duration, got %q", v)
+		}
+		c.TaskReorderWindow = dur
+	}
+	return nil
+}
+
+// HCSHandler builds an HCS handler config from the agent config.
+func (c *Config) HCSHandler(transport hcs.Transport) hcs.HandlerConfig {
+	hc := hcs.HandlerConfig{
+		Transport:     transport,
+		TaskTopicID:   c.HCSTaskTopic,
+		ResultTopicID: c.HCSResultTopic,
+		AgentID:       c.AgentID,
+		Groups:        c.Groups,
+		SequenceFile:  c.SequenceFile,
+		TaskBuffer:    c.TaskBuffer,
+		ReorderWindow: c.TaskReorderWindow,
+
+		MaxInlineResultBytes: c.MaxInlineResultBytes,
+	}
+	if c.TaskWALDir != "" {
+		hc.OnAccept = taskWAL{dir: c.TaskWALDir}.record
+	}
+	return hc
+}
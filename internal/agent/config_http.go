@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+)
+
+// loadHTTPEnv reads the ZG_HTTP_* settings and builds the shared outbound
+// HTTP transport.
+func (c *Config) loadHTTPEnv() error {
+	c.UserAgentIncludeAgentID = os.Getenv("ZG_HTTP_USER_AGENT_INCLUDE_AGENT_ID") == "true"
+	c.HTTP = zerog.HTTPConfig{
+		ProxyURL:  os.Getenv("ZG_HTTP_PROXY"),
+		CAFile:    os.Getenv("ZG_HTTP_CA_FILE"),
+		UserAgent: envOr("ZG_HTTP_USER_AGENT", c.defaultUserAgent("")),
+	}
+	for _, pin := range strings.Split(os.Getenv("ZG_HTTP_PINNED_SPKI"), ",") {
+		if pin = strings.TrimSpace(pin); pin != "" {
+			c.HTTP.PinnedSPKI = append(c.HTTP.PinnedSPKI, pin)
+		}
+	}
+	return c.buildHTTPTransport()
+}
+
+// SetVersion records the build version and, unless ZG_HTTP_USER_AGENT
+// overrides the default, rebuilds the outbound HTTP transport so its
+// User-Agent carries the version.
+func (c *Config) SetVersion(version string) error {
+	c.Version = version
+	if c.HTTP.UserAgent != c.defaultUserAgent("") {
+		return nil
+	}
+	c.HTTP.UserAgent = c.defaultUserAgent(version)
+	return c.buildHTTPTransport()
+}
+
+func (c *Config) defaultUserAgent(version string) string {
+	if c.UserAgentIncludeAgentID {
+		return zerog.UserAgent(version, c.AgentID)
+	}
+	return zerog.UserAgent(version, "")
+}
+
+// buildHTTPTransport builds HTTPTransport from HTTP and sets it on the
+// compute and storage configs.
+func (c *Config) buildHTTPTransport() error {
+	rt, err := zerog.NewHTTPTransport(c.HTTP)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	c.HTTPTransport = rt
+	c.Compute.Transport = rt
+	c.Storage.Transport = rt
+	return nil
+}
@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/storage"
+)
+
+// loadStorageEnv reads the 0G Storage settings.
+func (c *Config) loadStorageEnv(chain chainEnv) error {
+	c.Storage.ChainRPC = chain.rpc
+	c.Storage.ChainID = chain.id
+	c.Storage.PrivateKey = chain.key
+	c.Storage.FlowContractAddress = envOr("ZG_FLOW_CONTRACT", "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296")
+	c.Storage.StorageNodeEndpoint = os.Getenv("ZG_STORAGE_NODE_ENDPOINT")
+	c.Storage.Endpoint = os.Getenv("ZG_STORAGE_ENDPOINT")
+	c.Storage.SkipExisting = os.Getenv("ZG_STORAGE_SKIP_EXISTING") == "true"
+	c.Storage.Token = os.Getenv("ZG_STORAGE_TOKEN")
+	hashAlg, err := storage.ParseHashAlgorithm(os.Getenv("ZG_STORAGE_HASH_ALGORITHM"))
+	if err != nil {
+		return fmt.Errorf("config: invalid ZG_STORAGE_HASH_ALGORITHM: %w", err)
+	}
+	c.Storage.HashAlgorithm = hashAlg
+	c.Storage.VerifyDownloads = os.Getenv("ZG_STORAGE_VERIFY_DOWNLOADS") == "true"
+	c.Storage.TxLimiter = c.TxLimiter
+	return nil
+}
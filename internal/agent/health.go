@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/lancekrogers/agent-coordinator-ethden-2026/pkg/daemon"
+	"github.com/lancekrogers/agent-inference/internal/hcs"
+)
+
+// ReadinessCheck is a named dependency probe used by /readyz.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// ReadinessChecks returns the agent's built-in readiness probes: the HCS
+// task subscription is active and at least one compute model is discovered.
+func (a *Agent) ReadinessChecks() []ReadinessCheck {
+	return []ReadinessCheck{
+		{Name: "hcs_subscription", Check: func(context.Context) error {
+			if !a.subscribed.Load() {
+				return errors.New("HCS task subscription not active")
+			}
+			return nil
+		}},
+		{Name: "compute_models", Check: func(ctx context.Context) error {
+			models, err := a.compute.ListModels(ctx)
+			if err != nil {
+				return err
+			}
+			if len(models) == 0 {
+				return errors.New("no compute models discovered")
+			}
+			return nil
+		}},
+	}
+}
+
+// HealthHandler serves /livez, which always reports the process is up,
+// /readyz, which runs every check and returns 503 if any fails, and /stats
+// with the agent's activity snapshot when stats is non-nil.
+func HealthHandler(checks []ReadinessCheck, stats func() Stats) http.Handler {
+	mux := http.NewServeMux()
+	if stats != nil {
+		mux.HandleFunc("GET /stats", func(w http.ResponseWriter, _ *http.Request) {
+			writeHealth(w, http.StatusOK, stats())
+		})
+	}
+	mux.HandleFunc("GET /livez", func(w http.ResponseWriter, _ *http.Request) {
+		writeHealth(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		status := http.StatusOK
+		results := make(map[string]string, len(checks))
+		for _, c := range checks {
+			if err := c.Check(ctx); err != nil {
+				status = http.StatusServiceUnavailable
+				results[c.Name] = err.Error()
+				continue
+			}
+			results[c.Name] = "ok"
+		}
+		writeHealth(w, status, results)
+	})
+	return mux
+}
+
+func writeHealth(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// ServeHealth runs the health and stats HTTP server on addr until ctx is cancelled.
+func ServeHealth(ctx context.Context, addr string, log *slog.Logger, checks []ReadinessCheck, stats func() Stats) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("agent: health listen on %s: %w", addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           HealthHandler(checks, stats),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("health server listening", "addr", ln.Addr().String())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("agent: health server: %w", err)
+	}
+	return nil
+}
+
+// healthStatus builds the health message published over HCS.
+func (a *Agent) healthStatus(ctx context.Context, status string) hcs.HealthStatus {
+	st := a.Stats()
+	return hcs.HealthStatus{
+		AgentID:        a.cfg.AgentID,
+		Status:         status,
+		UptimeSeconds:  int64(st.Uptime.Seconds()),
+		CompletedTasks: int(st.Completed),
+		FailedTasks:    int(st.Failed),
+		HealthScore:    a.HealthScore(ctx),
+	}
+}
+
+func (a *Agent) healthLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			st := a.Stats()
+			status := "idle"
+			if st.ActiveTasks > 0 {
+				status = "busy"
+			}
+			a.handler.PublishHealth(ctx, a.healthStatus(ctx, status))
+
+			// Daemon heartbeat on the same tick.
+			hbReq := daemon.HeartbeatRequest{Timestamp: time.Now()}
+			if a.daemonReg != nil {
+				hbReq.AgentID = a.daemonReg.AgentID
+				hbReq.SessionID = a.daemonReg.SessionID
+			}
+			if err := a.daemon.Heartbeat(ctx, hbReq); err != nil {
+				a.log.Warn("daemon heartbeat failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/inft"
+)
+
+// mintResult mints the run's iNFT with encrypted metadata, embedding the
+// output itself when it was not uploaded to storage.
+func (a *Agent) mintResult(ctx context.Context, run *taskRun) error {
+	task := run.task
+	meta := map[string]string{
+		"task_id":  task.TaskID,
+		"model_id": task.ModelID,
+		"agent_id": a.cfg.AgentID,
+	}
+	if run.stored.inline {
+		meta["output"] = run.result.Output
+	}
+	tokenID, err := a.minter.Mint(ctx, inft.MintRequest{
+		Name:             fmt.Sprintf("Inference Result: %s", task.TaskID),
+		InferenceJobID:   run.jobID,
+		ResultHash:       run.resultHash,
+		StorageContentID: run.stored.contentID,
+		PlaintextMeta:    meta,
+	})
+	if err != nil {
+		return fmt.Errorf("agent: iNFT mint failed for task %s: %w", task.TaskID, err)
+	}
+	run.tokenID = tokenID
+	return nil
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/hcs"
+	"github.com/lancekrogers/agent-inference/internal/zerog/compute"
+	"github.com/lancekrogers/agent-inference/internal/zerog/da"
+)
+
+// ErrTaskTimeout marks a task that overran Config.MaxTaskDuration.
+var ErrTaskTimeout = errors.New("agent: task exceeded max duration")
+
+// Pipeline stages a task can fail in, as reported in StageError.Stage and
+// hcs.TaskResult.Stage.
+const (
+	StageValidate    = "validate"
+	StageCompute     = "compute"
+	StagePostprocess = "postprocess"
+	StageStorage     = "storage"
+	StageMint        = "mint"
+	StageReport      = "report"
+)
+
+// StageError is a task failure tagged with the pipeline stage it happened
+// in. Use errors.As to recover it from the error ProcessTask returns.
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string { return e.Err.Error() }
+
+func (e *StageError) Unwrap() error { return e.Err }
+
+// failedStage returns the stage err happened in, or "" if it carries none.
+func failedStage(err error) string {
+	var se *StageError
+	if errors.As(err, &se) {
+		return se.Stage
+	}
+	return ""
+}
+
+// taskRun carries one task's intermediate results through the pipeline.
+type taskRun struct {
+	task       hcs.TaskAssignment
+	start      time.Time
+	jobID      string
+	result     *compute.JobResult
+	resultHash string
+	stored     storedOutput
+	tokenID    string
+	auditSub   da.Submission
+}
+
+// execute runs the pipeline under Config.MaxTaskDuration and accounts for
+// a failure. Success is accounted by complete once the caller has
+// delivered the result.
+func (a *Agent) execute(ctx context.Context, task hcs.TaskAssignment) (hcs.TaskResult, ProvenanceRecord, error) {
+	taskCtx := ctx
+	if a.cfg.MaxTaskDuration > 0 {
+		var cancel context.CancelFunc
+		taskCtx, cancel = context.WithTimeoutCause(ctx, a.cfg.MaxTaskDuration, ErrTaskTimeout)
+		defer cancel()
+	}
+	result, rec, err := a.runPipeline(taskCtx, task)
+	if err != nil {
+		a.fail()
+		status := "failed"
+		if ctx.Err() == nil && errors.Is(context.Cause(taskCtx), ErrTaskTimeout) {
+			status = "timeout"
+			err = fmt.Errorf("agent: task %s exceeded %s: %w: %w", task.TaskID, a.cfg.MaxTaskDuration, ErrTaskTimeout, err)
+			a.auditTimeout(ctx, task, err)
+		}
+		return hcs.TaskResult{TaskID: task.TaskID, Status: status, Stage: failedStage(err), Error: err.Error()}, ProvenanceRecord{}, err
+	}
+	return result, rec, nil
+}
+
+// complete counts a delivered task as completed and records its
+// provenance.
+func (a *Agent) complete(ctx context.Context, result hcs.TaskResult, rec ProvenanceRecord) {
+	a.completedTasks.Add(1)
+	a.outcomes.record(false)
+	a.recordProvenance(ctx, rec)
+	a.log.Info("task completed", "task_id", result.TaskID, "duration_ms", result.DurationMs)
+}
+
+// fail counts a task as failed.
+func (a *Agent) fail() {
+	a.failedTasks.Add(1)
+	a.outcomes.record(true)
+}
+
+// runPipeline executes the pipeline steps for ProcessTask, returning the
+// result and the provenance record complete stores once it is delivered.
+// Failures are StageErrors naming the step that failed.
+func (a *Agent) runPipeline(ctx context.Context, task hcs.TaskAssignment) (hcs.TaskResult, ProvenanceRecord, error) {
+	a.log.Info("processing task", "task_id", task.TaskID, "model", task.ModelID)
+	run := &taskRun{task: task, start: time.Now()}
+	a.activeTasks.Add(1)
+	defer a.activeTasks.Add(-1)
+	a.inflight.Store(task.TaskID, struct{}{})
+	defer a.inflight.Delete(task.TaskID)
+
+	// 1. Audit: task received
+	a.audit.Publish(ctx, da.AuditEvent{
+		Type:      da.EventTypeTaskReceived,
+		AgentID:   a.cfg.AgentID,
+		TaskID:    task.TaskID,
+		Timestamp: time.Now(),
+	})
+
+	// 2–3. Run inference on 0G Compute and post-process the result
+	if err := a.infer(ctx, run); err != nil {
+		return hcs.TaskResult{}, ProvenanceRecord{}, err
+	}
+
+	// 4. Store result on 0G Storage, unless it is small enough to embed
+	// in the iNFT metadata instead.
+	resultHash, err := a.hashOutput(task.TaskID, run.result.Output)
+	if err != nil {
+		return hcs.TaskResult{}, ProvenanceRecord{}, &StageError{Stage: StageStorage, Err: err}
+	}
+	run.resultHash = resultHash
+	if err := a.storeOutput(ctx, run); err != nil {
+		return hcs.TaskResult{}, ProvenanceRecord{}, &StageError{Stage: StageStorage, Err: err}
+	}
+
+	// 5. Mint iNFT with encrypted metadata
+	if err := a.mintResult(ctx, run); err != nil {
+		return hcs.TaskResult{}, ProvenanceRecord{}, &StageError{Stage: StageMint, Err: err}
+	}
+
+	// 6. Audit: inference completed
+	a.auditCompleted(ctx, run)
+
+	// 7. Build the result (includes CRE signal fields)
+	return a.taskResult(run), a.provenanceRecord(run), nil
+}
+
+// infer validates the task input, runs it on 0G Compute, and applies
+// Config.ResultProcessor, leaving the job ID and result in run.
+func (a *Agent) infer(ctx context.Context, run *taskRun) error {
+	task := run.task
+	if err := a.validateInput(task.ModelID, task.Input); err != nil {
+		return &StageError{Stage: StageValidate, Err: fmt.Errorf("agent: task %s rejected for model %s: %w", task.TaskID, task.ModelID, err)}
+	}
+	jobID, err := a.compute.SubmitJob(ctx, compute.JobRequest{
+		ModelID:         task.ModelID,
+		Input:           task.Input,
+		MaxTokens:       task.MaxTokens,
+		ProviderAddress: task.ProviderAddress,
+		ProviderURL:     task.ProviderURL,
+		StatusCallback: func(status compute.JobStatus) {
+			a.log.Debug("compute job status", "task_id", task.TaskID, "status", status)
+		},
+	})
+	if err != nil {
+		return &StageError{Stage: StageCompute, Err: fmt.Errorf("agent: compute submit failed for task %s: %w", task.TaskID, err)}
+	}
+
+	result, err := a.compute.GetResult(ctx, jobID)
+	if err != nil {
+		if ctx.Err() != nil {
+			a.cancelJob(jobID)
+		}
+		return &StageError{Stage: StageCompute, Err: fmt.Errorf("agent: compute result failed for job %s: %w", jobID, err)}
+	}
+	a.tokensUsed.Add(int64(result.TokensUsed))
+	if result.Truncated() {
+		a.log.Warn("inference output truncated at token limit",
+			"task_id", task.TaskID, "job_id", jobID, "max_tokens", task.MaxTokens)
+	}
+	if a.cfg.ResultProcessor != nil {
+		processed, err := a.cfg.ResultProcessor(ctx, task, *result)
+		if err != nil {
+			return &StageError{Stage: StagePostprocess, Err: fmt.Errorf("agent: postprocess failed for task %s: %w", task.TaskID, err)}
+		}
+		result = &processed
+	}
+	run.jobID, run.result = jobID, result
+	return nil
+}
+
+// taskResult builds the HCS result for a finished run, including the CRE
+// signal fields.
+func (a *Agent) taskResult(run *taskRun) hcs.TaskResult {
+	confidence, riskScore := a.deriveSignalMetrics(run.result)
+	return hcs.TaskResult{
+		TaskID:            run.task.TaskID,
+		Status:            "completed",
+		Output:            run.result.Output,
+		DurationMs:        time.Since(run.start).Milliseconds(),
+		TokensUsed:        run.result.TokensUsed,
+		StorageContentID:  run.stored.contentID,
+		INFTTokenID:       run.tokenID,
+		AuditSubmissionID: run.auditSub.ID,
+		Provider:          run.result.Provider,
+		SignalConfidence:  confidence,
+		RiskScore:         riskScore,
+	}
+}
+
+// provenanceRecord builds the provenance record for a finished run.
+func (a *Agent) provenanceRecord(run *taskRun) ProvenanceRecord {
+	return ProvenanceRecord{
+		TaskID:           run.task.TaskID,
+		AgentID:          a.cfg.AgentID,
+		ModelID:          run.task.ModelID,
+		JobID:            run.jobID,
+		ResultHash:       run.resultHash,
+		StorageContentID: run.stored.contentID,
+		INFTTokenID:      run.tokenID,
+		DASubmissionID:   run.auditSub.ID,
+		DABlockHeight:    run.auditSub.BlockHeight,
+		HCSResultTopic:   a.cfg.HCSResultTopic,
+		CompletedAt:      time.Now(),
+	}
+}
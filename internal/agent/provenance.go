@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/storage"
+)
+
+// ErrProvenanceNotFound is returned by ExportProvenance for tasks this
+// agent has not completed.
+var ErrProvenanceNotFound = errors.New("agent: no provenance record for task")
+
+// provenanceVersion identifies the ProvenanceRecord layout.
+const provenanceVersion = 1
+
+// ProvenanceRecord ties together every artifact produced for a completed
+// task. Signature is an EIP-191 personal signature over the record's JSON
+// with Signature empty; Signer is the signing address.
+type ProvenanceRecord struct {
+	Version          int       `json:"version"`
+	TaskID           string    `json:"task_id"`
+	AgentID          string    `json:"agent_id"`
+	ModelID          string    `json:"model_id"`
+	JobID            string    `json:"job_id"`
+	ResultHash       string    `json:"result_hash"`
+	StorageContentID string    `json:"storage_content_id"`
+	INFTTokenID      string    `json:"inft_token_id"`
+	DASubmissionID   string    `json:"da_submission_id,omitempty"`
+	DABlockHeight    uint64    `json:"da_block_height,omitempty"`
+	HCSResultTopic   string    `json:"hcs_result_topic,omitempty"`
+	CompletedAt      time.Time `json:"completed_at"`
+	Signer           string    `json:"signer,omitempty"`
+	Signature        string    `json:"signature,omitempty"`
+}
+
+// Digest returns the hash the record's signature covers.
+func (r ProvenanceRecord) Digest() ([]byte, error) {
+	r.Signature = ""
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("agent: marshal provenance: %w", err)
+	}
+	return accounts.TextHash(payload), nil
+}
+
+// sign sets Signer and Signature using key.
+func (r *ProvenanceRecord) sign(key *ecdsa.PrivateKey) error {
+	r.Signer = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	digest, err := r.Digest()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		return fmt.Errorf("agent: sign provenance: %w", err)
+	}
+	r.Signature = hexutil.Encode(sig)
+	return nil
+}
+
+// SetProvenanceKey sets the key used to sign provenance records. Without
+// one, records are exported unsigned.
+func (a *Agent) SetProvenanceKey(key *ecdsa.PrivateKey) {
+	a.provenanceKey = key
+}
+
+// ExportProvenance returns the provenance record for one of the most recent
+// tasks completed by this agent since it started. Older records are dropped
+// from memory; the copy stored in 0G Storage remains.
+func (a *Agent) ExportProvenance(ctx context.Context, taskID string) (ProvenanceRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return ProvenanceRecord{}, fmt.Errorf("agent: export provenance: %w", err)
+	}
+	rec, ok := a.provenance.get(taskID)
+	if !ok {
+		return ProvenanceRecord{}, fmt.Errorf("agent: task %s: %w", taskID, ErrProvenanceNotFound)
+	}
+	return rec, nil
+}
+
+// recordProvenance signs rec, keeps it for ExportProvenance, and stores it
+// in 0G Storage next to the result. Failures are logged, not returned: the
+// task itself has already completed.
+func (a *Agent) recordProvenance(ctx context.Context, rec ProvenanceRecord) {
+	rec.Version = provenanceVersion
+	if a.provenanceKey != nil {
+		if err := rec.sign(a.provenanceKey); err != nil {
+			a.log.Warn("provenance signing failed", "task_id", rec.TaskID, "error", err)
+		}
+	}
+	a.provenance.add(rec.TaskID, rec)
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		a.log.Warn("provenance marshal failed", "task_id", rec.TaskID, "error", err)
+		return
+	}
+	contentID, err := a.storage.Upload(ctx, data, storage.Metadata{
+		Name:        fmt.Sprintf("provenance-%s", rec.TaskID),
+		ContentType: "application/json",
+		Tags:        map[string]string{"task_id": rec.TaskID, "kind": "provenance"},
+	})
+	if err != nil {
+		a.log.Warn("provenance upload failed", "task_id", rec.TaskID, "error", err)
+		return
+	}
+	a.log.Info("provenance recorded", "task_id", rec.TaskID, "content_id", contentID)
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"container/list"
+	"sync"
+)
+
+// recentTasks bounds the per-task state the agent keeps in memory.
+const recentTasks = 1024
+
+// recentEntry is a key and its value in a recent map.
+type recentEntry[V any] struct {
+	key string
+	val V
+}
+
+// recent maps task IDs to values, keeping only the newest max entries so
+// per-task state does not grow for the life of the process. It is safe
+// for concurrent use.
+type recent[V any] struct {
+	mu    sync.Mutex
+	max   int
+	order *list.List // of recentEntry[V], newest at the front
+	items map[string]*list.Element
+}
+
+// newRecent returns a recent map holding at most max entries.
+func newRecent[V any](max int) *recent[V] {
+	return &recent[V]{max: max, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// add stores val under key, replacing any earlier value, and evicts the
+// oldest entry if the map is full.
+func (r *recent[V]) add(key string, val V) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if el, ok := r.items[key]; ok {
+		el.Value = recentEntry[V]{key: key, val: val}
+		r.order.MoveToFront(el)
+		return
+	}
+	r.items[key] = r.order.PushFront(recentEntry[V]{key: key, val: val})
+	if r.order.Len() > r.max {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.items, oldest.Value.(recentEntry[V]).key)
+	}
+}
+
+// has reports whether key is stored.
+func (r *recent[V]) has(key string) bool {
+	_, ok := r.get(key)
+	return ok
+}
+
+// get returns the value stored under key.
+func (r *recent[V]) get(key string) (V, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	el, ok := r.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return el.Value.(recentEntry[V]).val, true
+}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/atomicfile"
+	"github.com/lancekrogers/agent-inference/internal/hcs"
+	"github.com/lancekrogers/agent-inference/internal/zerog/da"
+)
+
+// runLoops are the background loops Run stops, in order, on shutdown.
+type runLoops struct {
+	stopHealth context.CancelFunc
+	healthDone <-chan struct{}
+	stopSub    context.CancelFunc
+	subDone    <-chan struct{}
+}
+
+// ShutdownReport summarizes a run of the agent. It is written to
+// Config.ShutdownReportFile and its counters are attached to the
+// agent_stopped audit event.
+type ShutdownReport struct {
+	AgentID    string        `json:"agent_id"`
+	Version    string        `json:"version,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	StoppedAt  time.Time     `json:"stopped_at"`
+	Uptime     time.Duration `json:"uptime_ns"`
+	Completed  int64         `json:"completed"`
+	Failed     int64         `json:"failed"`
+	TokensUsed int64         `json:"tokens_used"`
+	// Abandoned lists tasks that were queued or still running when the
+	// agent stopped and never produced a result.
+	Abandoned []string `json:"abandoned_tasks"`
+	// Reason is why Run returned.
+	Reason string `json:"reason"`
+}
+
+// shutdownReport snapshots the run and collects abandoned tasks: the
+// in-flight ones, unstarted, and those still queued, which are drained
+// from the handler since nothing will process them.
+func (a *Agent) shutdownReport(reason error, unstarted ...hcs.TaskAssignment) ShutdownReport {
+	st := a.Stats()
+	report := ShutdownReport{
+		AgentID:    a.cfg.AgentID,
+		Version:    a.cfg.Version,
+		StoppedAt:  time.Now(),
+		Uptime:     st.Uptime,
+		Completed:  st.Completed,
+		Failed:     st.Failed,
+		TokensUsed: st.TokensUsed,
+		Abandoned:  []string{},
+		Reason:     reason.Error(),
+	}
+	if started := a.startTime.Load(); started != nil {
+		report.StartedAt = *started
+	}
+	a.inflight.Range(func(id, _ any) bool {
+		report.Abandoned = append(report.Abandoned, id.(string))
+		return true
+	})
+	for _, task := range unstarted {
+		report.Abandoned = append(report.Abandoned, task.TaskID)
+	}
+	for drained := false; !drained; {
+		select {
+		case task := <-a.handler.Tasks():
+			report.Abandoned = append(report.Abandoned, task.TaskID)
+		default:
+			drained = true
+		}
+	}
+	return report
+}
+
+// details flattens the report into audit event details.
+func (r ShutdownReport) details() map[string]string {
+	return map[string]string{
+		"uptime":          r.Uptime.Round(time.Second).String(),
+		"completed":       strconv.FormatInt(r.Completed, 10),
+		"failed":          strconv.FormatInt(r.Failed, 10),
+		"tokens_used":     strconv.FormatInt(r.TokensUsed, 10),
+		"abandoned_tasks": strings.Join(r.Abandoned, ","),
+		"reason":          r.Reason,
+	}
+}
+
+// writeShutdownReport atomically writes report as JSON to path.
+func writeShutdownReport(path string, report ShutdownReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(path, append(data, '\n'))
+}
+
+// shutdown stops the agent in a fixed order: it waits for the health loop
+// to exit, publishes a final "stopping" health status while the transport
+// is still up, then tears down the subscription. Only after that does it
+// log final stats, write the shutdown report if configured, and record
+// the agent_stopped audit event. All of it is best-effort and runs on
+// ctx, which Run ends Config.ShutdownTimeout after the run context, so
+// the drain and the shutdown sequence share one deadline. Unstarted tasks
+// are listed as abandoned.
+func (a *Agent) shutdown(ctx context.Context, reason error, loops runLoops, unstarted ...hcs.TaskAssignment) {
+	loops.stopHealth()
+	if !waitDone(ctx, loops.healthDone) {
+		a.log.Warn("health loop did not stop before shutdown timeout")
+	}
+	if err := a.handler.PublishHealth(ctx, a.healthStatus(ctx, "stopping")); err != nil {
+		a.log.Warn("final health publish failed", "error", err)
+	}
+	loops.stopSub()
+	if !waitDone(ctx, loops.subDone) {
+		a.log.Warn("HCS subscription did not stop before shutdown timeout")
+	}
+
+	report := a.shutdownReport(reason, unstarted...)
+	a.log.Info("shutting down inference agent",
+		"completed", report.Completed,
+		"failed", report.Failed,
+		"tokens_used", report.TokensUsed,
+		"abandoned", len(report.Abandoned),
+		"uptime", report.Uptime)
+
+	if a.cfg.ShutdownReportFile != "" {
+		if err := writeShutdownReport(a.cfg.ShutdownReportFile, report); err != nil {
+			a.log.Warn("shutdown report write failed", "path", a.cfg.ShutdownReportFile, "error", err)
+		}
+	}
+	a.publishLifecycle(ctx, da.EventTypeAgentStopped, report.details())
+}
+
+// waitDone reports whether done closed before ctx ended.
+func waitDone(ctx context.Context, done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthWindowSize is how many recent task outcomes the failure rate
+// covers.
+const healthWindowSize = 20
+
+// HealthWeights sets how much each signal contributes to HealthScore.
+// Weights are relative; they need not sum to 100.
+//
+// The score is
+//
+//	100 * (Failure*(1-failureRate) + Subscription*subscribed + Chain*chainOK)
+//	    / (Failure + Subscription + Chain)
+//
+// where failureRate covers the last 20 tasks (0 when none have run),
+// subscribed is 1 while the HCS task subscription is active, and chainOK
+// is 1 when the chain check passes or none is configured.
+type HealthWeights struct {
+	Failure      float64
+	Subscription float64
+	Chain        float64
+}
+
+// DefaultHealthWeights weighs recent task failures at half the score.
+var DefaultHealthWeights = HealthWeights{Failure: 50, Subscription: 25, Chain: 25}
+
+// score combines the signals into a 0-100 health score.
+func (w HealthWeights) score(failureRate float64, subscribed, chainOK bool) int {
+	total := w.Failure + w.Subscription + w.Chain
+	if total <= 0 {
+		return 100
+	}
+	sum := w.Failure * (1 - failureRate)
+	if subscribed {
+		sum += w.Subscription
+	}
+	if chainOK {
+		sum += w.Chain
+	}
+	return int(math.Round(100 * sum / total))
+}
+
+// parseHealthWeights parses "failure=N,subscription=N,chain=N". Omitted
+// signals keep their default weight.
+func parseHealthWeights(s string) (HealthWeights, error) {
+	w := DefaultHealthWeights
+	if s == "" {
+		return w, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		name, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return w, fmt.Errorf("expected name=weight, got %q", pair)
+		}
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil || f < 0 {
+			return w, fmt.Errorf("invalid weight for %s: %q", name, val)
+		}
+		switch name {
+		case "failure":
+			w.Failure = f
+		case "subscription":
+			w.Subscription = f
+		case "chain":
+			w.Chain = f
+		default:
+			return w, fmt.Errorf("unknown health signal %q", name)
+		}
+	}
+	return w, nil
+}
+
+// outcomeWindow tracks whether each of the most recent tasks failed.
+type outcomeWindow struct {
+	mu     sync.Mutex
+	failed [healthWindowSize]bool
+	next   int
+	count  int
+}
+
+func (o *outcomeWindow) record(failed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.failed[o.next] = failed
+	o.next = (o.next + 1) % healthWindowSize
+	if o.count < healthWindowSize {
+		o.count++
+	}
+}
+
+func (o *outcomeWindow) failureRate() float64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.count == 0 {
+		return 0
+	}
+	var n int
+	for i := 0; i < o.count; i++ {
+		if o.failed[i] {
+			n++
+		}
+	}
+	return float64(n) / float64(o.count)
+}
+
+// SetChainCheck sets the chain reachability probe that feeds HealthScore.
+// Without one, the chain is assumed reachable.
+func (a *Agent) SetChainCheck(check func(ctx context.Context) error) {
+	a.chainCheck = check
+}
+
+// HealthScore returns the agent's current 0-100 health score; see
+// HealthWeights for the formula.
+func (a *Agent) HealthScore(ctx context.Context) int {
+	chainOK := true
+	if a.chainCheck != nil {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		chainOK = a.chainCheck(ctx) == nil
+	}
+	return a.cfg.HealthWeights.score(a.outcomes.failureRate(), a.subscribed.Load(), chainOK)
+}
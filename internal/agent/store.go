@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/storage"
+)
+
+// storedOutput records where a task's output was kept.
+type storedOutput struct {
+	inline    bool   // embedded in the iNFT metadata, not uploaded
+	contentID string // storage content ID; empty if inline or degraded
+	upload    storage.UploadReceipt
+	err       error // upload failure tolerated under AllowStorageless
+}
+
+// storeOutput uploads the run's output to 0G Storage, or marks it inline
+// when it is below Config.InlineStorageThreshold. With AllowStorageless an
+// upload failure is recorded in run.stored and the task continues.
+func (a *Agent) storeOutput(ctx context.Context, run *taskRun) error {
+	task, output := run.task, run.result.Output
+	if len(output) < a.cfg.InlineStorageThreshold {
+		a.log.Debug("embedding small result in iNFT metadata, skipping storage",
+			"task_id", task.TaskID, "bytes", len(output))
+		run.stored = storedOutput{inline: true}
+		return nil
+	}
+	upload, err := a.storage.UploadWithReceipt(ctx, []byte(output), storage.Metadata{
+		Name:        fmt.Sprintf("inference-%s", task.TaskID),
+		ContentType: "application/json",
+		Tags:        map[string]string{"task_id": task.TaskID, "model": task.ModelID},
+	})
+	if err != nil {
+		if !a.cfg.AllowStorageless {
+			return fmt.Errorf("agent: storage upload failed for task %s: %w", task.TaskID, err)
+		}
+		// Degraded: keep the inference result and report it inline.
+		a.log.Warn("storage upload failed, continuing without storage",
+			"task_id", task.TaskID, "error", err)
+		run.stored = storedOutput{upload: upload, err: err}
+		return nil
+	}
+	run.stored = storedOutput{contentID: upload.ContentID, upload: upload}
+	return checkContentID(task.TaskID, upload.ContentID, run.resultHash)
+}
+
+// checkContentID verifies that a content-addressed storage ID, when storage
+// reports one, equals the result hash.
+func checkContentID(taskID, contentID, resultHash string) error {
+	if !isContentHash(contentID) {
+		return nil
+	}
+	if !strings.EqualFold(contentID, resultHash) {
+		return fmt.Errorf("agent: storage content %s does not match result hash %s for task %s: %w",
+			contentID, resultHash, taskID, storage.ErrIntegrity)
+	}
+	return nil
+}
+
+// hashOutput returns the hex hash of an inference output under the storage
+// hash algorithm: the result hash recorded on the iNFT and in provenance,
+// equal to the content ID the output is stored under.
+func (a *Agent) hashOutput(taskID, output string) (string, error) {
+	hash, err := storage.ContentID(a.cfg.Storage.HashAlgorithm, []byte(output))
+	if err != nil {
+		return "", fmt.Errorf("agent: hash output for task %s: %w", taskID, err)
+	}
+	return hash, nil
+}
+
+// isContentHash reports whether id looks like a hex 32-byte content ID.
+func isContentHash(id string) bool {
+	b, err := hex.DecodeString(id)
+	return err == nil && len(b) == sha256.Size
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidInput marks a task whose input was rejected by its model's
+// InputValidator before any compute was spent on it.
+var ErrInvalidInput = errors.New("invalid_input")
+
+// InputValidator checks a task's input before it is sent to compute. A
+// non-nil error rejects the task.
+type InputValidator func(input string) error
+
+// ValidateText accepts non-empty UTF-8 text without NUL bytes.
+func ValidateText(input string) error {
+	if strings.TrimSpace(input) == "" {
+		return errors.New("input is empty")
+	}
+	if !utf8.ValidString(input) {
+		return errors.New("input is not valid UTF-8")
+	}
+	if strings.ContainsRune(input, 0) {
+		return errors.New("input contains NUL bytes")
+	}
+	return nil
+}
+
+// ValidateJSON accepts input that is a single well-formed JSON value.
+func ValidateJSON(input string) error {
+	if !json.Valid([]byte(input)) {
+		return errors.New("input is not valid JSON")
+	}
+	return nil
+}
+
+// inputValidators maps the format names accepted in
+// INFERENCE_INPUT_FORMATS to their validators.
+var inputValidators = map[string]InputValidator{
+	"text": ValidateText,
+	"json": ValidateJSON,
+}
+
+// validateInput applies the validator configured for the task's model, if
+// any.
+func (a *Agent) validateInput(modelID, input string) error {
+	validate := a.cfg.InputValidators[modelID]
+	if validate == nil {
+		return nil
+	}
+	if err := validate(input); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// parseInputFormats parses "model=format" pairs separated by commas, where
+// format is "text" or "json".
+func parseInputFormats(s string) (map[string]InputValidator, error) {
+	if s == "" {
+		return nil, nil
+	}
+	validators := make(map[string]InputValidator)
+	for _, pair := range strings.Split(s, ",") {
+		model, format, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || model == "" {
+			return nil, fmt.Errorf("expected model=format, got %q", pair)
+		}
+		validate, ok := inputValidators[format]
+		if !ok {
+			return nil, fmt.Errorf("unknown input format for %s: %q", model, format)
+		}
+		validators[model] = validate
+	}
+	return validators, nil
+}
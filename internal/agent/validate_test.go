@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/agent-coordinator-ethden-2026/pkg/daemon"
+	"github.com/lancekrogers/agent-inference/internal/hcs"
+	"github.com/lancekrogers/agent-inference/internal/zerog/compute"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate InputValidator
+		input    string
+		ok       bool
+	}{
+		{"text ok", ValidateText, "summarize this", true},
+		{"text empty", ValidateText, "  \n", false},
+		{"text bad utf8", ValidateText, "\xff\xfe", false},
+		{"text nul", ValidateText, "a\x00b", false},
+		{"json object", ValidateJSON, `{"prompt":"hi"}`, true},
+		{"json string", ValidateJSON, `"hi"`, true},
+		{"json malformed", ValidateJSON, `{"prompt":`, false},
+		{"json plain text", ValidateJSON, "hello", false},
+	}
+	for _, tt := range tests {
+		if err := tt.validate(tt.input); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestParseInputFormats(t *testing.T) {
+	v, err := parseInputFormats("a=json, b=text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v) != 2 || v["a"]("{}") != nil || v["b"]("hi") != nil {
+		t.Errorf("unexpected validators: %v", v)
+	}
+	for _, bad := range []string{"a", "=json", "a=xml"} {
+		if _, err := parseInputFormats(bad); err == nil {
+			t.Errorf("parseInputFormats(%q): expected error", bad)
+		}
+	}
+}
+
+func TestProcessTask_InvalidInput(t *testing.T) {
+	comp := &mockCompute{jobID: "j1", result: &compute.JobResult{Status: compute.JobStatusCompleted, Output: "ok"}}
+	cfg := testConfig()
+	cfg.InputValidators = map[string]InputValidator{"json-model": ValidateJSON}
+	a := New(cfg, testLogger(), daemon.Noop(), comp,
+		&mockStorage{}, &mockMinter{}, &mockAudit{},
+		hcs.NewHandler(hcs.HandlerConfig{Transport: newMockTransport(), AgentID: "a"}),
+	)
+
+	res, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "t1", ModelID: "json-model", Input: "not json"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+	if res.Status != "failed" || !strings.Contains(res.Error, "invalid_input") {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if comp.submitted != 0 {
+		t.Error("invalid input must not reach compute")
+	}
+
+	if _, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "t2", ModelID: "json-model", Input: `{"q":1}`}); err != nil {
+		t.Fatalf("valid JSON rejected: %v", err)
+	}
+	// Models without a validator are not checked.
+	if _, err := a.ProcessTask(context.Background(), hcs.TaskAssignment{TaskID: "t3", ModelID: "other", Input: "anything"}); err != nil {
+		t.Fatalf("unvalidated model rejected: %v", err)
+	}
+}
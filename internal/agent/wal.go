@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/atomicfile"
+	"github.com/lancekrogers/agent-inference/internal/hcs"
+)
+
+// walSuffix marks task entries in the WAL directory.
+const walSuffix = ".task.json"
+
+// taskWAL is a write-ahead log of accepted tasks, one file per task in
+// dir. A task is recorded when the handler accepts it and removed once
+// processed, so entries left behind by a crash are the tasks to replay.
+type taskWAL struct {
+	dir string
+}
+
+// walEntry is the on-disk form of a recorded task.
+type walEntry struct {
+	AcceptedAt time.Time          `json:"accepted_at"`
+	Task       hcs.TaskAssignment `json:"task"`
+}
+
+// path returns the entry file for taskID. Task IDs come from the network,
+// so they are hashed rather than used as file names.
+func (w taskWAL) path(taskID string) string {
+	sum := sha256.Sum256([]byte(taskID))
+	return filepath.Join(w.dir, hex.EncodeToString(sum[:16])+walSuffix)
+}
+
+// record durably writes task to the log.
+func (w taskWAL) record(task hcs.TaskAssignment) error {
+	data, err := json.Marshal(walEntry{AcceptedAt: time.Now(), Task: task})
+	if err != nil {
+		return fmt.Errorf("agent: task wal: %w", err)
+	}
+	if err := os.MkdirAll(w.dir, 0o700); err != nil {
+		return fmt.Errorf("agent: task wal: %w", err)
+	}
+	if err := atomicfile.Write(w.path(task.TaskID), data); err != nil {
+		return fmt.Errorf("agent: task wal: record %s: %w", task.TaskID, err)
+	}
+	return nil
+}
+
+// done removes taskID from the log. A missing entry is not an error.
+func (w taskWAL) done(taskID string) error {
+	if err := os.Remove(w.path(taskID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("agent: task wal: mark %s done: %w", taskID, err)
+	}
+	return nil
+}
+
+// pending returns the unfinished tasks in acceptance order. Unreadable
+// entries are skipped and reported in the returned error alongside the
+// tasks that could be read.
+func (w taskWAL) pending() ([]hcs.TaskAssignment, error) {
+	files, err := os.ReadDir(w.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("agent: task wal: %w", err)
+	}
+
+	var entries []walEntry
+	var errs []error
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), walSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(w.dir, f.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var e walEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Name(), err))
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].AcceptedAt.Before(entries[j].AcceptedAt)
+	})
+
+	tasks := make([]hcs.TaskAssignment, len(entries))
+	for i, e := range entries {
+		tasks[i] = e.Task
+	}
+	if err := errors.Join(errs...); err != nil {
+		return tasks, fmt.Errorf("agent: task wal: %w", err)
+	}
+	return tasks, nil
+}
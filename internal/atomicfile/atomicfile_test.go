@@ -0,0 +1,31 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite_ReplacesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	for _, want := range []string{"first", "second"} {
+		if err := Write(path, []byte(want)); err != nil {
+			t.Fatalf("Write(%q): %v", want, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != want {
+			t.Fatalf("read back %q, %v; want %q", got, err, want)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the target file, found %d entries", len(entries))
+	}
+}
+
+func TestWrite_MissingDirLeavesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "state.json")
+	if err := Write(path, []byte("x")); err == nil {
+		t.Fatal("expected error for a missing directory")
+	}
+}
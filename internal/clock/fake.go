@@ -0,0 +1,120 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a manually advanced Clock for tests. Timers and tickers fire only
+// when Advance moves the clock past their deadline.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	at      time.Time
+	period  time.Duration // zero for one-shot timers
+	ch      chan time.Time
+	stopped bool
+}
+
+// NewFake returns a Fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake current time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives once the clock advances by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.add(d, 0).ch
+}
+
+// NewTicker returns a ticker that fires each time the clock advances by d.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	return &fakeTicker{f: f, w: f.add(d, d)}
+}
+
+func (f *Fake) add(d, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &fakeWaiter{at: f.now.Add(d), period: period, ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	return w
+}
+
+// Advance moves the clock forward by d, firing any timers and tickers that
+// come due. Like time.Ticker, a ticker whose channel is full drops ticks.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.stopped {
+			continue
+		}
+		if !w.at.After(f.now) {
+			select {
+			case w.ch <- f.now:
+			default:
+			}
+			if w.period == 0 {
+				continue
+			}
+			for !w.at.After(f.now) {
+				w.at = w.at.Add(w.period)
+			}
+		}
+		pending = append(pending, w)
+	}
+	f.waiters = pending
+}
+
+// BlockUntil waits until at least n timers or tickers are pending, so a test
+// can advance the clock only after the code under test has started waiting.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for f.pendingLocked() < n {
+		f.cond.Wait()
+	}
+}
+
+func (f *Fake) pendingLocked() int {
+	n := 0
+	for _, w := range f.waiters {
+		if !w.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+type fakeTicker struct {
+	f *Fake
+	w *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.w.ch }
+
+func (t *fakeTicker) Stop() {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	t.w.stopped = true
+}
+
+// Compile-time interface compliance check.
+var _ Clock = (*Fake)(nil)
@@ -0,0 +1,51 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake_AfterFiresOnAdvance(t *testing.T) {
+	start := time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	ch := f.After(time.Second)
+
+	f.Advance(999 * time.Millisecond)
+	select {
+	case <-ch:
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	f.Advance(time.Millisecond)
+	select {
+	case got := <-ch:
+		if !got.Equal(start.Add(time.Second)) {
+			t.Errorf("expected fire time %v, got %v", start.Add(time.Second), got)
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+}
+
+func TestFake_TickerRepeatsUntilStopped(t *testing.T) {
+	f := NewFake(time.Now())
+	tk := f.NewTicker(time.Second)
+
+	for i := 0; i < 3; i++ {
+		f.Advance(time.Second)
+		select {
+		case <-tk.C():
+		default:
+			t.Fatalf("tick %d did not fire", i)
+		}
+	}
+
+	tk.Stop()
+	f.Advance(time.Second)
+	select {
+	case <-tk.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
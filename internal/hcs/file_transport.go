@@ -0,0 +1,104 @@
+package hcs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileTransportConfig holds configuration for the file-backed replay transport.
+type FileTransportConfig struct {
+	// TasksFile holds newline-delimited envelopes emitted on Subscribe.
+	TasksFile string
+
+	// ResultsFile receives every published message, one per line.
+	// Empty discards published messages.
+	ResultsFile string
+}
+
+// FileTransport implements Transport by replaying envelopes from a file and
+// capturing published messages to another file. It lets the agent run
+// deterministic, offline demos and benchmarks without a live coordinator.
+type FileTransport struct {
+	cfg FileTransportConfig
+	mu  sync.Mutex
+}
+
+// NewFileTransport creates a file-backed replay transport.
+func NewFileTransport(cfg FileTransportConfig) *FileTransport {
+	return &FileTransport{cfg: cfg}
+}
+
+// Publish appends data as a single line to the results file.
+func (t *FileTransport) Publish(ctx context.Context, topicID string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("file transport: publish to %s: %w", topicID, err)
+	}
+	if t.cfg.ResultsFile == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, err := os.OpenFile(t.cfg.ResultsFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("file transport: open results file: %w", err)
+	}
+	defer f.Close()
+
+	line := append(bytes.TrimRight(data, "\n"), '\n')
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("file transport: write results file: %w", err)
+	}
+	return nil
+}
+
+// Subscribe emits each non-empty line of the tasks file in order. The topic
+// ID is ignored. After the file is exhausted the channels stay open until
+// ctx is cancelled, mirroring a live topic with no new messages.
+func (t *FileTransport) Subscribe(ctx context.Context, _ string) (<-chan []byte, <-chan error) {
+	msgCh := make(chan []byte)
+	errCh := make(chan error, 1)
+
+	f, err := os.Open(t.cfg.TasksFile)
+	if err != nil {
+		errCh <- fmt.Errorf("file transport: open tasks file: %w", err)
+		close(msgCh)
+		close(errCh)
+		return msgCh, errCh
+	}
+
+	go func() {
+		defer f.Close()
+		defer close(msgCh)
+		defer close(errCh)
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			select {
+			case msgCh <- append([]byte(nil), line...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			errCh <- fmt.Errorf("file transport: read tasks file: %w", err)
+			return
+		}
+		<-ctx.Done()
+	}()
+
+	return msgCh, errCh
+}
+
+// Compile-time interface compliance check.
+var _ Transport = (*FileTransport)(nil)
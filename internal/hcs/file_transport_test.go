@@ -0,0 +1,86 @@
+package hcs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTasksFile(t *testing.T, taskIDs ...string) string {
+	t.Helper()
+	var lines []string
+	for _, id := range taskIDs {
+		payload, _ := json.Marshal(TaskAssignment{TaskID: id, ModelID: "m", Input: "in"})
+		data, err := (&Envelope{Type: MessageTypeTaskAssignment, TaskID: id, Payload: payload}).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(data))
+	}
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileTransport_ReplaysTasksInOrder(t *testing.T) {
+	ft := NewFileTransport(FileTransportConfig{TasksFile: writeTasksFile(t, "task-1", "task-2")})
+	h := NewHandler(HandlerConfig{Transport: ft, AgentID: "agent-1"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.StartSubscription(ctx)
+
+	for _, want := range []string{"task-1", "task-2"} {
+		select {
+		case task := <-h.Tasks():
+			if task.TaskID != want {
+				t.Errorf("expected %s, got %s", want, task.TaskID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+}
+
+func TestFileTransport_MissingTasksFile(t *testing.T) {
+	ft := NewFileTransport(FileTransportConfig{TasksFile: filepath.Join(t.TempDir(), "missing.jsonl")})
+	_, errCh := ft.Subscribe(context.Background(), "topic")
+	if err := <-errCh; err == nil {
+		t.Fatal("expected error for missing tasks file")
+	}
+}
+
+func TestFileTransport_CapturesPublished(t *testing.T) {
+	results := filepath.Join(t.TempDir(), "results.jsonl")
+	ft := NewFileTransport(FileTransportConfig{ResultsFile: results})
+	h := NewHandler(HandlerConfig{Transport: ft, ResultTopicID: "results", AgentID: "agent-1"})
+
+	ctx := context.Background()
+	for _, id := range []string{"task-1", "task-2"} {
+		if err := h.PublishResult(ctx, TaskResult{TaskID: id, Status: "completed"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 captured messages, got %d", len(lines))
+	}
+	env, err := UnmarshalEnvelope([]byte(lines[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Type != MessageTypeTaskResult || env.TaskID != "task-2" {
+		t.Errorf("unexpected captured envelope: %+v", env)
+	}
+}
@@ -13,9 +13,16 @@ package hcs
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
 )
 
 // Transport abstracts the HCS topic operations for testability.
@@ -26,9 +33,47 @@ type Transport interface {
 
 	// Subscribe starts receiving messages from an HCS topic.
 	// Messages are delivered to the returned channel until ctx is cancelled.
+	// Errors wrapping ErrSubscriptionFailed mean the subscription is over;
+	// any other error reports a failed attempt the transport recovers from.
 	Subscribe(ctx context.Context, topicID string) (<-chan []byte, <-chan error)
 }
 
+// Receipt records where on HCS a published message landed.
+type Receipt struct {
+	TopicID string
+	// TransactionID identifies the submit transaction, e.g. for lookup on
+	// a Hedera explorer.
+	TransactionID string
+	// SequenceNumber is the message's sequence number on the topic.
+	SequenceNumber uint64
+	// ConsensusTimestamp is when the network ordered the message; zero
+	// when the transport does not know.
+	ConsensusTimestamp time.Time
+}
+
+// ReceiptTransport is implemented by transports that can report where a
+// published message landed. The handler prefers it over Transport.Publish
+// so results are traceable; other transports publish with an empty
+// Receipt.
+type ReceiptTransport interface {
+	PublishReceipt(ctx context.Context, topicID string, data []byte) (Receipt, error)
+}
+
+// TopicInfo describes an HCS topic as the network reports it.
+type TopicInfo struct {
+	TopicID        string
+	Memo           string
+	SequenceNumber uint64
+	ExpirationTime time.Time
+}
+
+// TopicInspector is implemented by transports that can look a topic up on
+// the network. Handler.CheckTopics uses it to catch a misconfigured topic
+// ID at startup; for other transports the check is skipped.
+type TopicInspector interface {
+	TopicInfo(ctx context.Context, topicID string) (TopicInfo, error)
+}
+
 // TaskHandler processes incoming task assignments from the coordinator.
 type TaskHandler interface {
 	HandleTask(ctx context.Context, task TaskAssignment) error
@@ -53,6 +98,47 @@ type HandlerConfig struct {
 
 	// AgentID is this agent's unique identifier.
 	AgentID string
+
+	// MaxInlineResultBytes caps TaskResult.Output in published results.
+	// Longer outputs with a StorageContentID are cut and marked, leaving
+	// the full output in storage; a cap too small for the marker cuts
+	// without it. Zero means no truncation.
+	MaxInlineResultBytes int
+
+	// Groups are the groups this agent belongs to. Envelopes addressed to
+	// "group:<name>" are accepted when name is listed here.
+	Groups []string
+
+	// SequenceFile persists a high-water mark of envelope sequence numbers
+	// so they stay monotonic across restarts, possibly with gaps. A corrupt
+	// file makes publishing fail with ErrSequenceFile rather than restart
+	// the sequence. Empty restarts the sequence at zero.
+	SequenceFile string
+
+	// TaskBuffer is the number of task assignments queued for the agent
+	// before delivery blocks. Zero uses 16.
+	TaskBuffer int
+
+	// OnAccept, if set, is called with each task before it is queued, for
+	// example to persist it for crash recovery. An error is logged and the
+	// task is still queued.
+	OnAccept func(TaskAssignment) error
+
+	// Observer, if set, receives task queue depth and blocked/dropped
+	// counts.
+	Observer Observer
+
+	// ReorderWindow is how long timestamped task messages are held so that
+	// ones reordered by chunking or reconnects are delivered in consensus
+	// order. Zero uses 500ms.
+	ReorderWindow time.Duration
+
+	// Clock drives the reorder window. Nil uses real time.
+	Clock clock.Clock
+
+	// Log receives a debug line per dropped malformed message. Nil uses
+	// slog.Default.
+	Log *slog.Logger
 }
 
 // Handler manages HCS subscriptions and publishing for the inference agent.
@@ -61,14 +147,45 @@ type Handler struct {
 	cfg    HandlerConfig
 	seqNum atomic.Uint64
 	taskCh chan TaskAssignment
+
+	seqMu    sync.Mutex
+	seqSaved uint64 // high-water mark recorded in SequenceFile
+	seqErr   error  // why SequenceFile could not be loaded
+
+	blocked atomic.Uint64
+	dropped atomic.Uint64
+	stale   atomic.Uint64
+	invalid atomic.Uint64
+
+	log   *slog.Logger
+	clock clock.Clock
+	// pending and lastConsensus are owned by the StartSubscription loop.
+	pending       reorderHeap
+	lastConsensus time.Time
 }
 
 // NewHandler creates an HCS handler for the inference agent.
 func NewHandler(cfg HandlerConfig) *Handler {
-	return &Handler{
+	if cfg.TaskBuffer <= 0 {
+		cfg.TaskBuffer = defaultTaskBuffer
+	}
+	if cfg.ReorderWindow <= 0 {
+		cfg.ReorderWindow = defaultReorderWindow
+	}
+	if cfg.Log == nil {
+		cfg.Log = slog.Default()
+	}
+	h := &Handler{
 		cfg:    cfg,
-		taskCh: make(chan TaskAssignment, 16),
+		taskCh: make(chan TaskAssignment, cfg.TaskBuffer),
+		log:    cfg.Log,
+		clock:  clock.OrReal(cfg.Clock),
 	}
+	if cfg.SequenceFile != "" {
+		h.seqSaved, h.seqErr = loadSequence(cfg.SequenceFile)
+		h.seqNum.Store(h.seqSaved)
+	}
+	return h
 }
 
 // Tasks returns a read-only channel of incoming task assignments.
@@ -77,26 +194,72 @@ func (h *Handler) Tasks() <-chan TaskAssignment {
 }
 
 // StartSubscription begins listening for task assignments on HCS.
-// It runs until the context is cancelled. Malformed messages are logged and skipped.
+// It runs until the context is cancelled or the transport gives up with an
+// error wrapping ErrSubscriptionFailed; other transport errors are logged
+// while the transport reconnects. Malformed messages are logged and skipped.
+// Messages carrying consensus timestamps are delivered in timestamp order
+// within ReorderWindow; any older than the last delivered one are dropped.
 func (h *Handler) StartSubscription(ctx context.Context) error {
-	msgCh, errCh := h.cfg.Transport.Subscribe(ctx, h.cfg.TaskTopicID)
+	msgCh, errCh := h.subscribe(ctx)
 	if msgCh == nil {
 		return ErrSubscriptionFailed
 	}
 
+	var flush <-chan time.Time
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case err := <-errCh:
-			if err != nil {
-				return fmt.Errorf("hcs: subscription error: %w", ErrSubscriptionFailed)
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+			} else if err := h.subscriptionError(err); err != nil {
+				return err
 			}
-		case data, ok := <-msgCh:
+		case msg, ok := <-msgCh:
+			if !ok {
+				h.releaseDue(ctx, true)
+				return h.closedSubscription(errCh)
+			}
+			h.receive(ctx, msg)
+			if flush == nil && h.pending.Len() > 0 {
+				flush = h.clock.After(h.cfg.ReorderWindow)
+			}
+		case <-flush:
+			flush = nil
+			if wait := h.releaseDue(ctx, false); wait > 0 {
+				flush = h.clock.After(wait)
+			}
+		}
+	}
+}
+
+// subscriptionError returns an error ending the subscription if err
+// wraps ErrSubscriptionFailed, meaning the transport has given up.
+// Anything else is a failed attempt the transport recovers from by
+// reconnecting, so it is only logged.
+func (h *Handler) subscriptionError(err error) error {
+	if errors.Is(err, ErrSubscriptionFailed) {
+		return fmt.Errorf("hcs: subscription error: %w", err)
+	}
+	h.log.Warn("task subscription interrupted, transport reconnecting", "error", err)
+	return nil
+}
+
+// closedSubscription checks errCh for the reason the transport closed the
+// subscription, returning nil if it gave none.
+func (h *Handler) closedSubscription(errCh <-chan error) error {
+	for {
+		select {
+		case err, ok := <-errCh:
 			if !ok {
 				return nil
 			}
-			h.processMessage(ctx, data)
+			if err := h.subscriptionError(err); err != nil {
+				return err
+			}
+		default:
+			return nil
 		}
 	}
 }
@@ -104,71 +267,130 @@ func (h *Handler) StartSubscription(ctx context.Context) error {
 func (h *Handler) processMessage(ctx context.Context, data []byte) {
 	env, err := UnmarshalEnvelope(data)
 	if err != nil {
-		return // skip malformed messages
+		h.dropInvalid(err)
+		return
 	}
 
 	if env.Type != MessageTypeTaskAssignment {
 		return // skip non-task messages
 	}
 
-	// Filter: only accept messages addressed to us or broadcast
-	if env.Recipient != "" && env.Recipient != h.cfg.AgentID {
+	if !h.addressedToUs(env.Recipient) {
 		return
 	}
 
 	var task TaskAssignment
 	if err := json.Unmarshal(env.Payload, &task); err != nil {
-		return // skip messages with invalid payload
+		h.dropInvalid(invalidMessage("task_assignment payload", env.Payload, err))
+		return
 	}
 
-	select {
-	case h.taskCh <- task:
-	case <-ctx.Done():
+	h.enqueue(ctx, task)
+}
+
+// groupRecipientPrefix marks an envelope Recipient that names a group of
+// agents rather than a single agent ID.
+const groupRecipientPrefix = "group:"
+
+// addressedToUs reports whether a message for recipient should be
+// handled: broadcasts (empty), our exact agent ID, or one of our groups.
+func (h *Handler) addressedToUs(recipient string) bool {
+	if recipient == "" || recipient == h.cfg.AgentID {
+		return true
 	}
+	group, ok := strings.CutPrefix(recipient, groupRecipientPrefix)
+	return ok && slices.Contains(h.cfg.Groups, group)
+}
+
+// dropInvalid counts and logs a message that could not be decoded.
+func (h *Handler) dropInvalid(err error) {
+	h.invalid.Add(1)
+	h.log.Debug("dropping invalid HCS message", "error", err)
 }
 
 // HandleTask processes a task assignment (satisfies TaskHandler interface).
 func (h *Handler) HandleTask(ctx context.Context, task TaskAssignment) error {
-	select {
-	case h.taskCh <- task:
-		return nil
-	case <-ctx.Done():
+	if !h.enqueue(ctx, task) {
 		return ctx.Err()
 	}
+	return nil
 }
 
 // PublishResult sends a task result to the coordinator via HCS.
 func (h *Handler) PublishResult(ctx context.Context, result TaskResult) error {
+	_, err := h.PublishResultReceipt(ctx, result)
+	return err
+}
+
+// PublishResultReceipt sends a task result to the coordinator via HCS and
+// returns where it landed. The receipt is empty when the transport does
+// not implement ReceiptTransport.
+func (h *Handler) PublishResultReceipt(ctx context.Context, result TaskResult) (Receipt, error) {
 	if err := ctx.Err(); err != nil {
-		return fmt.Errorf("hcs: context cancelled before publish result: %w", err)
+		return Receipt{}, fmt.Errorf("hcs: context cancelled before publish result: %w", err)
 	}
 
-	payload, err := json.Marshal(result)
+	payload, err := json.Marshal(truncateOutput(result, h.cfg.MaxInlineResultBytes))
+	if err != nil {
+		return Receipt{}, fmt.Errorf("hcs: failed to marshal result: %w", err)
+	}
+	seq, err := h.nextSeq()
 	if err != nil {
-		return fmt.Errorf("hcs: failed to marshal result: %w", err)
+		return Receipt{}, err
 	}
 
 	env := Envelope{
 		Type:        MessageTypeTaskResult,
 		Sender:      h.cfg.AgentID,
 		TaskID:      result.TaskID,
-		SequenceNum: h.seqNum.Add(1),
+		SequenceNum: seq,
 		Timestamp:   time.Now(),
 		Payload:     payload,
 	}
 
 	data, err := env.Marshal()
 	if err != nil {
-		return fmt.Errorf("hcs: failed to marshal envelope: %w", err)
+		return Receipt{}, fmt.Errorf("hcs: failed to marshal envelope: %w", err)
 	}
 
-	if err := h.cfg.Transport.Publish(ctx, h.cfg.ResultTopicID, data); err != nil {
-		return fmt.Errorf("hcs: failed to publish result for task %s: %w", result.TaskID, ErrPublishFailed)
+	receipt, err := h.publish(ctx, h.cfg.ResultTopicID, data)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("hcs: failed to publish result for task %s: %w", result.TaskID, ErrPublishFailed)
 	}
 
+	return receipt, nil
+}
+
+// CheckTopics confirms that the configured task and result topics exist,
+// so a mistyped topic ID fails startup instead of leaving the agent
+// subscribed to nothing. A missing topic yields an error wrapping
+// ErrTopicNotFound. It does nothing for transports that do not implement
+// TopicInspector.
+func (h *Handler) CheckTopics(ctx context.Context) error {
+	ti, ok := h.cfg.Transport.(TopicInspector)
+	if !ok {
+		return nil
+	}
+	for _, topicID := range []string{h.cfg.TaskTopicID, h.cfg.ResultTopicID} {
+		if topicID == "" {
+			continue
+		}
+		if _, err := ti.TopicInfo(ctx, topicID); err != nil {
+			return fmt.Errorf("hcs: check topic %s: %w", topicID, err)
+		}
+	}
 	return nil
 }
 
+// publish sends data through the transport, collecting a receipt when the
+// transport can provide one.
+func (h *Handler) publish(ctx context.Context, topicID string, data []byte) (Receipt, error) {
+	if rt, ok := h.cfg.Transport.(ReceiptTransport); ok {
+		return rt.PublishReceipt(ctx, topicID, data)
+	}
+	return Receipt{}, h.cfg.Transport.Publish(ctx, topicID, data)
+}
+
 // PublishHealth sends a health status update to the coordinator via HCS.
 func (h *Handler) PublishHealth(ctx context.Context, status HealthStatus) error {
 	if err := ctx.Err(); err != nil {
@@ -179,11 +401,15 @@ func (h *Handler) PublishHealth(ctx context.Context, status HealthStatus) error
 	if err != nil {
 		return fmt.Errorf("hcs: failed to marshal health status: %w", err)
 	}
+	seq, err := h.nextSeq()
+	if err != nil {
+		return err
+	}
 
 	env := Envelope{
 		Type:        MessageTypeHeartbeat,
 		Sender:      h.cfg.AgentID,
-		SequenceNum: h.seqNum.Add(1),
+		SequenceNum: seq,
 		Timestamp:   time.Now(),
 		Payload:     payload,
 	}
@@ -4,8 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 // mockTransport implements Transport for testing.
@@ -71,9 +77,9 @@ func TestEnvelope_RoundTrip(t *testing.T) {
 
 func TestTaskAssignment_RoundTrip(t *testing.T) {
 	task := TaskAssignment{
-		TaskID:  "task-1",
-		ModelID: "qwen-2.5-7b",
-		Input:   "test prompt",
+		TaskID:   "task-1",
+		ModelID:  "qwen-2.5-7b",
+		Input:    "test prompt",
 		Priority: 5,
 	}
 
@@ -222,6 +228,91 @@ func TestStartSubscription_InvalidMessage(t *testing.T) {
 	cancel()
 }
 
+func TestUnmarshalEnvelope_InvalidMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantType string
+	}{
+		{"not json", "not json", "type unknown"},
+		{"schema drift", `{"type":"task_assignment","sequence_num":"seven"}`, "type task_assignment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalEnvelope([]byte(tt.data))
+			if !errors.Is(err, ErrInvalidMessage) {
+				t.Fatalf("expected ErrInvalidMessage, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantType) {
+				t.Errorf("error should report %q: %v", tt.wantType, err)
+			}
+			if !strings.Contains(err.Error(), strconv.Quote(tt.data)) {
+				t.Errorf("error should quote the message start: %v", err)
+			}
+		})
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	_, err := UnmarshalEnvelope([]byte(`{"sequence_num":"seven"}`))
+	if !errors.As(err, &typeErr) {
+		t.Errorf("expected the JSON error to stay unwrappable, got %v", err)
+	}
+}
+
+func TestProcessMessage_CountsInvalid(t *testing.T) {
+	h := NewHandler(HandlerConfig{Transport: newMockTransport(), AgentID: "agent-1"})
+	badPayload, _ := (&Envelope{
+		Type:    MessageTypeTaskAssignment,
+		Payload: json.RawMessage(`{"task_id":42}`),
+	}).Marshal()
+
+	h.processMessage(context.Background(), []byte("not json"))
+	h.processMessage(context.Background(), badPayload)
+
+	if got := h.QueueStats().Invalid; got != 2 {
+		t.Errorf("Invalid = %d, want 2", got)
+	}
+	if got := h.QueueStats().Depth; got != 0 {
+		t.Errorf("expected no tasks queued, got %d", got)
+	}
+}
+
+func TestProcessMessage_RecipientRouting(t *testing.T) {
+	tests := []struct {
+		recipient string
+		want      bool
+	}{
+		{"", true},
+		{"agent-1", true},
+		{"agent-2", false},
+		{"group:gpu", true},
+		{"group:eu", true},
+		{"group:cpu", false},
+		{"gpu", false},
+		{"group:", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.recipient, func(t *testing.T) {
+			h := NewHandler(HandlerConfig{
+				Transport: newMockTransport(),
+				AgentID:   "agent-1",
+				Groups:    []string{"gpu", "eu"},
+			})
+			payload, _ := json.Marshal(TaskAssignment{TaskID: "t"})
+			data, _ := (&Envelope{
+				Type:      MessageTypeTaskAssignment,
+				Recipient: tt.recipient,
+				Payload:   payload,
+			}).Marshal()
+
+			h.processMessage(context.Background(), data)
+			if got := h.QueueStats().Depth == 1; got != tt.want {
+				t.Errorf("recipient %q accepted = %v, want %v", tt.recipient, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestStartSubscription_ContextCancelled(t *testing.T) {
 	mt := newMockTransport()
 	h := NewHandler(HandlerConfig{
@@ -249,6 +340,69 @@ func TestStartSubscription_ContextCancelled(t *testing.T) {
 	}
 }
 
+func TestStartSubscription_SurvivesTransientError(t *testing.T) {
+	mt := newMockTransport()
+	h := NewHandler(HandlerConfig{
+		Transport:   mt,
+		TaskTopicID: "topic-1",
+		AgentID:     "agent-1",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- h.StartSubscription(ctx)
+	}()
+
+	// A failed attempt the transport reconnects from, then more tasks.
+	mt.subErr <- errors.New("hcs transport: subscribe to topic-1 attempt 1: stream reset")
+	for _, id := range []string{"task-1", "task-2"} {
+		payload, _ := json.Marshal(TaskAssignment{TaskID: id, ModelID: "qwen", Input: "test"})
+		env := Envelope{Type: MessageTypeTaskAssignment, Sender: "coordinator", Payload: payload}
+		data, _ := env.Marshal()
+		mt.messages <- data
+	}
+
+	for _, want := range []string{"task-1", "task-2"} {
+		select {
+		case task := <-h.Tasks():
+			if task.TaskID != want {
+				t.Errorf("expected %s, got %s", want, task.TaskID)
+			}
+		case err := <-done:
+			t.Fatalf("subscription ended after a transient error: %v", err)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %s", want)
+		}
+	}
+}
+
+func TestStartSubscription_FailsWhenTransportGivesUp(t *testing.T) {
+	mt := newMockTransport()
+	h := NewHandler(HandlerConfig{
+		Transport:   mt,
+		TaskTopicID: "topic-1",
+		AgentID:     "agent-1",
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.StartSubscription(context.Background())
+	}()
+	mt.subErr <- fmt.Errorf("hcs transport: subscribe to topic-1: exhausted 6 reconnect attempts: %w", ErrSubscriptionFailed)
+	close(mt.messages)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrSubscriptionFailed) {
+			t.Errorf("expected ErrSubscriptionFailed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for subscription to fail")
+	}
+}
+
 func TestPublishResult_Success(t *testing.T) {
 	mt := newMockTransport()
 	h := NewHandler(HandlerConfig{
@@ -280,6 +434,77 @@ func TestPublishResult_Success(t *testing.T) {
 	}
 }
 
+func TestPublishResult_TruncatesOutput(t *testing.T) {
+	long := strings.Repeat("é", 100)
+	tests := []struct {
+		name      string
+		contentID string
+		wantCut   bool
+	}{
+		{"stored output is truncated", "root-abc", true},
+		{"unstored output is kept whole", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := newMockTransport()
+			h := NewHandler(HandlerConfig{
+				Transport:            mt,
+				ResultTopicID:        "result-topic",
+				AgentID:              "agent-1",
+				MaxInlineResultBytes: 64,
+			})
+
+			err := h.PublishResult(context.Background(), TaskResult{
+				TaskID:           "task-1",
+				Status:           "completed",
+				Output:           long,
+				StorageContentID: tt.contentID,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var env Envelope
+			if err := json.Unmarshal(mt.published[0], &env); err != nil {
+				t.Fatalf("unmarshal envelope: %v", err)
+			}
+			var got TaskResult
+			if err := json.Unmarshal(env.Payload, &got); err != nil {
+				t.Fatalf("unmarshal result: %v", err)
+			}
+			if got.StorageContentID != tt.contentID {
+				t.Errorf("StorageContentID = %q, want %q", got.StorageContentID, tt.contentID)
+			}
+			if !tt.wantCut {
+				if got.Output != long {
+					t.Errorf("output was modified without a storage reference")
+				}
+				return
+			}
+			if len(got.Output) > 64 {
+				t.Errorf("output is %d bytes, want at most 64", len(got.Output))
+			}
+			if !strings.HasSuffix(got.Output, truncatedMarker) {
+				t.Errorf("output %q lacks truncation marker", got.Output)
+			}
+			if !utf8.ValidString(got.Output) {
+				t.Errorf("truncation split a UTF-8 sequence: %q", got.Output)
+			}
+		})
+	}
+}
+
+func TestTruncateOutput_CapBelowMarker(t *testing.T) {
+	max := len(truncatedMarker) - 1
+	got := truncateOutput(TaskResult{Output: strings.Repeat("é", 100), StorageContentID: "root-abc"}, max)
+	if len(got.Output) > max {
+		t.Errorf("output is %d bytes, want at most %d", len(got.Output), max)
+	}
+	if !utf8.ValidString(got.Output) {
+		t.Errorf("truncation split a UTF-8 sequence: %q", got.Output)
+	}
+}
+
 func TestPublishResult_Failed(t *testing.T) {
 	mt := newMockTransport()
 	mt.publishErr = errors.New("network error")
@@ -295,6 +520,99 @@ func TestPublishResult_Failed(t *testing.T) {
 	}
 }
 
+// receiptTransport is a mockTransport that reports receipts.
+type receiptTransport struct {
+	*mockTransport
+}
+
+func (r receiptTransport) PublishReceipt(ctx context.Context, topicID string, data []byte) (Receipt, error) {
+	if err := r.Publish(ctx, topicID, data); err != nil {
+		return Receipt{}, err
+	}
+	return Receipt{
+		TopicID:        topicID,
+		TransactionID:  "0.0.1234@1700000000.000000001",
+		SequenceNumber: uint64(len(r.published)),
+	}, nil
+}
+
+func TestPublishResultReceipt(t *testing.T) {
+	mt := newMockTransport()
+	h := NewHandler(HandlerConfig{
+		Transport:     receiptTransport{mt},
+		ResultTopicID: "result-topic",
+		AgentID:       "agent-1",
+	})
+
+	h.PublishResult(context.Background(), TaskResult{TaskID: "task-1"})
+	receipt, err := h.PublishResultReceipt(context.Background(), TaskResult{TaskID: "task-2"})
+	if err != nil {
+		t.Fatalf("PublishResultReceipt: %v", err)
+	}
+	want := Receipt{TopicID: "result-topic", TransactionID: "0.0.1234@1700000000.000000001", SequenceNumber: 2}
+	if receipt != want {
+		t.Errorf("receipt = %+v, want %+v", receipt, want)
+	}
+	if len(mt.published) != 2 {
+		t.Errorf("published %d messages, want 2", len(mt.published))
+	}
+}
+
+func TestPublishResultReceipt_PlainTransport(t *testing.T) {
+	mt := newMockTransport()
+	h := NewHandler(HandlerConfig{
+		Transport:     mt,
+		ResultTopicID: "result-topic",
+		AgentID:       "agent-1",
+	})
+
+	receipt, err := h.PublishResultReceipt(context.Background(), TaskResult{TaskID: "task-1"})
+	if err != nil {
+		t.Fatalf("PublishResultReceipt: %v", err)
+	}
+	if receipt != (Receipt{}) {
+		t.Errorf("receipt = %+v, want empty for a transport without receipts", receipt)
+	}
+	if len(mt.published) != 1 {
+		t.Errorf("published %d messages, want 1", len(mt.published))
+	}
+}
+
+// topicTransport is a mockTransport that knows a fixed set of topics.
+type topicTransport struct {
+	*mockTransport
+	topics map[string]bool
+}
+
+func (tt topicTransport) TopicInfo(_ context.Context, topicID string) (TopicInfo, error) {
+	if !tt.topics[topicID] {
+		return TopicInfo{}, ErrTopicNotFound
+	}
+	return TopicInfo{TopicID: topicID}, nil
+}
+
+func TestCheckTopics(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport Transport
+		wantErr   bool
+	}{
+		{"both exist", topicTransport{newMockTransport(), map[string]bool{"0.0.1": true, "0.0.2": true}}, false},
+		{"task topic missing", topicTransport{newMockTransport(), map[string]bool{"0.0.2": true}}, true},
+		{"result topic missing", topicTransport{newMockTransport(), map[string]bool{"0.0.1": true}}, true},
+		{"transport cannot inspect", newMockTransport(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(HandlerConfig{Transport: tt.transport, TaskTopicID: "0.0.1", ResultTopicID: "0.0.2"})
+			err := h.CheckTopics(context.Background())
+			if tt.wantErr != errors.Is(err, ErrTopicNotFound) {
+				t.Errorf("CheckTopics() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestPublishHealth_Success(t *testing.T) {
 	mt := newMockTransport()
 	h := NewHandler(HandlerConfig{
@@ -350,3 +668,101 @@ func TestPublishResult_SequenceIncrement(t *testing.T) {
 		t.Errorf("sequence numbers should be monotonically increasing: %v", seqs)
 	}
 }
+
+func TestHandler_SequenceResumesAfterRestart(t *testing.T) {
+	seqFile := filepath.Join(t.TempDir(), "seq")
+	ctx := context.Background()
+
+	first := NewHandler(HandlerConfig{Transport: newMockTransport(), AgentID: "agent-1", SequenceFile: seqFile})
+	for i := 0; i < 3; i++ {
+		if err := first.PublishHealth(ctx, HealthStatus{AgentID: "agent-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mt := newMockTransport()
+	restarted := NewHandler(HandlerConfig{Transport: mt, AgentID: "agent-1", SequenceFile: seqFile})
+	if err := restarted.PublishResult(ctx, TaskResult{TaskID: "task-1", Status: "completed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := UnmarshalEnvelope(mt.published[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first handler reserved 1..seqReserve; the restart skips the rest.
+	if env.SequenceNum != seqReserve+1 {
+		t.Errorf("expected restarted handler to continue at %d, got %d", seqReserve+1, env.SequenceNum)
+	}
+}
+
+func TestHandler_SequenceWritesHighWaterMark(t *testing.T) {
+	seqFile := filepath.Join(t.TempDir(), "seq")
+	h := NewHandler(HandlerConfig{Transport: newMockTransport(), AgentID: "agent-1", SequenceFile: seqFile})
+	for i := 0; i < seqReserve+1; i++ {
+		if err := h.PublishHealth(context.Background(), HealthStatus{AgentID: "agent-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := loadSequence(seqFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 2*seqReserve {
+		t.Errorf("expected high-water mark %d, got %d", 2*seqReserve, got)
+	}
+}
+
+func TestHandler_CorruptSequenceFile(t *testing.T) {
+	seqFile := filepath.Join(t.TempDir(), "seq")
+	if err := os.WriteFile(seqFile, []byte("not-a-number\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mt := newMockTransport()
+	h := NewHandler(HandlerConfig{Transport: mt, AgentID: "agent-1", SequenceFile: seqFile})
+	if err := h.CheckSequence(); !errors.Is(err, ErrSequenceFile) {
+		t.Errorf("CheckSequence() = %v, want ErrSequenceFile", err)
+	}
+	if err := h.PublishHealth(context.Background(), HealthStatus{AgentID: "agent-1"}); !errors.Is(err, ErrSequenceFile) {
+		t.Errorf("PublishHealth() = %v, want ErrSequenceFile", err)
+	}
+	if len(mt.published) != 0 {
+		t.Errorf("expected nothing published, got %d messages", len(mt.published))
+	}
+}
+
+type queueObserver struct {
+	depths           []int
+	blocked, dropped int
+}
+
+func (o *queueObserver) ObserveTaskQueue(depth, _ int) { o.depths = append(o.depths, depth) }
+func (o *queueObserver) ObserveTaskBlocked()           { o.blocked++ }
+func (o *queueObserver) ObserveTaskDropped()           { o.dropped++ }
+
+func TestHandler_TaskQueueMetrics(t *testing.T) {
+	obs := &queueObserver{}
+	h := NewHandler(HandlerConfig{Transport: newMockTransport(), AgentID: "a", TaskBuffer: 1, Observer: obs})
+
+	if err := h.HandleTask(context.Background(), TaskAssignment{TaskID: "t1"}); err != nil {
+		t.Fatalf("HandleTask: %v", err)
+	}
+
+	// The queue is full; the second task blocks until the context expires
+	// and is dropped.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.HandleTask(ctx, TaskAssignment{TaskID: "t2"}); err == nil {
+		t.Fatal("expected error for dropped task")
+	}
+
+	st := h.QueueStats()
+	if st != (QueueStats{Depth: 1, Capacity: 1, Blocked: 1, Dropped: 1}) {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+	if len(obs.depths) != 1 || obs.depths[0] != 1 || obs.blocked != 1 || obs.dropped != 1 {
+		t.Errorf("unexpected observer calls: %+v", obs)
+	}
+}
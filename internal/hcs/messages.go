@@ -3,6 +3,7 @@ package hcs
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -12,6 +13,7 @@ var (
 	ErrPublishFailed      = errors.New("hcs: message publish failed")
 	ErrInvalidMessage     = errors.New("hcs: received invalid message format")
 	ErrTopicNotFound      = errors.New("hcs: topic not found")
+	ErrSequenceFile       = errors.New("hcs: sequence file unusable")
 )
 
 // MessageType identifies the kind of protocol message in an envelope.
@@ -43,15 +45,38 @@ func (e *Envelope) Marshal() ([]byte, error) {
 	return json.Marshal(e)
 }
 
+// invalidPreviewBytes is how much of a malformed message is quoted in
+// its error.
+const invalidPreviewBytes = 64
+
 // UnmarshalEnvelope deserializes JSON bytes from HCS into an Envelope.
+// Failures wrap both ErrInvalidMessage and the underlying JSON error, and
+// quote the message's type (if readable) and first bytes, so schema drift
+// with the coordinator can be diagnosed.
 func UnmarshalEnvelope(data []byte) (*Envelope, error) {
 	var env Envelope
 	if err := json.Unmarshal(data, &env); err != nil {
-		return nil, err
+		return nil, invalidMessage("envelope", data, err)
 	}
 	return &env, nil
 }
 
+// invalidMessage describes a message part that failed to decode.
+func invalidMessage(part string, data []byte, err error) error {
+	var probe struct {
+		Type MessageType `json:"type"`
+	}
+	typ := "unknown"
+	if json.Unmarshal(data, &probe) == nil && probe.Type != "" {
+		typ = string(probe.Type)
+	}
+	preview := data
+	if len(preview) > invalidPreviewBytes {
+		preview = preview[:invalidPreviewBytes]
+	}
+	return fmt.Errorf("%w: %s (type %s, starts %q): %w", ErrInvalidMessage, part, typ, preview, err)
+}
+
 // TaskAssignment is received from the coordinator when a new task is assigned.
 type TaskAssignment struct {
 	TaskID      string    `json:"task_id"`
@@ -61,6 +86,11 @@ type TaskAssignment struct {
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	CallbackURL string    `json:"callback_url,omitempty"`
 	Deadline    time.Time `json:"deadline,omitempty"`
+	// ProviderAddress and ProviderURL optionally pin the task to a specific
+	// compute provider instead of letting the agent discover one. A pinned
+	// URL is only used if the service listing gives it for that provider.
+	ProviderAddress string `json:"provider_address,omitempty"`
+	ProviderURL     string `json:"provider_url,omitempty"`
 }
 
 // TaskResult is published back to the coordinator when a task completes.
@@ -73,6 +103,8 @@ type TaskResult struct {
 	StorageContentID  string  `json:"storage_content_id,omitempty"`
 	INFTTokenID       string  `json:"inft_token_id,omitempty"`
 	AuditSubmissionID string  `json:"audit_submission_id,omitempty"`
+	Provider          string  `json:"provider,omitempty"` // compute provider that served the job
+	Stage             string  `json:"stage,omitempty"`    // pipeline stage a failed task stopped in
 	Error             string  `json:"error,omitempty"`
 	SignalConfidence  float64 `json:"signal_confidence,omitempty"` // 0.0-1.0, for CRE Risk Router Gate 1
 	RiskScore         int     `json:"risk_score,omitempty"`        // 0-100, for CRE Risk Router Gate 2
@@ -86,4 +118,7 @@ type HealthStatus struct {
 	UptimeSeconds  int64  `json:"uptime_seconds"`
 	CompletedTasks int    `json:"completed_tasks"`
 	FailedTasks    int    `json:"failed_tasks"`
+	// HealthScore is 0-100 (100 healthy), combining recent failure rate,
+	// subscription state, and chain reachability.
+	HealthScore int `json:"health_score"`
 }
@@ -0,0 +1,76 @@
+package hcs
+
+import "context"
+
+const defaultTaskBuffer = 16
+
+// Observer receives task queue metrics from the handler. Implementations
+// must be safe for concurrent use and should return quickly.
+type Observer interface {
+	// ObserveTaskQueue reports the queue depth after a task is enqueued.
+	ObserveTaskQueue(depth, capacity int)
+	// ObserveTaskBlocked is called when the queue is full and delivery of
+	// an incoming task has to wait for the agent.
+	ObserveTaskBlocked()
+	// ObserveTaskDropped is called when a task is discarded because the
+	// context ended while it waited for queue space.
+	ObserveTaskDropped()
+}
+
+// QueueStats is a snapshot of the task queue.
+type QueueStats struct {
+	Depth    int    `json:"depth"`
+	Capacity int    `json:"capacity"`
+	Blocked  uint64 `json:"blocked"`
+	Dropped  uint64 `json:"dropped"`
+	// Stale counts task messages discarded because their consensus
+	// timestamp was not after the last delivered one.
+	Stale uint64 `json:"stale"`
+	// Invalid counts messages dropped because their envelope or task
+	// payload could not be decoded.
+	Invalid uint64 `json:"invalid"`
+}
+
+// QueueStats returns the current task queue depth and cumulative
+// blocked/dropped counts.
+func (h *Handler) QueueStats() QueueStats {
+	return QueueStats{
+		Depth:    len(h.taskCh),
+		Capacity: cap(h.taskCh),
+		Blocked:  h.blocked.Load(),
+		Dropped:  h.dropped.Load(),
+		Stale:    h.stale.Load(),
+		Invalid:  h.invalid.Load(),
+	}
+}
+
+// enqueue delivers task to the agent, waiting for space if the queue is
+// full. It reports false if ctx ends first and the task is dropped.
+func (h *Handler) enqueue(ctx context.Context, task TaskAssignment) bool {
+	if h.cfg.OnAccept != nil {
+		if err := h.cfg.OnAccept(task); err != nil {
+			h.log.Warn("task accept hook failed", "task_id", task.TaskID, "error", err)
+		}
+	}
+	select {
+	case h.taskCh <- task:
+	default:
+		h.blocked.Add(1)
+		if h.cfg.Observer != nil {
+			h.cfg.Observer.ObserveTaskBlocked()
+		}
+		select {
+		case h.taskCh <- task:
+		case <-ctx.Done():
+			h.dropped.Add(1)
+			if h.cfg.Observer != nil {
+				h.cfg.Observer.ObserveTaskDropped()
+			}
+			return false
+		}
+	}
+	if h.cfg.Observer != nil {
+		h.cfg.Observer.ObserveTaskQueue(len(h.taskCh), cap(h.taskCh))
+	}
+	return true
+}
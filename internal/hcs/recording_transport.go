@@ -0,0 +1,112 @@
+package hcs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const defaultRecordCapacity = 100
+
+// RecordingTransportConfig holds configuration for the recording transport.
+type RecordingTransportConfig struct {
+	// Log receives an info line per published message. Nil uses slog.Default.
+	Log *slog.Logger
+
+	// File, if set, receives every published message, one per line, in the
+	// same format as FileTransport's results file.
+	File string
+
+	// Capacity is how many recent messages Messages retains. Zero uses 100.
+	Capacity int
+}
+
+// RecordedMessage is a message captured by RecordingTransport.
+type RecordedMessage struct {
+	TopicID     string
+	Data        []byte
+	PublishedAt time.Time
+}
+
+// RecordingTransport implements Transport without a network. Published
+// messages are logged, kept in an in-memory ring buffer, and optionally
+// appended to a file; Subscribe never delivers. It stands in for HCS when
+// Hedera credentials are unavailable so local runs are not silently lossy.
+type RecordingTransport struct {
+	log  *slog.Logger
+	file *FileTransport
+
+	mu   sync.Mutex
+	ring []RecordedMessage
+	next int
+	full bool
+}
+
+// NewRecordingTransport creates a recording transport.
+func NewRecordingTransport(cfg RecordingTransportConfig) *RecordingTransport {
+	if cfg.Log == nil {
+		cfg.Log = slog.Default()
+	}
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = defaultRecordCapacity
+	}
+	t := &RecordingTransport{
+		log:  cfg.Log,
+		ring: make([]RecordedMessage, cfg.Capacity),
+	}
+	if cfg.File != "" {
+		t.file = NewFileTransport(FileTransportConfig{ResultsFile: cfg.File})
+	}
+	return t
+}
+
+// Publish records data and, if configured, appends it to the file.
+func (t *RecordingTransport) Publish(ctx context.Context, topicID string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("recording transport: publish to %s: %w", topicID, err)
+	}
+
+	t.mu.Lock()
+	t.ring[t.next] = RecordedMessage{
+		TopicID:     topicID,
+		Data:        append([]byte(nil), data...),
+		PublishedAt: time.Now(),
+	}
+	t.next = (t.next + 1) % len(t.ring)
+	if t.next == 0 {
+		t.full = true
+	}
+	t.mu.Unlock()
+
+	t.log.Info("HCS message recorded (transport offline)", "topic", topicID, "bytes", len(data))
+
+	if t.file != nil {
+		if err := t.file.Publish(ctx, topicID, data); err != nil {
+			return fmt.Errorf("recording transport: %w", err)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns channels that never deliver, as no topic is reachable.
+func (t *RecordingTransport) Subscribe(_ context.Context, _ string) (<-chan []byte, <-chan error) {
+	return make(chan []byte), make(chan error)
+}
+
+// Messages returns the retained messages, oldest first.
+func (t *RecordingTransport) Messages() []RecordedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.full {
+		return append([]RecordedMessage(nil), t.ring[:t.next]...)
+	}
+	out := make([]RecordedMessage, 0, len(t.ring))
+	out = append(out, t.ring[t.next:]...)
+	return append(out, t.ring[:t.next]...)
+}
+
+// Compile-time interface compliance check.
+var _ Transport = (*RecordingTransport)(nil)
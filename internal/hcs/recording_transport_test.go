@@ -0,0 +1,49 @@
+package hcs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordingTransport_RingBuffer(t *testing.T) {
+	rt := NewRecordingTransport(RecordingTransportConfig{
+		Log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Capacity: 2,
+	})
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := rt.Publish(context.Background(), "0.0.1", []byte(msg)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	got := rt.Messages()
+	if len(got) != 2 || string(got[0].Data) != "b" || string(got[1].Data) != "c" {
+		t.Fatalf("expected [b c], got %+v", got)
+	}
+	if got[0].TopicID != "0.0.1" {
+		t.Errorf("TopicID = %q, want 0.0.1", got[0].TopicID)
+	}
+}
+
+func TestRecordingTransport_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	rt := NewRecordingTransport(RecordingTransportConfig{
+		Log:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		File: path,
+	})
+	rt.Publish(context.Background(), "0.0.1", []byte(`{"n":1}`))
+	rt.Publish(context.Background(), "0.0.1", []byte(`{"n":2}`))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Split(strings.TrimSpace(string(data)), "\n"); len(got) != 2 || got[1] != `{"n":2}` {
+		t.Errorf("unexpected file contents: %q", data)
+	}
+}
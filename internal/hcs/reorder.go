@@ -0,0 +1,123 @@
+package hcs
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+const defaultReorderWindow = 500 * time.Millisecond
+
+// Message is a topic message with its consensus metadata.
+type Message struct {
+	Data []byte
+	// ConsensusTimestamp is when the network ordered the message. Zero
+	// means the transport does not know, and the message is delivered
+	// without reordering.
+	ConsensusTimestamp time.Time
+	// SequenceNumber is the topic sequence number, if known.
+	SequenceNumber uint64
+}
+
+// MessageTransport is implemented by transports that can report consensus
+// timestamps. The handler prefers it over Transport.Subscribe so tasks can
+// be delivered in consensus order.
+type MessageTransport interface {
+	SubscribeMessages(ctx context.Context, topicID string) (<-chan Message, <-chan error)
+}
+
+// subscribe opens the task topic, using consensus metadata when the
+// transport provides it.
+func (h *Handler) subscribe(ctx context.Context) (<-chan Message, <-chan error) {
+	if mt, ok := h.cfg.Transport.(MessageTransport); ok {
+		return mt.SubscribeMessages(ctx, h.cfg.TaskTopicID)
+	}
+	dataCh, errCh := h.cfg.Transport.Subscribe(ctx, h.cfg.TaskTopicID)
+	if dataCh == nil {
+		return nil, errCh
+	}
+	msgCh := make(chan Message)
+	go func() {
+		defer close(msgCh)
+		for {
+			var data []byte
+			var ok bool
+			select {
+			case data, ok = <-dataCh:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case msgCh <- Message{Data: data}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return msgCh, errCh
+}
+
+// pendingMessage is a message held in the reorder buffer.
+type pendingMessage struct {
+	msg     Message
+	arrived time.Time
+}
+
+// reorderHeap orders pending messages by consensus timestamp, then
+// sequence number.
+type reorderHeap []pendingMessage
+
+func (r reorderHeap) Len() int { return len(r) }
+func (r reorderHeap) Less(i, j int) bool {
+	a, b := r[i].msg, r[j].msg
+	if !a.ConsensusTimestamp.Equal(b.ConsensusTimestamp) {
+		return a.ConsensusTimestamp.Before(b.ConsensusTimestamp)
+	}
+	return a.SequenceNumber < b.SequenceNumber
+}
+func (r reorderHeap) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r *reorderHeap) Push(x any)   { *r = append(*r, x.(pendingMessage)) }
+func (r *reorderHeap) Pop() any {
+	old := *r
+	x := old[len(old)-1]
+	*r = old[:len(old)-1]
+	return x
+}
+
+// receive buffers a timestamped message for ordered release, or processes
+// an untimestamped one immediately. Messages at or before the last released
+// timestamp are dropped as stale.
+func (h *Handler) receive(ctx context.Context, msg Message) {
+	if msg.ConsensusTimestamp.IsZero() {
+		h.processMessage(ctx, msg.Data)
+		return
+	}
+	if !msg.ConsensusTimestamp.After(h.lastConsensus) {
+		h.stale.Add(1)
+		return
+	}
+	heap.Push(&h.pending, pendingMessage{msg: msg, arrived: h.clock.Now()})
+}
+
+// releaseDue processes buffered messages in timestamp order while the
+// earliest one has been held for the full reorder window, and returns how
+// long until the next one is due (zero if the buffer is empty).
+func (h *Handler) releaseDue(ctx context.Context, all bool) time.Duration {
+	for h.pending.Len() > 0 {
+		wait := h.pending[0].arrived.Add(h.cfg.ReorderWindow).Sub(h.clock.Now())
+		if wait > 0 && !all {
+			return wait
+		}
+		p := heap.Pop(&h.pending).(pendingMessage)
+		if !p.msg.ConsensusTimestamp.After(h.lastConsensus) {
+			h.stale.Add(1)
+			continue
+		}
+		h.lastConsensus = p.msg.ConsensusTimestamp
+		h.processMessage(ctx, p.msg.Data)
+	}
+	return 0
+}
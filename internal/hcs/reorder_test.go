@@ -0,0 +1,97 @@
+package hcs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+)
+
+// messageTransport is a mockTransport that also reports consensus
+// timestamps. Its message channel is unbuffered so a send returns only once
+// the handler has taken the message.
+type messageTransport struct {
+	*mockTransport
+	msgs chan Message
+}
+
+func (m *messageTransport) SubscribeMessages(_ context.Context, _ string) (<-chan Message, <-chan error) {
+	return m.msgs, m.subErr
+}
+
+func taskMessage(t *testing.T, taskID string, ts time.Time) Message {
+	t.Helper()
+	payload, _ := json.Marshal(TaskAssignment{TaskID: taskID})
+	env := Envelope{Type: MessageTypeTaskAssignment, Sender: "coordinator", Payload: payload}
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Message{Data: data, ConsensusTimestamp: ts}
+}
+
+func nextTask(t *testing.T, h *Handler) string {
+	t.Helper()
+	select {
+	case task := <-h.Tasks():
+		return task.TaskID
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for task")
+		return ""
+	}
+}
+
+// barrier sends an untimestamped task, which the handler delivers at once,
+// and waits for it; every earlier message has then been buffered.
+func barrier(t *testing.T, mt *messageTransport, h *Handler) {
+	t.Helper()
+	mt.msgs <- taskMessage(t, "barrier", time.Time{})
+	if got := nextTask(t, h); got != "barrier" {
+		t.Fatalf("got %s before barrier", got)
+	}
+}
+
+func TestStartSubscription_ConsensusOrder(t *testing.T) {
+	mt := &messageTransport{mockTransport: newMockTransport(), msgs: make(chan Message)}
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	h := NewHandler(HandlerConfig{
+		Transport:     mt,
+		TaskTopicID:   "topic-1",
+		AgentID:       "agent-1",
+		ReorderWindow: time.Second,
+		Clock:         clk,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.StartSubscription(ctx)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mt.msgs <- taskMessage(t, "task-2", base.Add(2*time.Second))
+	mt.msgs <- taskMessage(t, "task-1", base.Add(1*time.Second))
+	barrier(t, mt, h)
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	if got := nextTask(t, h); got != "task-1" {
+		t.Fatalf("first task = %s, want task-1", got)
+	}
+	if got := nextTask(t, h); got != "task-2" {
+		t.Fatalf("second task = %s, want task-2", got)
+	}
+
+	// Older than the last delivered timestamp: dropped.
+	mt.msgs <- taskMessage(t, "task-old", base)
+	mt.msgs <- taskMessage(t, "task-3", base.Add(3*time.Second))
+	barrier(t, mt, h)
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	if got := nextTask(t, h); got != "task-3" {
+		t.Fatalf("third task = %s, want task-3", got)
+	}
+	if stale := h.QueueStats().Stale; stale != 1 {
+		t.Errorf("stale = %d, want 1", stale)
+	}
+}
@@ -0,0 +1,76 @@
+package hcs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lancekrogers/agent-inference/internal/atomicfile"
+)
+
+// seqReserve is how many sequence numbers are reserved per write to the
+// SequenceFile. The file holds a high-water mark rather than the last
+// number used, so it is written once per seqReserve messages and a
+// restarted agent skips the unused remainder of the block.
+const seqReserve = 100
+
+// loadSequence reads the persisted sequence high-water mark from path.
+// A missing file starts the sequence from zero; an unreadable or corrupt
+// one is an error wrapping ErrSequenceFile, since restarting at zero
+// would reuse sequence numbers.
+func loadSequence(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("hcs: read sequence file %s: %w: %w", path, ErrSequenceFile, err)
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("hcs: parse sequence file %s: %w: %w", path, ErrSequenceFile, err)
+	}
+	return n, nil
+}
+
+// saveSequence atomically writes n to path.
+func saveSequence(path string, n uint64) error {
+	return atomicfile.Write(path, []byte(strconv.FormatUint(n, 10)+"\n"))
+}
+
+// CheckSequence reports whether the SequenceFile loaded cleanly, so a
+// corrupt file fails startup rather than the first publish. It returns
+// nil when no SequenceFile is configured.
+func (h *Handler) CheckSequence() error {
+	return h.seqErr
+}
+
+// nextSeq returns the next envelope sequence number. With a SequenceFile
+// configured, a number is only handed out once the file records a
+// high-water mark at or above it, so a restarted agent never reuses one.
+// A sequence file that failed to load or cannot be written is an error
+// wrapping ErrSequenceFile.
+func (h *Handler) nextSeq() (uint64, error) {
+	if h.seqErr != nil {
+		return 0, h.seqErr
+	}
+	if h.cfg.SequenceFile == "" {
+		return h.seqNum.Add(1), nil
+	}
+
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+	n := h.seqNum.Load() + 1
+	if n > h.seqSaved {
+		mark := n + seqReserve - 1
+		if err := saveSequence(h.cfg.SequenceFile, mark); err != nil {
+			return 0, fmt.Errorf("hcs: persist sequence: %w: %w", ErrSequenceFile, err)
+		}
+		h.seqSaved = mark
+	}
+	h.seqNum.Store(n)
+	return n, nil
+}
@@ -2,32 +2,55 @@ package hcs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	hiero "github.com/hiero-ledger/hiero-sdk-go/v2/sdk"
+	"google.golang.org/grpc/status"
+
+	"github.com/lancekrogers/agent-inference/internal/retry"
 )
 
 const (
-	defaultMessageBuffer  = 100
-	defaultReconnectDelay = 2 * time.Second
-	defaultMaxReconnects  = 10
+	defaultMessageBuffer       = 100
+	defaultReconnectDelay      = 2 * time.Second
+	defaultMaxReconnectDelay   = time.Minute
+	defaultReconnectResetAfter = 5 * time.Minute
+	defaultMaxReconnects       = 10
 )
 
 // HCSTransportConfig holds configuration for the live Hedera transport.
 type HCSTransportConfig struct {
-	Client         *hiero.Client
-	MessageBuffer  int
+	Client        *hiero.Client
+	MessageBuffer int
+	// ReconnectDelay is the initial delay before resubscribing. It doubles
+	// on each consecutive failure, with jitter, up to MaxReconnectDelay.
 	ReconnectDelay time.Duration
-	MaxReconnects  int
+	// MaxReconnectDelay caps the reconnect backoff.
+	MaxReconnectDelay time.Duration
+	// ReconnectResetAfter is how long a subscription must stay up before
+	// the backoff and the reconnect budget are reset.
+	ReconnectResetAfter time.Duration
+	// MaxReconnects is the number of consecutive failed resubscriptions
+	// tolerated before giving up.
+	MaxReconnects int
+	// FetchRecords queries the transaction record after each publish so
+	// the Receipt carries the consensus timestamp. Record queries are
+	// paid, so this is off by default and receipts carry only the
+	// transaction ID and topic sequence number.
+	FetchRecords bool
 }
 
 // HCSTransport implements Transport using the Hiero (Hedera) SDK.
 type HCSTransport struct {
-	client         *hiero.Client
-	messageBuffer  int
-	reconnectDelay time.Duration
-	maxReconnects  int
+	client            *hiero.Client
+	messageBuffer     int
+	reconnectDelay    time.Duration
+	maxReconnectDelay time.Duration
+	resetAfter        time.Duration
+	maxReconnects     int
+	fetchRecords      bool
 }
 
 // NewHCSTransport creates a new HCS transport backed by a live Hedera client.
@@ -40,28 +63,47 @@ func NewHCSTransport(cfg HCSTransportConfig) *HCSTransport {
 	if delay <= 0 {
 		delay = defaultReconnectDelay
 	}
+	maxDelay := cfg.MaxReconnectDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxReconnectDelay
+	}
+	maxDelay = max(maxDelay, delay)
+	resetAfter := cfg.ReconnectResetAfter
+	if resetAfter <= 0 {
+		resetAfter = defaultReconnectResetAfter
+	}
 	maxR := cfg.MaxReconnects
 	if maxR <= 0 {
 		maxR = defaultMaxReconnects
 	}
 
 	return &HCSTransport{
-		client:         cfg.Client,
-		messageBuffer:  buf,
-		reconnectDelay: delay,
-		maxReconnects:  maxR,
+		client:            cfg.Client,
+		messageBuffer:     buf,
+		reconnectDelay:    delay,
+		maxReconnectDelay: maxDelay,
+		resetAfter:        resetAfter,
+		maxReconnects:     maxR,
+		fetchRecords:      cfg.FetchRecords,
 	}
 }
 
 // Publish sends raw bytes to an HCS topic.
 func (t *HCSTransport) Publish(ctx context.Context, topicID string, data []byte) error {
+	_, err := t.PublishReceipt(ctx, topicID, data)
+	return err
+}
+
+// PublishReceipt sends raw bytes to an HCS topic and reports where the
+// message landed.
+func (t *HCSTransport) PublishReceipt(ctx context.Context, topicID string, data []byte) (Receipt, error) {
 	if err := ctx.Err(); err != nil {
-		return fmt.Errorf("hcs transport: publish to %s: %w", topicID, err)
+		return Receipt{}, fmt.Errorf("hcs transport: publish to %s: %w", topicID, err)
 	}
 
 	tid, err := hiero.TopicIDFromString(topicID)
 	if err != nil {
-		return fmt.Errorf("hcs transport: parse topic %s: %w", topicID, err)
+		return Receipt{}, fmt.Errorf("hcs transport: parse topic %s: %w", topicID, err)
 	}
 
 	tx, err := hiero.NewTopicMessageSubmitTransaction().
@@ -69,31 +111,90 @@ func (t *HCSTransport) Publish(ctx context.Context, topicID string, data []byte)
 		SetMessage(data).
 		FreezeWith(t.client)
 	if err != nil {
-		return fmt.Errorf("hcs transport: publish to %s: freeze: %w", topicID, err)
+		return Receipt{}, fmt.Errorf("hcs transport: publish to %s: freeze: %w", topicID, err)
 	}
 
 	resp, err := tx.Execute(t.client)
 	if err != nil {
-		return fmt.Errorf("hcs transport: publish to %s: execute: %w", topicID, err)
+		return Receipt{}, fmt.Errorf("hcs transport: publish to %s: execute: %w", topicID, err)
 	}
 
-	_, err = resp.GetReceipt(t.client)
+	receipt, err := resp.GetReceipt(t.client)
 	if err != nil {
-		return fmt.Errorf("hcs transport: publish to %s: receipt: %w", topicID, err)
+		return Receipt{}, fmt.Errorf("hcs transport: publish to %s: receipt: %w", topicID, err)
 	}
 
-	return nil
+	out := Receipt{
+		TopicID:        topicID,
+		TransactionID:  resp.TransactionID.String(),
+		SequenceNumber: receipt.TopicSequenceNumber,
+	}
+	if t.fetchRecords {
+		// The message is already on the topic; a failed record query only
+		// costs the timestamp.
+		if record, err := resp.GetRecord(t.client); err == nil {
+			out.ConsensusTimestamp = record.ConsensusTimestamp
+		}
+	}
+	return out, nil
+}
+
+// TopicInfo queries the network for a topic. A topic ID that is malformed,
+// unknown, or expired yields an error wrapping ErrTopicNotFound.
+func (t *HCSTransport) TopicInfo(ctx context.Context, topicID string) (TopicInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return TopicInfo{}, fmt.Errorf("hcs transport: topic info %s: %w", topicID, err)
+	}
+
+	tid, err := hiero.TopicIDFromString(topicID)
+	if err != nil {
+		return TopicInfo{}, fmt.Errorf("hcs transport: parse topic %s: %v: %w", topicID, err, ErrTopicNotFound)
+	}
+
+	info, err := hiero.NewTopicInfoQuery().SetTopicID(tid).Execute(t.client)
+	if err != nil {
+		var precheck hiero.ErrHederaPreCheckStatus
+		if errors.As(err, &precheck) &&
+			(precheck.Status == hiero.StatusInvalidTopicID || precheck.Status == hiero.StatusTopicExpired) {
+			return TopicInfo{}, fmt.Errorf("hcs transport: topic %s: %s: %w", topicID, precheck.Status, ErrTopicNotFound)
+		}
+		return TopicInfo{}, fmt.Errorf("hcs transport: topic info %s: %w", topicID, err)
+	}
+	return TopicInfo{
+		TopicID:        topicID,
+		Memo:           info.TopicMemo,
+		SequenceNumber: info.SequenceNumber,
+		ExpirationTime: info.ExpirationTime,
+	}, nil
 }
 
 // Subscribe starts receiving messages from an HCS topic.
 // Messages are delivered as raw bytes to the returned channel until ctx is cancelled.
 func (t *HCSTransport) Subscribe(ctx context.Context, topicID string) (<-chan []byte, <-chan error) {
-	msgCh := make(chan []byte, t.messageBuffer)
+	msgCh, errCh := t.SubscribeMessages(ctx, topicID)
+	dataCh := make(chan []byte, t.messageBuffer)
+	go func() {
+		defer close(dataCh)
+		for msg := range msgCh {
+			select {
+			case dataCh <- msg.Data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return dataCh, errCh
+}
+
+// SubscribeMessages is like Subscribe but includes each message's consensus
+// timestamp and sequence number.
+func (t *HCSTransport) SubscribeMessages(ctx context.Context, topicID string) (<-chan Message, <-chan error) {
+	msgCh := make(chan Message, t.messageBuffer)
 	errCh := make(chan error, t.messageBuffer)
 
 	tid, err := hiero.TopicIDFromString(topicID)
 	if err != nil {
-		errCh <- fmt.Errorf("hcs transport: parse topic %s: %w", topicID, err)
+		errCh <- fmt.Errorf("hcs transport: parse topic %s: %v: %w", topicID, err, ErrSubscriptionFailed)
 		close(msgCh)
 		close(errCh)
 		return msgCh, errCh
@@ -108,36 +209,48 @@ func (t *HCSTransport) runSubscription(
 	ctx context.Context,
 	tid hiero.TopicID,
 	topicStr string,
-	msgCh chan<- []byte,
+	msgCh chan<- Message,
 	errCh chan<- error,
 ) {
 	defer close(msgCh)
 	defer close(errCh)
 
-	for reconnects := 0; reconnects <= t.maxReconnects; reconnects++ {
+	bo := retry.NewBackoff(t.reconnectDelay, t.maxReconnectDelay)
+	for failures := 0; ; {
 		if ctx.Err() != nil {
 			return
 		}
 
+		started := time.Now()
 		err := t.subscribeOnce(ctx, tid, msgCh)
 		if err == nil || ctx.Err() != nil {
 			return
 		}
+		// A subscription that stayed up for a while was healthy; start the
+		// next round of reconnects from scratch.
+		if time.Since(started) >= t.resetAfter {
+			bo.Reset()
+			failures = 0
+		}
+		failures++
 
 		select {
-		case errCh <- fmt.Errorf("hcs transport: subscribe to %s attempt %d: %w", topicStr, reconnects+1, err):
+		case errCh <- fmt.Errorf("hcs transport: subscribe to %s attempt %d: %w", topicStr, failures, err):
 		default:
 		}
+		if failures > t.maxReconnects {
+			break
+		}
 
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(t.reconnectDelay):
+		case <-time.After(bo.Next()):
 		}
 	}
 
 	select {
-	case errCh <- fmt.Errorf("hcs transport: subscribe to %s: exhausted %d reconnect attempts", topicStr, t.maxReconnects+1):
+	case errCh <- fmt.Errorf("hcs transport: subscribe to %s: exhausted %d reconnect attempts: %w", topicStr, t.maxReconnects+1, ErrSubscriptionFailed):
 	default:
 	}
 }
@@ -145,18 +258,33 @@ func (t *HCSTransport) runSubscription(
 func (t *HCSTransport) subscribeOnce(
 	ctx context.Context,
 	tid hiero.TopicID,
-	msgCh chan<- []byte,
+	msgCh chan<- Message,
 ) error {
 	// Start from 30 seconds ago to avoid replaying the entire topic history.
 	// This ensures we only process recent/new task assignments.
 	startTime := time.Now().Add(-30 * time.Second)
+	streamErr := make(chan error, 1)
 	handle, err := hiero.NewTopicMessageQuery().
 		SetTopicID(tid).
 		SetStartTime(startTime).
+		SetErrorHandler(func(stat status.Status) {
+			err := stat.Err()
+			if err == nil {
+				err = errors.New(stat.Message())
+			}
+			select {
+			case streamErr <- err:
+			default:
+			}
+		}).
 		Subscribe(t.client, func(message hiero.TopicMessage) {
-			data := append([]byte(nil), message.Contents...)
+			msg := Message{
+				Data:               append([]byte(nil), message.Contents...),
+				ConsensusTimestamp: message.ConsensusTimestamp,
+				SequenceNumber:     message.SequenceNumber,
+			}
 			select {
-			case msgCh <- data:
+			case msgCh <- msg:
 			case <-ctx.Done():
 			}
 		})
@@ -164,10 +292,17 @@ func (t *HCSTransport) subscribeOnce(
 		return fmt.Errorf("start subscription: %w", err)
 	}
 
-	<-ctx.Done()
-	handle.Unsubscribe()
-	return nil
+	defer handle.Unsubscribe()
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-streamErr:
+		return fmt.Errorf("subscription stream: %w", err)
+	}
 }
 
-// Compile-time interface compliance check.
-var _ Transport = (*HCSTransport)(nil)
+// Compile-time interface compliance checks.
+var (
+	_ Transport        = (*HCSTransport)(nil)
+	_ MessageTransport = (*HCSTransport)(nil)
+)
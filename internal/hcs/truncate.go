@@ -0,0 +1,25 @@
+package hcs
+
+import "unicode/utf8"
+
+// truncatedMarker ends an Output cut to HandlerConfig.MaxInlineResultBytes.
+const truncatedMarker = "…[truncated; full output in storage]"
+
+// truncateOutput shortens r.Output to at most max bytes, marker included,
+// when the full output is retrievable from storage. A max too small to
+// hold the marker cuts the output without one. Results without a
+// StorageContentID are left whole, since the output exists nowhere else.
+func truncateOutput(r TaskResult, max int) TaskResult {
+	if max <= 0 || len(r.Output) <= max || r.StorageContentID == "" {
+		return r
+	}
+	keep, marker := max-len(truncatedMarker), truncatedMarker
+	if keep < 0 {
+		keep, marker = max, ""
+	}
+	for keep > 0 && !utf8.RuneStart(r.Output[keep]) {
+		keep--
+	}
+	r.Output = r.Output[:keep] + marker
+	return r
+}
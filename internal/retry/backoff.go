@@ -0,0 +1,40 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff yields exponentially growing delays with jitter, for loops that
+// manage their own attempts, such as reconnecting a subscription. Each
+// delay is drawn from [d/2, d), where d doubles from base up to max.
+type Backoff struct {
+	base    time.Duration
+	max     time.Duration
+	attempt int
+	rand    func() float64 // nil disables jitter
+}
+
+// NewBackoff returns a jittered Backoff. A zero max leaves it uncapped.
+func NewBackoff(base, max time.Duration) *Backoff {
+	return &Backoff{base: base, max: max, rand: rand.Float64}
+}
+
+// Next returns the delay before the next attempt and advances the backoff.
+func (b *Backoff) Next() time.Duration {
+	d := b.base << min(b.attempt, 31)
+	if b.max > 0 {
+		d = min(d, b.max)
+	}
+	b.attempt++
+	if b.rand == nil {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(b.rand()*float64(d-half))
+}
+
+// Reset restarts the backoff from the base delay.
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
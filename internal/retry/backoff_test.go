@@ -0,0 +1,60 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff_GrowsWithJitterAndCaps(t *testing.T) {
+	b := NewBackoff(time.Second, 8*time.Second)
+
+	b.rand = func() float64 { return 0 }
+	var lows []time.Duration
+	for range 5 {
+		lows = append(lows, b.Next())
+	}
+	want := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for i := range want {
+		if lows[i] != want[i] {
+			t.Errorf("delay %d with no jitter = %s, want %s", i, lows[i], want[i])
+		}
+	}
+
+	b.rand = func() float64 { return 0.999 }
+	if d := b.Next(); d <= 7*time.Second || d >= 8*time.Second {
+		t.Errorf("capped delay with max jitter = %s, want just under 8s", d)
+	}
+}
+
+func TestBackoff_Reset(t *testing.T) {
+	b := NewBackoff(time.Second, time.Minute)
+	b.rand = func() float64 { return 0 }
+	for range 4 {
+		b.Next()
+	}
+	b.Reset()
+	if d := b.Next(); d != 500*time.Millisecond {
+		t.Errorf("delay after reset = %s, want 500ms", d)
+	}
+}
+
+func TestBackoff_JitterStaysInRange(t *testing.T) {
+	b := NewBackoff(100*time.Millisecond, time.Second)
+	for range 50 {
+		d := b.Next()
+		if d < 50*time.Millisecond || d >= time.Second {
+			t.Fatalf("delay %s outside [50ms, 1s)", d)
+		}
+	}
+}
+
+func TestBackoff_Uncapped(t *testing.T) {
+	b := NewBackoff(time.Second, 0)
+	b.rand = nil
+	for range 10 {
+		b.Next()
+	}
+	if d := b.Next(); d != 1024*time.Second {
+		t.Errorf("11th uncapped delay = %s, want 1024s", d)
+	}
+}
@@ -0,0 +1,66 @@
+// Package retry runs an operation with bounded attempts and exponential
+// backoff, so every package that talks to the network backs off the same
+// way.
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+)
+
+// Policy describes how an operation is retried.
+type Policy struct {
+	// MaxAttempts is the total number of calls, including the first.
+	// Values below 1 mean a single attempt.
+	MaxAttempts int
+	// BaseDelay is the wait after the first failure; it doubles after
+	// each further failure.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts. Zero leaves it uncapped.
+	MaxDelay time.Duration
+	// Jitter draws each wait from [d/2, d) instead of waiting exactly d,
+	// so callers failing together do not retry together.
+	Jitter bool
+	// Retryable reports whether a failure is worth retrying. Nil retries
+	// every error.
+	Retryable func(error) bool
+	// Clock drives the waits between attempts. Nil uses real time.
+	Clock clock.Clock
+}
+
+// Do calls fn until it succeeds, returns an error Retryable rejects, or
+// MaxAttempts calls have been made, and returns fn's last error. If ctx
+// ends first — before an attempt, after a failed one, or during a wait —
+// Do returns ctx.Err() instead, so callers can tell cancellation from
+// exhaustion with errors.Is.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	clk := clock.OrReal(p.Clock)
+	bo := NewBackoff(p.BaseDelay, p.MaxDelay)
+	if !p.Jitter {
+		bo.rand = nil
+	}
+	attempts := max(p.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= attempts || (p.Retryable != nil && !p.Retryable(err)) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-clk.After(bo.Next()):
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+)
+
+// instantClock fires every wait at once and records its duration.
+type instantClock struct {
+	clock.Clock
+	waits []time.Duration
+}
+
+func (c *instantClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+var (
+	errTransient = errors.New("transient")
+	errFatal     = errors.New("fatal")
+)
+
+func TestDo_Attempts(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		failures  []error // errors returned by successive calls; then success
+		wantCalls int
+		wantErr   error
+		wantWaits []time.Duration
+	}{
+		{
+			name:      "first call succeeds",
+			policy:    Policy{MaxAttempts: 3, BaseDelay: time.Second},
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds after retries",
+			policy:    Policy{MaxAttempts: 3, BaseDelay: time.Second},
+			failures:  []error{errTransient, errTransient},
+			wantCalls: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "exhausts attempts",
+			policy:    Policy{MaxAttempts: 3, BaseDelay: time.Second},
+			failures:  []error{errTransient, errTransient, errTransient, errTransient},
+			wantCalls: 3,
+			wantErr:   errTransient,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "zero attempts means one",
+			policy:    Policy{},
+			failures:  []error{errTransient},
+			wantCalls: 1,
+			wantErr:   errTransient,
+		},
+		{
+			name:      "delay capped",
+			policy:    Policy{MaxAttempts: 4, BaseDelay: time.Second, MaxDelay: 3 * time.Second},
+			failures:  []error{errTransient, errTransient, errTransient},
+			wantCalls: 4,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name: "non-retryable stops at once",
+			policy: Policy{MaxAttempts: 5, BaseDelay: time.Second, Retryable: func(err error) bool {
+				return errors.Is(err, errTransient)
+			}},
+			failures:  []error{errTransient, errFatal, errTransient},
+			wantCalls: 2,
+			wantErr:   errFatal,
+			wantWaits: []time.Duration{time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := &instantClock{}
+			tt.policy.Clock = clk
+			calls := 0
+			err := Do(context.Background(), tt.policy, func(context.Context) error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !slices.Equal(clk.waits, tt.wantWaits) {
+				t.Errorf("waits = %v, want %v", clk.waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+func TestDo_ContextCancellation(t *testing.T) {
+	t.Run("before first attempt", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		calls := 0
+		err := Do(ctx, Policy{MaxAttempts: 3}, func(context.Context) error {
+			calls++
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) || calls != 0 {
+			t.Errorf("err = %v after %d calls, want context.Canceled after 0", err, calls)
+		}
+	})
+
+	t.Run("during backoff", func(t *testing.T) {
+		fake := clock.NewFake(time.Unix(0, 0))
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		done := make(chan error, 1)
+		go func() {
+			done <- Do(ctx, Policy{MaxAttempts: 3, BaseDelay: time.Hour, Clock: fake}, func(context.Context) error {
+				calls++
+				return errTransient
+			})
+		}()
+		fake.BlockUntil(1)
+		cancel()
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Do did not return after cancellation")
+		}
+		if calls != 1 {
+			t.Errorf("calls = %d, want 1", calls)
+		}
+	})
+
+	t.Run("failure caused by cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		clk := &instantClock{}
+		err := Do(ctx, Policy{MaxAttempts: 3, Clock: clk}, func(context.Context) error {
+			cancel()
+			return errTransient
+		})
+		if !errors.Is(err, context.Canceled) || len(clk.waits) != 0 {
+			t.Errorf("err = %v with %d waits, want context.Canceled without waiting", err, len(clk.waits))
+		}
+	})
+}
@@ -5,8 +5,10 @@ package zerog
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -14,6 +16,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // ChainBackend combines the go-ethereum interfaces needed for on-chain
@@ -21,15 +24,92 @@ import (
 type ChainBackend interface {
 	bind.ContractBackend
 	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error)
 }
 
-// DialClient connects to an Ethereum-compatible JSON-RPC endpoint.
-func DialClient(ctx context.Context, rpcURL string) (*ethclient.Client, error) {
-	client, err := ethclient.DialContext(ctx, rpcURL)
+// DialClient connects to an Ethereum-compatible JSON-RPC endpoint. HTTP
+// endpoints use transport when it is non-nil (see NewHTTPTransport).
+func DialClient(ctx context.Context, rpcURL string, transport http.RoundTripper) (*ethclient.Client, error) {
+	var opts []rpc.ClientOption
+	if transport != nil {
+		opts = append(opts, rpc.WithHTTPClient(&http.Client{Transport: transport}))
+	}
+	c, err := rpc.DialOptions(ctx, rpcURL, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("zerog: dial %s: %w", rpcURL, err)
 	}
-	return client, nil
+	return ethclient.NewClient(c), nil
+}
+
+// ErrChainIDMismatch is returned when the RPC endpoint reports a different
+// chain than the one the agent is configured to sign for.
+var ErrChainIDMismatch = errors.New("zerog: chain ID mismatch")
+
+// ChainIDReader reports the chain ID of a connected RPC endpoint.
+type ChainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// VerifyChainID checks that the endpoint behind backend reports the expected
+// chain ID, so transactions are never signed for the wrong network.
+func VerifyChainID(ctx context.Context, backend ChainIDReader, expected int64) error {
+	got, err := backend.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("zerog: query chain ID: %w", err)
+	}
+	if got.Cmp(big.NewInt(expected)) != 0 {
+		return fmt.Errorf("%w: RPC reports %s, configured %d", ErrChainIDMismatch, got, expected)
+	}
+	return nil
+}
+
+// ErrNoContractCode is returned when a configured contract address has no
+// code deployed, usually a typo or an address from another network.
+var ErrNoContractCode = errors.New("zerog: no contract code at address")
+
+// ErrInvalidAddress is returned for a malformed contract address or a
+// mixed-case address whose EIP-55 checksum does not match.
+var ErrInvalidAddress = errors.New("zerog: invalid contract address")
+
+// CodeReader reads the code deployed at an address.
+type CodeReader interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// Contract names a configured contract address for VerifyContracts.
+type Contract struct {
+	Name    string
+	Address string
+}
+
+// VerifyContracts checks that each contract address is well formed, passes
+// its EIP-55 checksum when written in mixed case, and has code deployed,
+// so misconfigured addresses fail at startup instead of at first use.
+// Contracts with an empty address are skipped.
+func VerifyContracts(ctx context.Context, backend CodeReader, contracts []Contract) error {
+	for _, c := range contracts {
+		if c.Address == "" {
+			continue
+		}
+		if !common.IsHexAddress(c.Address) {
+			return fmt.Errorf("%w: %s %q", ErrInvalidAddress, c.Name, c.Address)
+		}
+		addr := common.HexToAddress(c.Address)
+		hexPart := strings.TrimPrefix(strings.TrimPrefix(c.Address, "0x"), "0X")
+		mixed := hexPart != strings.ToLower(hexPart) && hexPart != strings.ToUpper(hexPart)
+		if mixed && hexPart != addr.Hex()[2:] {
+			return fmt.Errorf("%w: %s %s fails EIP-55 checksum (expected %s)",
+				ErrInvalidAddress, c.Name, c.Address, addr.Hex())
+		}
+		code, err := backend.CodeAt(ctx, addr, nil)
+		if err != nil {
+			return fmt.Errorf("zerog: query code for %s %s: %w", c.Name, c.Address, err)
+		}
+		if len(code) == 0 {
+			return fmt.Errorf("%w: %s %s", ErrNoContractCode, c.Name, c.Address)
+		}
+	}
+	return nil
 }
 
 // LoadKey parses a hex-encoded ECDSA private key.
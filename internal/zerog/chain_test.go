@@ -0,0 +1,88 @@
+package zerog
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestVerifyChainID_Match(t *testing.T) {
+	backend := &zgtest.MockBackend{}
+	if err := VerifyChainID(context.Background(), backend, 16602); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyChainID_Mismatch(t *testing.T) {
+	backend := &zgtest.MockBackend{
+		ChainIDFn: func(_ context.Context) (*big.Int, error) {
+			return big.NewInt(1), nil
+		},
+	}
+	err := VerifyChainID(context.Background(), backend, 16602)
+	if !errors.Is(err, ErrChainIDMismatch) {
+		t.Fatalf("expected ErrChainIDMismatch, got %v", err)
+	}
+}
+
+func TestVerifyChainID_QueryError(t *testing.T) {
+	backend := &zgtest.MockBackend{Err: errors.New("rpc down")}
+	err := VerifyChainID(context.Background(), backend, 16602)
+	if err == nil || errors.Is(err, ErrChainIDMismatch) {
+		t.Fatalf("expected query error, got %v", err)
+	}
+}
+
+func TestVerifyContracts_OK(t *testing.T) {
+	backend := &zgtest.MockBackend{}
+	err := VerifyContracts(context.Background(), backend, []Contract{
+		{Name: "flow", Address: "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296"},
+		{Name: "lower", Address: "0x22e03a6a89b950f1c82ec5e74f8eca321a105296"},
+		{Name: "unset", Address: ""},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyContracts_NoCode(t *testing.T) {
+	empty := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	backend := &zgtest.MockBackend{
+		CodeAtFn: func(_ context.Context, addr common.Address) ([]byte, error) {
+			if addr == empty {
+				return nil, nil
+			}
+			return []byte{0x01}, nil
+		},
+	}
+	err := VerifyContracts(context.Background(), backend, []Contract{
+		{Name: "ok", Address: "0x0000000000000000000000000000000000000001"},
+		{Name: "ZG_DA_CONTRACT", Address: empty.Hex()},
+	})
+	if !errors.Is(err, ErrNoContractCode) {
+		t.Fatalf("expected ErrNoContractCode, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "ZG_DA_CONTRACT") || !strings.Contains(err.Error(), empty.Hex()) {
+		t.Errorf("error should name the contract and address: %v", err)
+	}
+}
+
+func TestVerifyContracts_InvalidAddress(t *testing.T) {
+	backend := &zgtest.MockBackend{}
+	for _, addr := range []string{
+		"0x1234",
+		"0x22E03a6A89B950F1c82ec5e74F8eCa321a105297", // mixed case, bad checksum
+		"0x22e03A6A89B950F1c82ec5e74F8eCa321a105296",
+	} {
+		err := VerifyContracts(context.Background(), backend, []Contract{{Name: "c", Address: addr}})
+		if !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("%s: expected ErrInvalidAddress, got %v", addr, err)
+		}
+	}
+}
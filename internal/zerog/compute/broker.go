@@ -9,12 +9,12 @@
 package compute
 
 import (
-	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"strings"
@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 
+	"github.com/lancekrogers/agent-inference/internal/clock"
 	"github.com/lancekrogers/agent-inference/internal/zerog"
 )
 
@@ -101,8 +102,10 @@ func mustParseABI(raw string) abi.ABI {
 	return parsed
 }
 
+// ModelCacheTTL is how long a model listing is served from cache.
+const ModelCacheTTL = 5 * time.Minute
+
 const (
-	modelCacheDuration = 5 * time.Minute
 	// servicesPageLimit is the maximum number of services the contract allows
 	// per getAllServices call. The contract reverts with limit > 50.
 	servicesPageLimit = 50
@@ -111,8 +114,31 @@ const (
 // ComputeBroker submits inference jobs to 0G decentralized GPU compute.
 type ComputeBroker interface {
 	SubmitJob(ctx context.Context, req JobRequest) (string, error)
+	// GetResult returns a submitted job's result. The broker keeps a result
+	// only until it is collected, and only for its most recent jobs.
 	GetResult(ctx context.Context, jobID string) (*JobResult, error)
 	ListModels(ctx context.Context) ([]Model, error)
+	// ListModelsWithTotal is ListModels plus the registry's total service
+	// count, so callers can tell whether the listing is complete.
+	ListModelsWithTotal(ctx context.Context) (ListModelsResult, error)
+	// CancelJob forgets a job and asks its provider to stop it if it has
+	// not answered yet. Providers without cancellation support are treated
+	// as a successful no-op. SubmitJob waits for the provider's answer;
+	// cancelling its context makes SubmitJob cancel the request itself.
+	CancelJob(ctx context.Context, jobID string) error
+	// EstimateCost projects the price of req at the provider it would be
+	// routed to, using the configured Tokenizer.
+	EstimateCost(ctx context.Context, req JobRequest) (CostEstimate, error)
+	// WarmCache fetches a fresh model listing into the cache, ignoring
+	// any cached one, so later lookups skip discovery latency.
+	WarmCache(ctx context.Context) error
+	// SubmitRaw posts body verbatim as a chat completion request to a
+	// provider serving modelID and returns the parsed result. No request
+	// checks or defaults apply; the caller owns the body's correctness.
+	SubmitRaw(ctx context.Context, modelID string, body json.RawMessage) (*JobResult, error)
+	// Close releases the broker's connections and caches. The broker must
+	// not be used afterwards.
+	Close() error
 }
 
 type broker struct {
@@ -123,11 +149,17 @@ type broker struct {
 	client   *http.Client
 	session  *sessionManager
 
-	mu        sync.RWMutex
-	models    []Model
-	modelsTTL time.Time
+	warmMu      sync.Mutex // serializes WarmCache refreshes
+	mu          sync.RWMutex
+	models      []Model
+	modelsTotal int
+	modelsTTL   time.Time
+
+	clock clock.Clock
 
-	results sync.Map // jobID → *JobResult
+	latency *latencyTracker
+
+	jobs *jobTable // submitted jobs awaiting GetResult or CancelJob
 }
 
 // NewBroker creates a new ComputeBroker.
@@ -139,13 +171,33 @@ func NewBroker(cfg BrokerConfig, backend zerog.ChainBackend, key *ecdsa.PrivateK
 	if cfg.PollTimeout == 0 {
 		cfg.PollTimeout = 5 * time.Minute
 	}
+	if cfg.MaxRetryAfter == 0 {
+		cfg.MaxRetryAfter = defaultMaxRetryAfter
+	}
+	if cfg.MaxInputBytes == 0 {
+		cfg.MaxInputBytes = DefaultMaxInputBytes
+	}
+	if cfg.ClockSkewTolerance == 0 {
+		cfg.ClockSkewTolerance = DefaultClockSkewTolerance
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if cfg.MaxListBytes <= 0 {
+		cfg.MaxListBytes = DefaultMaxListBytes
+	}
 
 	contractAddr := common.HexToAddress(cfg.ServingContractAddress)
 	bc := bind.NewBoundContract(contractAddr, servingABI, backend, backend, backend)
 
 	var sm *sessionManager
 	if key != nil {
-		sm = newSessionManager(key, backend, cfg.ChainID)
+		sm = newSessionManager(key, backend, cfg.ChainID, cfg.AuthTokenBuilder)
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second, Transport: cfg.Transport}
+	if cfg.Debug {
+		httpClient.Transport = newLoggingTransport(cfg.Transport, slog.Default())
 	}
 
 	return &broker{
@@ -153,31 +205,67 @@ func NewBroker(cfg BrokerConfig, backend zerog.ChainBackend, key *ecdsa.PrivateK
 		backend:  backend,
 		contract: bc,
 		key:      key,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		session: sm,
+		client:   httpClient,
+		session:  sm,
+		clock:    clock.OrReal(cfg.Clock),
+		latency:  newLatencyTracker(),
+		jobs:     newJobTable(maxTrackedJobs),
 	}
 }
 
 func (b *broker) SubmitJob(ctx context.Context, req JobRequest) (string, error) {
+	req.StatusCallback = newStatusReporter(req.StatusCallback)
+	req.notify(JobStatusPending)
+	jobID, err := b.submitJob(ctx, req)
+	if err != nil {
+		req.notify(JobStatusFailed)
+		return "", err
+	}
+	req.notify(JobStatusCompleted)
+	return jobID, nil
+}
+
+// submitJob validates req, resolves its provider, and submits it.
+func (b *broker) submitJob(ctx context.Context, req JobRequest) (string, error) {
 	if err := ctx.Err(); err != nil {
 		return "", fmt.Errorf("compute: context cancelled before submit: %w", err)
 	}
+	if err := validateJobRequest(req, b.cfg.MaxInputBytes); err != nil {
+		return "", err
+	}
+	req, err := b.fitContextWindow(b.applyModelDefaults(req))
+	if err != nil {
+		return "", err
+	}
 
-	// Discover provider URL and address for the requested model
-	provider, err := b.resolveProvider(ctx, req.ModelID)
+	// Discover provider URL and address for the requested model, unless
+	// the request pins one.
+	provider, err := b.providerFor(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("compute: resolve provider for %s: %w", req.ModelID, err)
 	}
 
+	jobID, err := b.submitTo(ctx, req, provider)
+	if err != nil && req.pinned() && errors.Is(err, ErrBrokerDown) {
+		// Pinned provider unreachable: fall back to discovery.
+		fallback, resolveErr := b.resolveProvider(ctx, req.ModelID)
+		if resolveErr != nil || fallback.URL == provider.URL {
+			return "", err
+		}
+		return b.submitTo(ctx, req, fallback)
+	}
+	return jobID, err
+}
+
+// submitTo sends req to provider and caches the result for GetResult.
+func (b *broker) submitTo(ctx context.Context, req JobRequest, provider providerInfo) (string, error) {
 	chatReq := chatRequest{
 		Model: req.ModelID,
 		Messages: []chatMessage{
 			{Role: "user", Content: req.Input},
 		},
 		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
+		Temperature: b.temperatureFor(req),
 	}
 
 	body, err := json.Marshal(chatReq)
@@ -185,105 +273,12 @@ func (b *broker) SubmitJob(ctx context.Context, req JobRequest) (string, error)
 		return "", fmt.Errorf("compute: marshal request: %w", err)
 	}
 
-	endpoint := provider.URL + "/v1/proxy/chat/completions"
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("compute: create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// Ensure on-chain session and get signed auth token.
-	if b.session != nil && provider.Address != "" {
-		token, tokenErr := b.session.EnsureSession(ctx, provider.Address)
-		if tokenErr != nil {
-			return "", fmt.Errorf("compute: ensure session: %w", tokenErr)
-		}
-		httpReq.Header.Set("Authorization", "Bearer "+token)
-	}
-
-	resp, err := b.doWithAuthRetry(ctx, httpReq, body)
+	req.notify(JobStatusRunning)
+	result, err := b.sendTracked(ctx, provider, body)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	const maxResponseBytes = 1 << 20 // 1 MB
-	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
-	if err != nil {
-		return "", fmt.Errorf("compute: read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("compute: provider returned status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	var chatResp chatResponse
-	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return "", fmt.Errorf("compute: parse response: %w", err)
-	}
-
-	if chatResp.Error != nil {
-		return "", fmt.Errorf("compute: API error: %s: %w", chatResp.Error.Message, ErrJobFailed)
-	}
-
-	// Cache the result for GetResult
-	output := ""
-	if len(chatResp.Choices) > 0 {
-		output = chatResp.Choices[0].Message.Content
-	}
-
-	result := &JobResult{
-		JobID:      chatResp.ID,
-		Status:     JobStatusCompleted,
-		Output:     output,
-		ModelID:    chatResp.Model,
-		TokensUsed: chatResp.Usage.TotalTokens,
-	}
-	b.results.Store(chatResp.ID, result)
-
-	return chatResp.ID, nil
-}
-
-// doWithAuthRetry executes the HTTP request. On 401, it invalidates the cached
-// session token and retries once with a fresh token.
-func (b *broker) doWithAuthRetry(ctx context.Context, req *http.Request, body []byte) (*http.Response, error) {
-	resp, err := b.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("compute: provider request failed: %w", ErrBrokerDown)
-	}
-
-	if resp.StatusCode != http.StatusUnauthorized || b.session == nil {
-		return resp, nil
-	}
-
-	// 401 — invalidate cached session and retry once.
-	resp.Body.Close()
-	b.session.invalidate()
-
-	// Re-extract provider from the URL (stored during session setup)
-	providerAddr := b.session.cachedProvider
-	if providerAddr == "" {
-		return nil, fmt.Errorf("compute: no provider address for auth retry")
-	}
-
-	token, tokenErr := b.session.EnsureSession(ctx, providerAddr)
-	if tokenErr != nil {
-		return nil, fmt.Errorf("compute: refresh session token: %w", tokenErr)
-	}
-
-	retryReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL.String(), bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("compute: create retry request: %w", err)
-	}
-	retryReq.Header.Set("Content-Type", "application/json")
-	retryReq.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err = b.client.Do(retryReq)
-	if err != nil {
-		return nil, fmt.Errorf("compute: retry request failed: %w", ErrBrokerDown)
-	}
-
-	return resp, nil
+	return result.JobID, nil
 }
 
 func (b *broker) GetResult(ctx context.Context, jobID string) (*JobResult, error) {
@@ -291,14 +286,15 @@ func (b *broker) GetResult(ctx context.Context, jobID string) (*JobResult, error
 		return nil, fmt.Errorf("compute: context cancelled: %w", err)
 	}
 
-	// Check cache first (populated by SubmitJob)
-	if val, ok := b.results.Load(jobID); ok {
-		return val.(*JobResult), nil
+	// Check cache first (populated by SubmitJob). A result is handed out
+	// once and then forgotten.
+	if result, ok := b.jobs.collect(jobID); ok {
+		return result, nil
 	}
 
 	// Poll for result (fallback for async providers)
-	deadline := time.After(b.cfg.PollTimeout)
-	ticker := time.NewTicker(b.cfg.PollInterval)
+	deadline := b.clock.After(b.cfg.PollTimeout)
+	ticker := b.clock.NewTicker(b.cfg.PollInterval)
 	defer ticker.Stop()
 
 	for {
@@ -307,150 +303,65 @@ func (b *broker) GetResult(ctx context.Context, jobID string) (*JobResult, error
 			return nil, fmt.Errorf("compute: context cancelled polling job %s: %w", jobID, ctx.Err())
 		case <-deadline:
 			return nil, fmt.Errorf("compute: timeout waiting for job %s after %v", jobID, b.cfg.PollTimeout)
-		case <-ticker.C:
-			if val, ok := b.results.Load(jobID); ok {
-				return val.(*JobResult), nil
+		case <-ticker.C():
+			if result, ok := b.jobs.collect(jobID); ok {
+				return result, nil
 			}
 		}
 	}
 }
 
 func (b *broker) ListModels(ctx context.Context) ([]Model, error) {
-	if err := ctx.Err(); err != nil {
-		return nil, fmt.Errorf("compute: context cancelled: %w", err)
-	}
-
-	if models := b.cachedModels(); models != nil {
-		return models, nil
-	}
-
-	models, err := b.listFromChain(ctx)
+	res, err := b.ListModelsWithTotal(ctx)
 	if err != nil {
-		// Fall back to HTTP endpoint if chain query fails and endpoint is set
-		if b.cfg.Endpoint != "" {
-			return b.listFromHTTP(ctx)
-		}
-		return nil, fmt.Errorf("compute: list models from chain: %w", err)
+		return nil, err
 	}
-
-	if len(models) == 0 {
-		return nil, ErrNoModels
-	}
-
-	b.cacheModels(models)
-	return models, nil
-}
-
-func (b *broker) listFromChain(ctx context.Context) ([]Model, error) {
-	var result []interface{}
-	err := b.contract.Call(&bind.CallOpts{Context: ctx}, &result, "getAllServices", big.NewInt(0), big.NewInt(servicesPageLimit))
-	if err != nil {
-		return nil, fmt.Errorf("getAllServices: %w", err)
-	}
-
-	if len(result) < 2 {
-		return nil, nil
-	}
-
-	// result[0] is the services array, result[1] is the total count.
-	// Struct field order must match the contract's Service struct exactly.
-	services, ok := result[0].([]struct {
-		Provider      common.Address `json:"provider"`
-		Name          string         `json:"name"`
-		Url           string         `json:"url"`
-		InputPrice    *big.Int       `json:"inputPrice"`
-		OutputPrice   *big.Int       `json:"outputPrice"`
-		UpdatedAt     *big.Int       `json:"updatedAt"`
-		Model         string         `json:"model"`
-		Verifiability string         `json:"verifiability"`
-		Content       string         `json:"content"`
-		Signer        common.Address `json:"signer"`
-		Occupied      bool           `json:"occupied"`
-	})
-	if !ok {
-		return nil, fmt.Errorf("unexpected services type: %T", result[0])
-	}
-
-	models := make([]Model, 0, len(services))
-	for _, svc := range services {
-		models = append(models, Model{
-			ID:       svc.Model,
-			Name:     svc.Name,
-			Provider: svc.Provider.Hex(),
-			URL:      svc.Url,
-		})
-	}
-
-	return models, nil
+	return res.Models, nil
 }
 
-func (b *broker) listFromHTTP(ctx context.Context) ([]Model, error) {
-	endpoint := b.cfg.Endpoint + "/api/services/list"
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+func (b *broker) ListModelsWithTotal(ctx context.Context) (ListModelsResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ListModelsResult{}, fmt.Errorf("compute: context cancelled: %w", err)
 	}
 
-	resp, err := b.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("list services: %w", ErrBrokerDown)
+	if res, ok := b.cachedListing(); ok {
+		return res, nil
 	}
-	defer resp.Body.Close()
 
-	const maxListBytes = 64 * 1024 // 64 KB
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxListBytes))
+	models, total, err := b.list(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("list returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	type serviceEntry struct {
-		Provider    string `json:"providerAddress"`
-		Name        string `json:"name"`
-		ServiceType string `json:"serviceType"`
-		URL         string `json:"url"`
-		Model       string `json:"model"`
-	}
-
-	var services []serviceEntry
-	if err := json.Unmarshal(body, &services); err != nil {
-		return nil, fmt.Errorf("parse services: %w", err)
-	}
-
-	if len(services) == 0 {
-		return nil, ErrNoModels
-	}
-
-	models := make([]Model, len(services))
-	for i, svc := range services {
-		models[i] = Model{
-			ID:          svc.Model,
-			Name:        svc.Name,
-			Provider:    svc.Provider,
-			ServiceType: svc.ServiceType,
-			URL:         svc.URL,
+		if res, ok := b.staleListing(err); ok {
+			return res, nil
 		}
+		return ListModelsResult{}, err
 	}
 
-	b.cacheModels(models)
-	return models, nil
+	return b.cacheListing(models, total), nil
 }
 
-// providerInfo holds the resolved URL and on-chain address of a provider.
+// providerInfo holds the resolved URL, on-chain address, and published
+// per-token prices of a provider.
 type providerInfo struct {
-	URL     string
-	Address string
+	URL           string
+	Address       string
+	InputPrice    *big.Int
+	OutputPrice   *big.Int
+	Verifiability string
+}
+
+// id returns the provider's address, or its URL if the address is unknown.
+func (p providerInfo) id() string {
+	if p.Address != "" {
+		return p.Address
+	}
+	return p.URL
 }
 
 func (b *broker) resolveProvider(ctx context.Context, modelID string) (providerInfo, error) {
 	// Try cache first
 	if models := b.cachedModels(); models != nil {
-		for _, m := range models {
-			if m.ID == modelID && m.URL != "" {
-				return providerInfo{URL: m.URL, Address: m.Provider}, nil
-			}
+		if candidates := b.verifiable(providersFor(models, modelID)); len(candidates) > 0 {
+			return b.selectProvider(candidates), nil
 		}
 	}
 
@@ -458,16 +369,19 @@ func (b *broker) resolveProvider(ctx context.Context, modelID string) (providerI
 	models, err := b.ListModels(ctx)
 	if err != nil {
 		// Last resort: use fallback endpoint
-		if b.cfg.Endpoint != "" {
+		if b.cfg.Endpoint != "" && b.cfg.RequireVerifiability == "" {
 			return providerInfo{URL: b.cfg.Endpoint}, nil
 		}
 		return providerInfo{}, fmt.Errorf("no provider for model %s: %w", modelID, err)
 	}
 
-	for _, m := range models {
-		if m.ID == modelID && m.URL != "" {
-			return providerInfo{URL: m.URL, Address: m.Provider}, nil
-		}
+	all := providersFor(models, modelID)
+	if candidates := b.verifiable(all); len(candidates) > 0 {
+		return b.selectProvider(candidates), nil
+	}
+	if b.cfg.RequireVerifiability != "" {
+		return providerInfo{}, fmt.Errorf("no %s provider for model %s (%d listed): %w",
+			b.cfg.RequireVerifiability, modelID, len(all), ErrUnverifiedProvider)
 	}
 
 	// If model not found but we have a fallback endpoint, use it
@@ -478,10 +392,53 @@ func (b *broker) resolveProvider(ctx context.Context, modelID string) (providerI
 	return providerInfo{}, fmt.Errorf("no provider for model %s: %w", modelID, ErrNoModels)
 }
 
+// providersFor returns the providers serving modelID, in listing order.
+func providersFor(models []Model, modelID string) []providerInfo {
+	var out []providerInfo
+	for _, m := range models {
+		if m.ID == modelID && m.URL != "" {
+			out = append(out, providerInfo{
+				URL:           m.URL,
+				Address:       m.Provider,
+				InputPrice:    m.InputPrice,
+				OutputPrice:   m.OutputPrice,
+				Verifiability: m.Verifiability,
+			})
+		}
+	}
+	return out
+}
+
+// selectProvider applies cfg.ProviderSelection to a non-empty candidate list.
+func (b *broker) selectProvider(candidates []providerInfo) providerInfo {
+	if b.cfg.ProviderSelection == ProviderSelectionFastest {
+		return candidates[b.latency.fastest(candidates)]
+	}
+	return candidates[0]
+}
+
+// recordLatency updates the provider's moving average and notifies the
+// observer.
+func (b *broker) recordLatency(url string, sample time.Duration) {
+	avg := b.latency.record(url, sample)
+	if b.cfg.Observer != nil {
+		b.cfg.Observer.ObserveProviderLatency(url, sample, avg)
+	}
+}
+
+func (b *broker) cachedListing() (ListModelsResult, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.models != nil && b.clock.Now().Before(b.modelsTTL) {
+		return newListModelsResult(append([]Model(nil), b.models...), b.modelsTotal), true
+	}
+	return ListModelsResult{}, false
+}
+
 func (b *broker) cachedModels() []Model {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	if b.models != nil && time.Now().Before(b.modelsTTL) {
+	if b.models != nil && b.clock.Now().Before(b.modelsTTL) {
 		dst := make([]Model, len(b.models))
 		copy(dst, b.models)
 		return dst
@@ -490,9 +447,26 @@ func (b *broker) cachedModels() []Model {
 }
 
 func (b *broker) cacheModels(models []Model) {
+	b.cacheListing(models, len(models))
+}
+
+// cacheListing caches models with the registry total and returns the
+// listing.
+func (b *broker) cacheListing(models []Model, total int) ListModelsResult {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.models = models
-	b.modelsTTL = time.Now().Add(modelCacheDuration)
+	b.modelsTotal = total
+	b.modelsTTL = b.clock.Now().Add(ModelCacheTTL)
+	return newListModelsResult(append([]Model(nil), models...), total)
 }
 
+// Close drops idle provider connections and cached listings and results.
+func (b *broker) Close() error {
+	b.client.CloseIdleConnections()
+	b.mu.Lock()
+	b.models, b.modelsTotal, b.modelsTTL = nil, 0, time.Time{}
+	b.mu.Unlock()
+	b.jobs.clear()
+	return nil
+}
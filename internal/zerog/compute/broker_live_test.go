@@ -131,11 +131,12 @@ func TestLive_SubmitJob_ToProvider(t *testing.T) {
 	}
 
 	t.Logf("Submitting inference to %s at %s", target.ID, target.URL)
+	temperature := 0.0
 	jobID, err := b.SubmitJob(ctx, JobRequest{
 		ModelID:     target.ID,
 		Input:       "What is 2+2? Answer with just the number.",
 		MaxTokens:   32,
-		Temperature: 0.0,
+		Temperature: &temperature,
 	})
 	if err != nil {
 		// 0G providers require session-based auth (Bearer app-sk-<base64(rawMessage:signature)>).
@@ -2,7 +2,10 @@ package compute
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +18,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/lancekrogers/agent-inference/internal/clock"
 	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
 )
 
@@ -34,10 +38,11 @@ func newTestBroker(t *testing.T, backend *zgtest.MockBackend, httpEndpoint strin
 }
 
 type serviceTestData struct {
-	Provider common.Address
-	Name     string
-	URL      string
-	Model    string
+	Provider      common.Address
+	Name          string
+	URL           string
+	Model         string
+	Verifiability string // "none" when empty
 }
 
 // encodedAllServices returns ABI-encoded outputs for getAllServices.
@@ -74,6 +79,10 @@ func encodedAllServices(services []serviceTestData, total int) []byte {
 
 	svcs := make([]svcStruct, len(services))
 	for i, s := range services {
+		verifiability := s.Verifiability
+		if verifiability == "" {
+			verifiability = "none"
+		}
 		svcs[i] = svcStruct{
 			Provider:      s.Provider,
 			Name:          s.Name,
@@ -82,7 +91,7 @@ func encodedAllServices(services []serviceTestData, total int) []byte {
 			OutputPrice:   big.NewInt(0),
 			UpdatedAt:     big.NewInt(0),
 			Model:         s.Model,
-			Verifiability: "none",
+			Verifiability: verifiability,
 			Content:       "",
 			Signer:        common.Address{},
 			Occupied:      true,
@@ -149,6 +158,13 @@ func TestSubmitJob_Success(t *testing.T) {
 	if jobID != "job-123" {
 		t.Errorf("expected job-123, got %s", jobID)
 	}
+	result, err := b.GetResult(context.Background(), jobID)
+	if err != nil {
+		t.Fatalf("GetResult: %v", err)
+	}
+	if result.Provider != "0xabc" {
+		t.Errorf("expected provider 0xabc, got %q", result.Provider)
+	}
 }
 
 func TestSubmitJob_APIError(t *testing.T) {
@@ -183,8 +199,31 @@ func TestSubmitJob_APIError(t *testing.T) {
 		ModelID: "bad-model",
 		Input:   "hello",
 	})
-	if err == nil {
-		t.Fatal("expected error for API error response")
+	if !errors.Is(err, ErrJobFailed) {
+		t.Fatalf("expected ErrJobFailed for API error response, got %v", err)
+	}
+}
+
+func TestSubmitJob_EmptyChoices(t *testing.T) {
+	for name, body := range map[string]string{
+		"empty":   `{"id":"job-1","choices":[],"model":"test-model"}`,
+		"missing": `{"id":"job-1","model":"test-model"}`,
+	} {
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/services/list" {
+				fmt.Fprintf(w, `[{"providerAddress":"0xabc","url":%q,"model":"test-model"}]`, srv.URL)
+				return
+			}
+			w.Write([]byte(body))
+		}))
+
+		b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL)
+		_, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "test-model", Input: "hello"})
+		if !errors.Is(err, ErrEmptyResponse) {
+			t.Errorf("%s choices: expected ErrEmptyResponse, got %v", name, err)
+		}
+		srv.Close()
 	}
 }
 
@@ -240,6 +279,34 @@ func TestGetResult_Completed(t *testing.T) {
 	if result.TokensUsed != 25 {
 		t.Errorf("expected 25 tokens, got %d", result.TokensUsed)
 	}
+	if n := b.(*broker).jobs.len(); n != 0 {
+		t.Errorf("collected result still tracked: %d jobs", n)
+	}
+}
+
+func TestGetResult_FinishReason(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"id":"job-789","model":"test-model","usage":{"total_tokens":64},` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"cut"},"finish_reason":"length"}]}`))
+	}))
+	defer srv.Close()
+
+	b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL)
+	jobID, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "test-model", Input: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := b.GetResult(context.Background(), jobID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.FinishReason != FinishReasonLength {
+		t.Errorf("expected finish_reason %q, got %q", FinishReasonLength, result.FinishReason)
+	}
+	if !result.Truncated() {
+		t.Error("expected result to be reported as truncated")
+	}
 }
 
 func TestGetResult_ContextCancelled(t *testing.T) {
@@ -261,15 +328,25 @@ func TestGetResult_ContextCancelled(t *testing.T) {
 func TestGetResult_Timeout(t *testing.T) {
 	backend := &zgtest.MockBackend{}
 	key, _ := crypto.GenerateKey()
+	clk := clock.NewFake(time.Now())
 	b := NewBroker(BrokerConfig{
 		ChainID:                16602,
 		ServingContractAddress: "0x0000000000000000000000000000000000000001",
-		PollInterval:           10 * time.Millisecond,
-		PollTimeout:            50 * time.Millisecond,
+		PollInterval:           10 * time.Second,
+		PollTimeout:            time.Minute,
+		Clock:                  clk,
 	}, backend, key)
 
-	_, err := b.GetResult(context.Background(), "job-timeout")
-	if err == nil {
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := b.GetResult(context.Background(), "job-timeout")
+		errCh <- err
+	}()
+
+	clk.BlockUntil(2) // deadline timer + poll ticker
+	clk.Advance(time.Minute)
+
+	if err := <-errCh; err == nil {
 		t.Fatal("expected timeout error")
 	}
 }
@@ -307,6 +384,31 @@ func TestListModels_FromChain(t *testing.T) {
 	}
 }
 
+func TestListModelsWithTotal_Truncated(t *testing.T) {
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, call ethereum.CallMsg) ([]byte, error) {
+			return encodedAllServices([]serviceTestData{
+				{Provider: common.HexToAddress("0xabc"), Name: "Qwen 2.5", URL: "https://p1.example.com", Model: "qwen-2.5-7b"},
+			}, 5), nil
+		},
+	}
+	key, _ := crypto.GenerateKey()
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+	}, backend, key)
+
+	for i := 0; i < 2; i++ { // second call is served from cache
+		res, err := b.ListModelsWithTotal(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(res.Models) != 1 || res.Total != 5 || !res.Truncated {
+			t.Errorf("call %d: unexpected result: %+v", i, res)
+		}
+	}
+}
+
 func TestListModels_FallbackHTTP(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		type serviceEntry struct {
@@ -407,6 +509,37 @@ func TestListModels_Cached(t *testing.T) {
 	}
 }
 
+func TestClose_DropsCache(t *testing.T) {
+	callCount := 0
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			callCount++
+			return encodedAllServices([]serviceTestData{
+				{Provider: common.HexToAddress("0xabc"), Name: "Model1", URL: "https://p.example.com", Model: "m1"},
+			}, 1), nil
+		},
+	}
+
+	key, _ := crypto.GenerateKey()
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+	}, backend, key)
+
+	if _, err := b.ListModels(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if b.(*broker).cachedModels() != nil {
+		t.Error("expected model cache to be cleared after Close")
+	}
+	if callCount == 0 {
+		t.Error("expected a chain call before Close")
+	}
+}
+
 func TestSubmitJob_AuthHeader(t *testing.T) {
 	var gotAuth string
 	var srv *httptest.Server
@@ -513,3 +646,47 @@ func TestListModels_ContextCancelled(t *testing.T) {
 		t.Fatal("expected error for cancelled context")
 	}
 }
+
+func TestSubmitJob_CustomAuthTokenBuilder(t *testing.T) {
+	var authHeaders []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/proxy/chat/completions":
+			authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+			if len(authHeaders) == 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(chatResponse{
+				ID:      "job-custom",
+				Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "ok"}}},
+			})
+		case "/api/services/list":
+			json.NewEncoder(w).Encode([]map[string]string{
+				{"providerAddress": "0xabc", "name": "Test", "url": srv.URL, "model": "test-model"},
+			})
+		}
+	}))
+	defer srv.Close()
+
+	key, _ := crypto.GenerateKey()
+	built := 0
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               srv.URL,
+		AuthTokenBuilder: func(_ *ecdsa.PrivateKey, provider string) (string, error) {
+			built++
+			return fmt.Sprintf("v2-%s-%d", provider, built), nil
+		},
+	}, &zgtest.MockBackend{}, key)
+
+	if _, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "test-model", Input: "hi"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"Bearer v2-0xabc-1", "Bearer v2-0xabc-2"}
+	if len(authHeaders) != 2 || authHeaders[0] != want[0] || authHeaders[1] != want[1] {
+		t.Errorf("expected custom tokens %v (initial + 401 refresh), got %v", want, authHeaders)
+	}
+}
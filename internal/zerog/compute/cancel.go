@@ -0,0 +1,100 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// requestIDHeader carries the client-generated ID of a chat request, so
+// the request can be cancelled before the provider has answered it.
+const requestIDHeader = "X-Request-ID"
+
+// abandonTimeout bounds the DELETE sent for a request whose caller gave
+// up, since the caller's context is already done.
+const abandonTimeout = 10 * time.Second
+
+// sendTracked posts body to provider under a fresh request ID, tracking the
+// job while the request is in flight. If ctx ends before the provider
+// answers, it cancels the request with the provider. The result is tracked
+// under its JobID for GetResult.
+func (b *broker) sendTracked(ctx context.Context, provider providerInfo, body []byte) (*JobResult, error) {
+	requestID, err := generateNonce()
+	if err != nil {
+		return nil, fmt.Errorf("compute: generate request ID: %w", err)
+	}
+	b.jobs.track(trackedJob{id: requestID, providerURL: provider.URL})
+
+	result, err := b.postChat(ctx, provider, body, requestID)
+	if err != nil {
+		if ctx.Err() != nil {
+			b.abandon(ctx, requestID)
+		} else {
+			b.jobs.take(requestID)
+		}
+		return nil, err
+	}
+	b.jobs.take(requestID)
+	b.jobs.track(trackedJob{id: result.JobID, providerURL: provider.URL, result: result})
+	return result, nil
+}
+
+// abandon cancels an in-flight request whose context ended, on a context
+// that outlives ctx. Failures are dropped: the caller has already given up.
+func (b *broker) abandon(ctx context.Context, requestID string) {
+	cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), abandonTimeout)
+	defer cancel()
+	if err := b.CancelJob(cancelCtx, requestID); err != nil {
+		b.jobs.take(requestID)
+	}
+}
+
+// CancelJob forgets the job and, unless its provider already answered,
+// sends DELETE /v1/proxy/jobs/{id} to that provider. Completed jobs, unknown
+// jobs, and providers that answer 404, 405, or 501 are treated as having
+// nothing to cancel. A failed DELETE leaves the job tracked so it can be
+// retried.
+func (b *broker) CancelJob(ctx context.Context, jobID string) error {
+	job, ok := b.jobs.take(jobID)
+	if !ok || (job.result != nil && job.result.Status == JobStatusCompleted) {
+		return nil
+	}
+	if err := b.requestCancel(ctx, job); err != nil {
+		b.jobs.track(job)
+		return err
+	}
+	return nil
+}
+
+// requestCancel sends the DELETE for job to its provider.
+func (b *broker) requestCancel(ctx context.Context, job trackedJob) error {
+	jobID := job.id
+	endpoint := job.providerURL + "/v1/proxy/jobs/" + url.PathEscape(jobID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("compute: create cancel request: %w", err)
+	}
+	if b.session != nil {
+		if token := b.session.currentToken(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("compute: cancel job %s: %w", jobID, ErrBrokerDown)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent,
+		http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil
+	default:
+		return classifyProviderError(resp.StatusCode, body)
+	}
+}
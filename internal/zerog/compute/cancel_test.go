@@ -0,0 +1,149 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+// hangingServer lists itself as serving test-model and holds every chat
+// request open until the client gives up. It reports each chat request's
+// X-Request-ID on started and each DELETE path on deleted, answering
+// DELETEs with deleteStatus.
+func hangingServer(t *testing.T, deleteStatus int) (srv *httptest.Server, started, deleted chan string) {
+	t.Helper()
+	started, deleted = make(chan string, 4), make(chan string, 4)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/proxy/chat/completions":
+			// Drain the body so the server notices the client hanging up.
+			io.Copy(io.Discard, r.Body)
+			started <- r.Header.Get(requestIDHeader)
+			<-r.Context().Done()
+		case r.Method == http.MethodDelete:
+			deleted <- r.URL.Path
+			w.WriteHeader(deleteStatus)
+		case r.URL.Path == "/api/services/list":
+			json.NewEncoder(w).Encode([]map[string]string{{
+				"providerAddress": "0xprovider", "url": srv.URL, "model": "test-model",
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, started, deleted
+}
+
+// submitAndCancel runs SubmitJob on b and cancels its context once the
+// provider has the request, returning the request's ID.
+func submitAndCancel(t *testing.T, b ComputeBroker, started chan string) string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	id := make(chan string, 1)
+	go func() {
+		id <- <-started
+		cancel()
+	}()
+	if _, err := b.SubmitJob(ctx, JobRequest{ModelID: "test-model", Input: "hi"}); err == nil {
+		t.Fatal("expected error from cancelled SubmitJob")
+	}
+	return <-id
+}
+
+func TestSubmitJob_CancelledContextCancelsRequest(t *testing.T) {
+	srv, started, deleted := hangingServer(t, http.StatusNoContent)
+	b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL).(*broker)
+
+	id := submitAndCancel(t, b, started)
+	if id == "" {
+		t.Fatal("chat request carried no request ID")
+	}
+	select {
+	case path := <-deleted:
+		if path != "/v1/proxy/jobs/"+id {
+			t.Errorf("DELETE %s, want /v1/proxy/jobs/%s", path, id)
+		}
+	default:
+		t.Fatal("cancelled request was not cancelled with the provider")
+	}
+	if b.jobs.len() != 0 {
+		t.Error("cancelled request is still tracked")
+	}
+}
+
+func TestSubmitJob_CancelUnsupportedIsNoop(t *testing.T) {
+	for _, status := range []int{http.StatusMethodNotAllowed, http.StatusInternalServerError} {
+		srv, started, deleted := hangingServer(t, status)
+		b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL).(*broker)
+
+		submitAndCancel(t, b, started)
+
+		if len(deleted) != 1 {
+			t.Errorf("status %d: expected one DELETE, got %d", status, len(deleted))
+		}
+		if b.jobs.len() != 0 {
+			t.Errorf("status %d: abandoned request is still tracked", status)
+		}
+	}
+}
+
+func TestCancelJob_ServerErrorKeepsJob(t *testing.T) {
+	srv, _, _ := hangingServer(t, http.StatusInternalServerError)
+	b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL).(*broker)
+	b.jobs.track(trackedJob{id: "job-1", providerURL: srv.URL})
+
+	if err := b.CancelJob(context.Background(), "job-1"); !errors.Is(err, ErrProviderError) {
+		t.Fatalf("expected ErrProviderError, got %v", err)
+	}
+	if b.jobs.len() != 1 {
+		t.Error("failed cancel should keep the job tracked for a retry")
+	}
+}
+
+func TestCancelJob_CompletedJobIsNoop(t *testing.T) {
+	srv := chatServer(t, "job-1")
+	b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL).(*broker)
+
+	jobID, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "test-model", Input: "hi"})
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	if b.jobs.len() != 1 {
+		t.Fatalf("expected only the result tracked, got %d jobs", b.jobs.len())
+	}
+	// chatServer answers 404 to a DELETE, so a sent DELETE would also pass;
+	// the point is that the job is forgotten.
+	if err := b.CancelJob(context.Background(), jobID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.jobs.len() != 0 {
+		t.Error("cancelled job is still tracked")
+	}
+	if err := b.CancelJob(context.Background(), "unknown-job"); err != nil {
+		t.Fatalf("expected no-op for unknown job, got %v", err)
+	}
+}
+
+func TestJobTable_ForgetsOldestWhenFull(t *testing.T) {
+	jobs := newJobTable(2)
+	for _, id := range []string{"a", "b", "c"} {
+		jobs.track(trackedJob{id: id, result: &JobResult{JobID: id}})
+	}
+	if _, ok := jobs.collect("a"); ok {
+		t.Error("oldest job should have been forgotten")
+	}
+	if _, ok := jobs.collect("c"); !ok {
+		t.Error("newest job should still be tracked")
+	}
+	if _, ok := jobs.collect("c"); ok {
+		t.Error("collected job should be forgotten")
+	}
+}
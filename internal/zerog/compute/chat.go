@@ -0,0 +1,117 @@
+package compute
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// postChat sends a chat completion body to provider with session auth,
+// tagged with requestID, and parses the OpenAI-compatible response.
+func (b *broker) postChat(ctx context.Context, provider providerInfo, body []byte, requestID string) (*JobResult, error) {
+	endpoint := provider.URL + "/v1/proxy/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("compute: create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(requestIDHeader, requestID)
+
+	// Ensure on-chain session and get signed auth token.
+	if b.session != nil && provider.Address != "" {
+		token, tokenErr := b.session.EnsureSession(ctx, provider.Address)
+		if tokenErr != nil {
+			return nil, fmt.Errorf("compute: ensure session: %w", tokenErr)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	start := b.clock.Now()
+	resp, err := b.doWithRateLimitRetry(ctx, httpReq, body, provider.Address)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, readErr := readCapped(resp.Body, b.cfg.MaxResponseBytes)
+	if readErr != nil && !errors.Is(readErr, ErrResponseTooLarge) {
+		return nil, fmt.Errorf("compute: read response: %w", readErr)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, classifyProviderError(resp.StatusCode, respBody)
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("compute: chat completion from %s: %w", provider.URL, readErr)
+	}
+	b.recordLatency(provider.URL, b.clock.Now().Sub(start))
+
+	var chatResp chatResponse
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		return nil, fmt.Errorf("compute: parse response: %w", err)
+	}
+
+	if chatResp.Error != nil {
+		return nil, fmt.Errorf("compute: API error: %s: %w", chatResp.Error.Message, ErrJobFailed)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("compute: provider %s returned no choices: %w", provider.URL, ErrEmptyResponse)
+	}
+
+	return &JobResult{
+		JobID:         chatResp.ID,
+		Status:        JobStatusCompleted,
+		Output:        chatResp.Choices[0].Message.Content,
+		ModelID:       chatResp.Model,
+		TokensUsed:    chatResp.Usage.TotalTokens,
+		FinishReason:  chatResp.Choices[0].FinishReason,
+		Provider:      provider.id(),
+		Verifiability: provider.Verifiability,
+	}, nil
+}
+
+// doWithAuthRetry executes the HTTP request. On 401, it invalidates the cached
+// session token and retries once with a fresh token for providerAddr.
+func (b *broker) doWithAuthRetry(ctx context.Context, req *http.Request, body []byte, providerAddr string) (*http.Response, error) {
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("compute: provider request failed: %w", ErrBrokerDown)
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized || b.session == nil {
+		return resp, nil
+	}
+
+	// 401 — invalidate cached session and retry once, first resyncing the
+	// token clock if the provider rejected the timestamp.
+	b.syncClockOnRejection(resp, providerAddr)
+	resp.Body.Close()
+	b.session.invalidate()
+
+	if providerAddr == "" {
+		return nil, fmt.Errorf("compute: no provider address for auth retry")
+	}
+
+	token, tokenErr := b.session.EnsureSession(ctx, providerAddr)
+	if tokenErr != nil {
+		return nil, fmt.Errorf("compute: refresh session token: %w", tokenErr)
+	}
+
+	retryReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("compute: create retry request: %w", err)
+	}
+	retryReq.Header = req.Header.Clone()
+	retryReq.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err = b.client.Do(retryReq)
+	if err != nil {
+		return nil, fmt.Errorf("compute: retry request failed: %w", ErrBrokerDown)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,60 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// CostEstimate is the projected price of a job at the provider the broker
+// would route it to.
+type CostEstimate struct {
+	// Provider is the provider URL the estimate applies to.
+	Provider string `json:"provider"`
+	// InputTokens is the tokenizer's count for the request input.
+	InputTokens int `json:"input_tokens"`
+	// MaxOutputTokens is the request's MaxTokens; zero when unbounded.
+	MaxOutputTokens int `json:"max_output_tokens"`
+	// Cost is the worst-case price in neuron (input tokens at the input
+	// price plus MaxOutputTokens at the output price). Nil when the
+	// provider does not publish prices.
+	Cost *big.Int `json:"cost,omitempty"`
+}
+
+func (b *broker) EstimateCost(ctx context.Context, req JobRequest) (CostEstimate, error) {
+	if err := validateJobRequest(req, b.cfg.MaxInputBytes); err != nil {
+		return CostEstimate{}, err
+	}
+	req, err := b.fitContextWindow(req)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	inputTokens, err := b.tokenizer().CountTokens(req.ModelID, req.Input)
+	if err != nil {
+		return CostEstimate{}, fmt.Errorf("compute: count input tokens: %w", err)
+	}
+	provider, err := b.providerFor(ctx, req)
+	if err != nil {
+		return CostEstimate{}, fmt.Errorf("compute: resolve provider for %s: %w", req.ModelID, err)
+	}
+
+	est := CostEstimate{
+		Provider:        provider.URL,
+		InputTokens:     inputTokens,
+		MaxOutputTokens: req.MaxTokens,
+	}
+	if provider.InputPrice != nil && provider.OutputPrice != nil {
+		in := new(big.Int).Mul(provider.InputPrice, big.NewInt(int64(inputTokens)))
+		out := new(big.Int).Mul(provider.OutputPrice, big.NewInt(int64(req.MaxTokens)))
+		est.Cost = in.Add(in, out)
+	}
+	return est, nil
+}
+
+// tokenizer returns the configured Tokenizer or ApproxTokenizer.
+func (b *broker) tokenizer() Tokenizer {
+	if b.cfg.Tokenizer != nil {
+		return b.cfg.Tokenizer
+	}
+	return ApproxTokenizer{}
+}
@@ -0,0 +1,130 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+type fixedTokenizer int
+
+func (f fixedTokenizer) CountTokens(_, _ string) (int, error) { return int(f), nil }
+
+func TestApproxTokenizer(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"héllo wörld", 3}, // 11 runes, not 13 bytes
+	}
+	for _, tt := range tests {
+		got, err := ApproxTokenizer{}.CountTokens("any", tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	b := NewBroker(BrokerConfig{
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Tokenizer:              fixedTokenizer(10),
+	}, &zgtest.MockBackend{}, nil).(*broker)
+	b.cacheModels([]Model{
+		{ID: "priced", URL: "http://a", InputPrice: big.NewInt(3), OutputPrice: big.NewInt(5)},
+		{ID: "free", URL: "http://b"},
+	})
+
+	est, err := b.EstimateCost(context.Background(), JobRequest{ModelID: "priced", Input: "hello", MaxTokens: 20})
+	if err != nil {
+		t.Fatalf("EstimateCost: %v", err)
+	}
+	// 10*3 + 20*5
+	if est.Cost == nil || est.Cost.Int64() != 130 {
+		t.Errorf("Cost = %v, want 130", est.Cost)
+	}
+	if est.InputTokens != 10 || est.MaxOutputTokens != 20 || est.Provider != "http://a" {
+		t.Errorf("unexpected estimate: %+v", est)
+	}
+
+	est, err = b.EstimateCost(context.Background(), JobRequest{ModelID: "free", Input: "hello"})
+	if err != nil {
+		t.Fatalf("EstimateCost: %v", err)
+	}
+	if est.Cost != nil {
+		t.Errorf("expected nil Cost without published prices, got %v", est.Cost)
+	}
+}
+
+func TestFitContextWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		clamp     bool
+		maxTokens int
+		wantMax   int
+		wantErr   error
+	}{
+		{"fits", false, 50, 50, nil},
+		{"unbounded output", false, 0, 0, nil},
+		{"overflow", false, 200, 0, ErrContextOverflow},
+		{"clamped", true, 200, 90, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBroker(BrokerConfig{
+				Tokenizer:       fixedTokenizer(10),
+				ContextWindows:  map[string]int{"m": 100},
+				AutoClampTokens: tt.clamp,
+			}, &zgtest.MockBackend{}, nil).(*broker)
+
+			got, err := b.fitContextWindow(JobRequest{ModelID: "m", Input: "x", MaxTokens: tt.maxTokens})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.MaxTokens != tt.wantMax {
+				t.Errorf("MaxTokens = %d, want %d", got.MaxTokens, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestSubmitJob_ContextOverflowBeforeHTTP(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	b := NewBroker(BrokerConfig{
+		Endpoint:       srv.URL,
+		ContextWindows: map[string]int{"m": 4},
+	}, &zgtest.MockBackend{}, nil)
+
+	// 20 characters is 5 approximate tokens, which fills the window.
+	_, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "m", Input: strings.Repeat("a", 20)})
+	if !errors.Is(err, ErrContextOverflow) {
+		t.Fatalf("expected ErrContextOverflow, got %v", err)
+	}
+	if called {
+		t.Error("expected no provider request")
+	}
+}
@@ -0,0 +1,105 @@
+package compute
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// debugBodyLimit caps how much of each request/response body is logged.
+const debugBodyLimit = 4 * 1024
+
+// loggingTransport logs provider HTTP exchanges at debug level. Bodies are
+// captured without consuming them and truncated to debugBodyLimit; the
+// Authorization header is redacted.
+type loggingTransport struct {
+	base http.RoundTripper
+	log  *slog.Logger
+}
+
+func newLoggingTransport(base http.RoundTripper, log *slog.Logger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &loggingTransport{base: base, log: log}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody := peekRequestBody(req)
+	start := time.Now()
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.log.Debug("provider request failed",
+			"method", req.Method,
+			"url", req.URL.String(),
+			"headers", redactHeaders(req.Header),
+			"request_body", reqBody,
+			"error", err)
+		return nil, err
+	}
+
+	head, rest := peek(resp.Body)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), rest), resp.Body}
+
+	t.log.Debug("provider request",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"headers", redactHeaders(req.Header),
+		"request_body", reqBody,
+		"status", resp.StatusCode,
+		"response_body", truncateBody(head),
+		"duration", time.Since(start))
+	return resp, nil
+}
+
+// peekRequestBody returns a copy of the request body. It uses GetBody when
+// available; otherwise it reads the body and replaces it.
+func peekRequestBody(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return ""
+		}
+		defer rc.Close()
+		head, _ := io.ReadAll(io.LimitReader(rc, debugBodyLimit+1))
+		return truncateBody(head)
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	return truncateBody(data)
+}
+
+// peek reads up to debugBodyLimit+1 bytes from r and returns them along
+// with r, which yields the remaining bytes.
+func peek(r io.Reader) ([]byte, io.Reader) {
+	head, _ := io.ReadAll(io.LimitReader(r, debugBodyLimit+1))
+	return head, r
+}
+
+func truncateBody(b []byte) string {
+	if len(b) > debugBodyLimit {
+		return string(b[:debugBodyLimit]) + "...(truncated)"
+	}
+	return string(b)
+}
+
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	if out.Get("Authorization") != "" {
+		out.Set("Authorization", "[REDACTED]")
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package compute
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoggingTransport_LogsWithoutConsumingBodies(t *testing.T) {
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := &http.Client{Transport: newLoggingTransport(nil, log)}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"prompt":"hi"}`))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if gotBody != `{"prompt":"hi"}` {
+		t.Errorf("server got body %q", gotBody)
+	}
+	if string(respBody) != `{"ok":true}` {
+		t.Errorf("client got body %q", respBody)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "secret-token") {
+		t.Errorf("log leaked Authorization header: %s", out)
+	}
+	for _, want := range []string{"REDACTED", "status=200", `prompt`, `ok`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q: %s", want, out)
+		}
+	}
+}
+
+func TestLoggingTransport_TruncatesLargeBodies(t *testing.T) {
+	large := strings.Repeat("x", debugBodyLimit*2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(large))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := &http.Client{Transport: newLoggingTransport(nil, log)}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if len(body) != len(large) {
+		t.Errorf("body length = %d, want %d", len(body), len(large))
+	}
+	if !strings.Contains(buf.String(), "(truncated)") {
+		t.Error("expected truncated marker in log")
+	}
+	if strings.Contains(buf.String(), large) {
+		t.Error("log contains full body")
+	}
+}
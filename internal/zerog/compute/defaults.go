@@ -0,0 +1,35 @@
+package compute
+
+// ModelParams are per-model request defaults. Unset fields leave the
+// request unchanged.
+type ModelParams struct {
+	// MaxTokens applies when JobRequest.MaxTokens is zero.
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// Temperature applies when JobRequest.Temperature is nil. A pointer,
+	// so a default of 0 (deterministic output) can be expressed and is
+	// sent to the provider rather than omitted.
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// applyModelDefaults fills the request's unset MaxTokens from the model's
+// defaults. Explicit request values always win.
+func (b *broker) applyModelDefaults(req JobRequest) JobRequest {
+	d, ok := b.cfg.ModelDefaults[req.ModelID]
+	if ok && req.MaxTokens == 0 {
+		req.MaxTokens = d.MaxTokens
+	}
+	return req
+}
+
+// temperatureFor returns the temperature to send for req: the request's
+// own value if set, else the model default, else nil so the provider
+// uses its own default.
+func (b *broker) temperatureFor(req JobRequest) *float64 {
+	if req.Temperature != nil {
+		return req.Temperature
+	}
+	if d, ok := b.cfg.ModelDefaults[req.ModelID]; ok {
+		return d.Temperature
+	}
+	return nil
+}
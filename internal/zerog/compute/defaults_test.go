@@ -0,0 +1,73 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestSubmitJob_ModelDefaultsPrecedence(t *testing.T) {
+	var got map[string]any
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/proxy/chat/completions":
+			got = nil
+			json.NewDecoder(r.Body).Decode(&got)
+			json.NewEncoder(w).Encode(chatResponse{
+				ID:      "job-defaults",
+				Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "ok"}}},
+			})
+		case "/api/services/list":
+			json.NewEncoder(w).Encode([]map[string]string{
+				{"url": srv.URL, "model": "classifier"},
+				{"url": srv.URL, "model": "chat"},
+			})
+		}
+	}))
+	defer srv.Close()
+
+	zero, low, warm := 0.0, 0.2, 0.7
+	key, _ := crypto.GenerateKey()
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               srv.URL,
+		ModelDefaults: map[string]ModelParams{
+			"classifier": {Temperature: &zero, MaxTokens: 16},
+			"chat":       {Temperature: &warm},
+		},
+	}, &zgtest.MockBackend{}, key)
+
+	tests := []struct {
+		name            string
+		req             JobRequest
+		wantTemperature any // nil means omitted
+		wantMaxTokens   any
+	}{
+		{"defaults applied", JobRequest{ModelID: "classifier", Input: "x"}, 0.0, 16.0},
+		{"request overrides", JobRequest{ModelID: "classifier", Input: "x", Temperature: &low, MaxTokens: 8}, 0.2, 8.0},
+		{"partial defaults", JobRequest{ModelID: "chat", Input: "x"}, 0.7, nil},
+		{"request sets zero", JobRequest{ModelID: "chat", Input: "x", Temperature: &zero}, 0.0, nil},
+		{"no defaults", JobRequest{ModelID: "other", Input: "x", ProviderURL: srv.URL}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := b.SubmitJob(context.Background(), tt.req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got["temperature"] != tt.wantTemperature {
+				t.Errorf("temperature = %v, want %v", got["temperature"], tt.wantTemperature)
+			}
+			if got["max_tokens"] != tt.wantMaxTokens {
+				t.Errorf("max_tokens = %v, want %v", got["max_tokens"], tt.wantMaxTokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// list fetches a fresh listing, hedged or serial per the config.
+func (b *broker) list(ctx context.Context) ([]Model, int, error) {
+	if b.cfg.HedgeListModels && b.cfg.Endpoint != "" {
+		return b.listHedged(ctx)
+	}
+	return b.listSerial(ctx)
+}
+
+// listSerial queries the chain and falls back to the HTTP endpoint, if
+// set, when the chain query fails.
+func (b *broker) listSerial(ctx context.Context) ([]Model, int, error) {
+	models, total, err := b.listFromChain(ctx)
+	if err != nil {
+		// Fall back to HTTP endpoint if chain query fails and endpoint is set
+		if b.cfg.Endpoint != "" {
+			return b.listFromHTTP(ctx)
+		}
+		return nil, 0, fmt.Errorf("compute: list models from chain: %w", err)
+	}
+
+	if len(models) == 0 {
+		return nil, 0, ErrNoModels
+	}
+
+	return models, total, nil
+}
+
+// listFromChain returns the first page of services and the contract's
+// total service count.
+func (b *broker) listFromChain(ctx context.Context) ([]Model, int, error) {
+	var result []interface{}
+	err := b.contract.Call(&bind.CallOpts{Context: ctx}, &result, "getAllServices", big.NewInt(0), big.NewInt(servicesPageLimit))
+	if err != nil {
+		return nil, 0, fmt.Errorf("getAllServices: %w", err)
+	}
+
+	if len(result) < 2 {
+		return nil, 0, nil
+	}
+
+	// result[0] is the services array, result[1] is the total count.
+	// Struct field order must match the contract's Service struct exactly.
+	services, ok := result[0].([]struct {
+		Provider      common.Address `json:"provider"`
+		Name          string         `json:"name"`
+		Url           string         `json:"url"`
+		InputPrice    *big.Int       `json:"inputPrice"`
+		OutputPrice   *big.Int       `json:"outputPrice"`
+		UpdatedAt     *big.Int       `json:"updatedAt"`
+		Model         string         `json:"model"`
+		Verifiability string         `json:"verifiability"`
+		Content       string         `json:"content"`
+		Signer        common.Address `json:"signer"`
+		Occupied      bool           `json:"occupied"`
+	})
+	if !ok {
+		return nil, 0, fmt.Errorf("unexpected services type: %T", result[0])
+	}
+	total := len(services)
+	if t, ok := result[1].(*big.Int); ok && t.IsInt64() {
+		total = int(t.Int64())
+	}
+
+	models := make([]Model, 0, len(services))
+	for _, svc := range services {
+		models = append(models, Model{
+			ID:            svc.Model,
+			Name:          svc.Name,
+			Provider:      svc.Provider.Hex(),
+			URL:           svc.Url,
+			InputPrice:    svc.InputPrice,
+			OutputPrice:   svc.OutputPrice,
+			Verifiability: svc.Verifiability,
+		})
+	}
+
+	return models, total, nil
+}
+
+func (b *broker) listFromHTTP(ctx context.Context) ([]Model, int, error) {
+	endpoint := b.cfg.Endpoint + "/api/services/list"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := b.client.Do(httpReq)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list services: %w", ErrBrokerDown)
+	}
+	defer resp.Body.Close()
+
+	body, readErr := readCapped(resp.Body, b.cfg.MaxListBytes)
+	if readErr != nil && !errors.Is(readErr, ErrResponseTooLarge) {
+		return nil, 0, fmt.Errorf("read response: %w", readErr)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("list returned status %d: %s", resp.StatusCode, string(body))
+	}
+	if readErr != nil {
+		return nil, 0, fmt.Errorf("list services: %w", readErr)
+	}
+
+	type serviceEntry struct {
+		Provider      string `json:"providerAddress"`
+		Name          string `json:"name"`
+		ServiceType   string `json:"serviceType"`
+		URL           string `json:"url"`
+		Model         string `json:"model"`
+		Verifiability string `json:"verifiability"`
+	}
+
+	var services []serviceEntry
+	if err := json.Unmarshal(body, &services); err != nil {
+		return nil, 0, fmt.Errorf("parse services: %w", err)
+	}
+
+	if len(services) == 0 {
+		return nil, 0, ErrNoModels
+	}
+
+	models := make([]Model, len(services))
+	for i, svc := range services {
+		models[i] = Model{
+			ID:            svc.Model,
+			Name:          svc.Name,
+			Provider:      svc.Provider,
+			ServiceType:   svc.ServiceType,
+			URL:           svc.URL,
+			Verifiability: svc.Verifiability,
+		}
+	}
+
+	return models, len(models), nil
+}
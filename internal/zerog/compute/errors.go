@@ -0,0 +1,45 @@
+package compute
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// classifyProviderError converts a non-200 provider response into an error
+// wrapping ErrRateLimited, ErrBadRequest, or ErrProviderError. The
+// OpenAI-style error body, when present, takes precedence over the status.
+func classifyProviderError(status int, body []byte) error {
+	var parsed struct {
+		Error *chatRespError `json:"error"`
+	}
+	detail := strings.TrimSpace(string(body))
+	var kind, code string
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error != nil {
+		kind = parsed.Error.Type
+		code = strings.Trim(string(parsed.Error.Code), `"`)
+		if parsed.Error.Message != "" {
+			detail = parsed.Error.Message
+		}
+	}
+
+	return fmt.Errorf("compute: provider returned status %d: %s: %w", status, detail, providerErrorClass(status, kind, code))
+}
+
+func providerErrorClass(status int, kind, code string) error {
+	switch {
+	case status == http.StatusTooManyRequests,
+		strings.Contains(kind, "rate_limit"),
+		strings.Contains(code, "rate_limit"):
+		return ErrRateLimited
+	case status >= 500,
+		kind == "server_error":
+		return ErrProviderError
+	case status >= 400,
+		strings.Contains(kind, "invalid_request"):
+		return ErrBadRequest
+	default:
+		return ErrProviderError
+	}
+}
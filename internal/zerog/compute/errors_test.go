@@ -0,0 +1,54 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestClassifyProviderError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"429 status", http.StatusTooManyRequests, `too many requests`, ErrRateLimited},
+		{"rate limit type on 400", http.StatusBadRequest, `{"error":{"message":"slow down","type":"rate_limit_error"}}`, ErrRateLimited},
+		{"rate limit code", http.StatusForbidden, `{"error":{"message":"quota","type":"requests","code":"rate_limit_exceeded"}}`, ErrRateLimited},
+		{"invalid request", http.StatusBadRequest, `{"error":{"message":"bad model","type":"invalid_request_error","code":null}}`, ErrBadRequest},
+		{"numeric code", http.StatusNotFound, `{"error":{"message":"missing","type":"not_found","code":404}}`, ErrBadRequest},
+		{"server error", http.StatusBadGateway, `upstream down`, ErrProviderError},
+		{"server error type", http.StatusInternalServerError, `{"error":{"message":"oops","type":"server_error"}}`, ErrProviderError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyProviderError(tt.status, []byte(tt.body))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSubmitJob_RateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/services/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"rate limit reached","type":"rate_limit_error"}}`))
+	}))
+	defer srv.Close()
+
+	b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL)
+	_, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "m", Input: "hi"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+}
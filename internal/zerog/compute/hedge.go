@@ -0,0 +1,47 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// listHedged queries the chain and the HTTP endpoint concurrently and
+// returns the first successful listing, cancelling the slower query. It
+// fails only when both sources do.
+func (b *broker) listHedged(ctx context.Context) ([]Model, int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type listing struct {
+		models []Model
+		total  int
+		err    error
+	}
+	results := make(chan listing, 2)
+
+	go func() {
+		models, total, err := b.listFromChain(ctx)
+		if err == nil && len(models) == 0 {
+			err = ErrNoModels
+		}
+		if err != nil {
+			err = fmt.Errorf("compute: list models from chain: %w", err)
+		}
+		results <- listing{models, total, err}
+	}()
+	go func() {
+		models, total, err := b.listFromHTTP(ctx)
+		results <- listing{models, total, err}
+	}()
+
+	var errs []error
+	for range 2 {
+		r := <-results
+		if r.err == nil {
+			return r.models, r.total, nil
+		}
+		errs = append(errs, r.err)
+	}
+	return nil, 0, errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestListModels_HedgedHTTPWins(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]string{
+			{"providerAddress": "0xabc", "name": "Test", "serviceType": "chatbot", "model": "http-model"},
+		})
+	}))
+	defer srv.Close()
+
+	chainCancelled := make(chan struct{})
+	backend := &zgtest.MockBackend{
+		CallFn: func(ctx context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			// A slow RPC: only returns once the hedge cancels it.
+			select {
+			case <-ctx.Done():
+				close(chainCancelled)
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil, context.DeadlineExceeded
+			}
+		},
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               srv.URL,
+		HedgeListModels:        true,
+	}, backend, key)
+
+	start := time.Now()
+	models, err := b.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("hedged listing waited on the slow chain: %v", elapsed)
+	}
+	if len(models) != 1 || models[0].ID != "http-model" {
+		t.Fatalf("expected the HTTP listing, got %+v", models)
+	}
+
+	select {
+	case <-chainCancelled:
+	case <-time.After(time.Second):
+		t.Error("chain query was not cancelled after HTTP won")
+	}
+}
+
+func TestListModels_HedgedBothFail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			return nil, context.DeadlineExceeded
+		},
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               srv.URL,
+		HedgeListModels:        true,
+	}, backend, key)
+
+	_, err = b.ListModels(context.Background())
+	if !errors.Is(err, ErrNoModels) {
+		t.Fatalf("expected ErrNoModels from the HTTP source, got %v", err)
+	}
+}
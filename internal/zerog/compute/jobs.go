@@ -0,0 +1,91 @@
+package compute
+
+import (
+	"container/list"
+	"sync"
+)
+
+// maxTrackedJobs bounds how many uncollected jobs the broker remembers.
+// Once full, the oldest job is forgotten.
+const maxTrackedJobs = 1024
+
+// trackedJob is what the broker remembers about a submitted job.
+type trackedJob struct {
+	id          string
+	providerURL string
+	result      *JobResult // nil until the provider has answered
+}
+
+// jobTable holds submitted jobs until GetResult or CancelJob collects
+// them, keeping at most max so jobs that are never collected do not
+// grow the broker for the life of the process. It is safe for
+// concurrent use.
+type jobTable struct {
+	mu    sync.Mutex
+	max   int
+	order *list.List // of trackedJob, newest at the front
+	items map[string]*list.Element
+}
+
+// newJobTable returns a job table holding at most max jobs.
+func newJobTable(max int) *jobTable {
+	return &jobTable{max: max, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// track records job, replacing any earlier entry with the same ID, and
+// forgets the oldest job if the table is full.
+func (t *jobTable) track(job trackedJob) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if el, ok := t.items[job.id]; ok {
+		t.order.Remove(el)
+	}
+	t.items[job.id] = t.order.PushFront(job)
+	if t.order.Len() > t.max {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.items, oldest.Value.(trackedJob).id)
+	}
+}
+
+// collect removes and returns the job's result once the provider has
+// answered; a job still awaiting its result stays tracked.
+func (t *jobTable) collect(id string) (*JobResult, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	el, ok := t.items[id]
+	if !ok || el.Value.(trackedJob).result == nil {
+		return nil, false
+	}
+	t.order.Remove(el)
+	delete(t.items, id)
+	return el.Value.(trackedJob).result, true
+}
+
+// take removes the job and returns what was recorded for it.
+func (t *jobTable) take(id string) (trackedJob, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	el, ok := t.items[id]
+	if !ok {
+		return trackedJob{}, false
+	}
+	t.order.Remove(el)
+	delete(t.items, id)
+	return el.Value.(trackedJob), true
+}
+
+// len returns the number of tracked jobs.
+func (t *jobTable) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.order.Len()
+}
+
+// clear forgets every job.
+func (t *jobTable) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.order.Init()
+	clear(t.items)
+}
@@ -0,0 +1,96 @@
+package compute
+
+import (
+	"sync"
+	"time"
+)
+
+// ProviderSelection chooses among providers serving the same model.
+type ProviderSelection string
+
+const (
+	// ProviderSelectionFirst uses the first provider listed for the model.
+	ProviderSelectionFirst ProviderSelection = "first"
+	// ProviderSelectionFastest uses the provider with the lowest observed
+	// response latency (EWMA).
+	ProviderSelectionFastest ProviderSelection = "fastest"
+)
+
+// Observer receives broker telemetry. Implementations must be safe for
+// concurrent use and should return quickly.
+type Observer interface {
+	// ObserveProviderLatency is called after each successful provider
+	// response with the sample and the provider's updated moving average.
+	ObserveProviderLatency(providerURL string, sample, average time.Duration)
+}
+
+// StaleModelsObserver is optionally implemented by an Observer to learn
+// when ListModels serves an expired listing because discovery failed.
+type StaleModelsObserver interface {
+	ObserveStaleModels(age time.Duration, cause error)
+}
+
+const (
+	// latencyAlpha weights the newest sample in the moving average.
+	latencyAlpha = 0.2
+	// defaultLatencySeed is the estimate for providers before any samples
+	// exist for the model.
+	defaultLatencySeed = time.Second
+)
+
+// latencyTracker keeps an exponentially-weighted moving average of
+// response latency per provider URL.
+type latencyTracker struct {
+	mu   sync.Mutex
+	ewma map[string]time.Duration
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{ewma: make(map[string]time.Duration)}
+}
+
+// record folds sample into the provider's average and returns the result.
+func (t *latencyTracker) record(url string, sample time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev, ok := t.ewma[url]
+	if !ok {
+		t.ewma[url] = sample
+		return sample
+	}
+	avg := time.Duration(latencyAlpha*float64(sample) + (1-latencyAlpha)*float64(prev))
+	t.ewma[url] = avg
+	return avg
+}
+
+// fastest returns the index of the candidate with the lowest average.
+// Unseen providers are seeded with the mean over all tracked providers so
+// they still receive traffic without displacing a known-fast provider.
+func (t *latencyTracker) fastest(candidates []providerInfo) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var sum time.Duration
+	var seen int
+	for _, d := range t.ewma {
+		sum += d
+		seen++
+	}
+	seed := defaultLatencySeed
+	if seen > 0 {
+		seed = sum / time.Duration(seen)
+	}
+
+	best := 0
+	bestLatency := time.Duration(-1)
+	for i, c := range candidates {
+		d, ok := t.ewma[c.URL]
+		if !ok {
+			d = seed
+		}
+		if bestLatency < 0 || d < bestLatency {
+			best, bestLatency = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,102 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestLatencyTracker_EWMA(t *testing.T) {
+	lt := newLatencyTracker()
+	if got := lt.record("a", 100*time.Millisecond); got != 100*time.Millisecond {
+		t.Errorf("first sample = %v, want 100ms", got)
+	}
+	// 0.2*200 + 0.8*100 = 120
+	if got := lt.record("a", 200*time.Millisecond); got != 120*time.Millisecond {
+		t.Errorf("second sample = %v, want 120ms", got)
+	}
+}
+
+func TestLatencyTracker_Fastest(t *testing.T) {
+	lt := newLatencyTracker()
+	lt.record("slow", 500*time.Millisecond)
+	lt.record("fast", 50*time.Millisecond)
+
+	candidates := []providerInfo{{URL: "slow"}, {URL: "fast"}}
+	if got := candidates[lt.fastest(candidates)].URL; got != "fast" {
+		t.Errorf("fastest = %q, want fast", got)
+	}
+
+	// An unseen provider is seeded with the mean (275ms): it beats the slow
+	// provider but not the fast one.
+	candidates = []providerInfo{{URL: "slow"}, {URL: "new"}}
+	if got := candidates[lt.fastest(candidates)].URL; got != "new" {
+		t.Errorf("fastest = %q, want new", got)
+	}
+	candidates = []providerInfo{{URL: "new"}, {URL: "fast"}}
+	if got := candidates[lt.fastest(candidates)].URL; got != "fast" {
+		t.Errorf("fastest = %q, want fast", got)
+	}
+}
+
+type latencyObserver struct {
+	mu      sync.Mutex
+	samples map[string]int
+}
+
+func (o *latencyObserver) ObserveProviderLatency(url string, _, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.samples[url]++
+}
+
+func TestSubmitJob_FastestProviderSelection(t *testing.T) {
+	newProvider := func(delay time.Duration, hits *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*hits++
+			time.Sleep(delay)
+			json.NewEncoder(w).Encode(chatResponse{
+				ID:      "job",
+				Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "ok"}}},
+			})
+		}))
+	}
+	var slowHits, fastHits int
+	slow := newProvider(30*time.Millisecond, &slowHits)
+	defer slow.Close()
+	fast := newProvider(0, &fastHits)
+	defer fast.Close()
+
+	obs := &latencyObserver{samples: make(map[string]int)}
+	b := NewBroker(BrokerConfig{
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		ProviderSelection:      ProviderSelectionFastest,
+		Observer:               obs,
+	}, &zgtest.MockBackend{}, nil).(*broker)
+	b.cacheModels([]Model{
+		{ID: "m", URL: slow.URL},
+		{ID: "m", URL: fast.URL},
+	})
+
+	// Seed both providers with one sample each.
+	b.recordLatency(slow.URL, 30*time.Millisecond)
+	b.recordLatency(fast.URL, time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "m", Input: "hi"}); err != nil {
+			t.Fatalf("SubmitJob: %v", err)
+		}
+	}
+	if fastHits != 3 || slowHits != 0 {
+		t.Errorf("hits fast=%d slow=%d, want 3/0", fastHits, slowHits)
+	}
+	if obs.samples[fast.URL] != 4 {
+		t.Errorf("observer samples for fast = %d, want 4", obs.samples[fast.URL])
+	}
+}
@@ -0,0 +1,29 @@
+package compute
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// DefaultMaxResponseBytes is the chat completion response cap used when
+	// BrokerConfig.MaxResponseBytes is zero.
+	DefaultMaxResponseBytes = 1 << 20
+	// DefaultMaxListBytes is the service listing response cap used when
+	// BrokerConfig.MaxListBytes is zero.
+	DefaultMaxListBytes = 64 << 10
+)
+
+// readCapped reads r up to limit bytes. If r holds more, it returns the
+// first limit bytes with an error wrapping ErrResponseTooLarge, so callers
+// never parse a silently truncated body.
+func readCapped(r io.Reader, limit int) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > limit {
+		return body[:limit], fmt.Errorf("response exceeds %d bytes: %w", limit, ErrResponseTooLarge)
+	}
+	return body, nil
+}
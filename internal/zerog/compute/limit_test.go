@@ -0,0 +1,84 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func newLimitServer(t *testing.T, output string, services int) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/proxy/chat/completions":
+			json.NewEncoder(w).Encode(chatResponse{
+				ID:      "job-big",
+				Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: output}}},
+				Model:   "test-model",
+			})
+		case "/api/services/list":
+			type svcEntry struct {
+				URL   string `json:"url"`
+				Model string `json:"model"`
+			}
+			list := make([]svcEntry, services)
+			for i := range list {
+				list[i] = svcEntry{URL: srv.URL, Model: "test-model"}
+			}
+			json.NewEncoder(w).Encode(list)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newLimitBroker(t *testing.T, endpoint string, maxResponse, maxList int) ComputeBroker {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               endpoint,
+		MaxResponseBytes:       maxResponse,
+		MaxListBytes:           maxList,
+	}, &zgtest.MockBackend{}, key)
+}
+
+func TestSubmitJob_ResponseTooLarge(t *testing.T) {
+	srv := newLimitServer(t, strings.Repeat("x", 4096), 1)
+
+	b := newLimitBroker(t, srv.URL, 1024, 0)
+	_, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "test-model", Input: "hi"})
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+
+	// The same response fits under a larger cap.
+	b = newLimitBroker(t, srv.URL, 8192, 0)
+	if _, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "test-model", Input: "hi"}); err != nil {
+		t.Fatalf("unexpected error under a larger cap: %v", err)
+	}
+}
+
+func TestListModels_ListingTooLarge(t *testing.T) {
+	srv := newLimitServer(t, "ok", 100)
+
+	b := newLimitBroker(t, srv.URL, 0, 512)
+	if _, err := b.ListModels(context.Background()); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
@@ -1,8 +1,13 @@
 package compute
 
 import (
+	"encoding/json"
 	"errors"
+	"math/big"
+	"net/http"
 	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
 )
 
 // Sentinel errors for compute operations.
@@ -11,6 +16,29 @@ var (
 	ErrJobFailed  = errors.New("compute: job execution failed")
 	ErrNoModels   = errors.New("compute: no models available")
 	ErrBrokerDown = errors.New("compute: broker is unreachable")
+
+	// Request validation errors, returned before any provider call.
+	ErrInvalidModel = errors.New("compute: invalid model ID")
+	ErrInvalidInput = errors.New("compute: invalid input")
+	// ErrContextOverflow means input plus MaxTokens exceeds the model's
+	// context window.
+	ErrContextOverflow = errors.New("compute: request exceeds model context window")
+
+	// Provider HTTP error classes, for retry decisions via errors.Is.
+	ErrRateLimited   = errors.New("compute: provider rate limited")
+	ErrBadRequest    = errors.New("compute: provider rejected request")
+	ErrProviderError = errors.New("compute: provider server error")
+	// ErrEmptyResponse means a provider answered successfully but with no
+	// choices, so there is no output to return.
+	ErrEmptyResponse = errors.New("compute: provider returned an empty response")
+	// ErrResponseTooLarge means a response exceeded its configured byte cap.
+	ErrResponseTooLarge = errors.New("compute: response too large")
+	// ErrUnverifiedProvider means no provider for the model meets
+	// BrokerConfig.RequireVerifiability.
+	ErrUnverifiedProvider = errors.New("compute: no provider meets the required verifiability")
+	// ErrUnlistedProvider means a pinned provider URL is not in the
+	// service listing.
+	ErrUnlistedProvider = errors.New("compute: pinned provider is not listed")
 )
 
 // JobStatus represents the state of an inference job.
@@ -23,13 +51,32 @@ const (
 	JobStatusFailed    JobStatus = "failed"
 )
 
+// Finish reasons reported by OpenAI-compatible providers.
+const (
+	FinishReasonStop          = "stop"
+	FinishReasonLength        = "length"
+	FinishReasonContentFilter = "content_filter"
+)
+
 // JobRequest describes an inference job to submit to 0G Compute.
 type JobRequest struct {
 	ModelID     string            `json:"model_id"`
 	Input       string            `json:"input"`
 	MaxTokens   int               `json:"max_tokens,omitempty"`
-	Temperature float64           `json:"temperature,omitempty"`
+	Temperature *float64          `json:"temperature,omitempty"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
+	// ProviderAddress pins the job to the provider with this on-chain
+	// address, bypassing discovery. Session auth still applies.
+	ProviderAddress string `json:"provider_address,omitempty"`
+	// ProviderURL pins the job to this provider endpoint. It must match
+	// the URL the service listing gives for the provider (for
+	// ProviderAddress, when both are set), or the job is refused with
+	// ErrUnlistedProvider. If the pinned provider is unreachable the
+	// broker falls back to discovery.
+	ProviderURL string `json:"provider_url,omitempty"`
+	// StatusCallback, if set, is called as the job moves through
+	// pending, running, and completed or failed.
+	StatusCallback StatusCallback `json:"-"`
 }
 
 // JobResult contains the output of a completed inference job.
@@ -41,6 +88,20 @@ type JobResult struct {
 	TokensUsed int           `json:"tokens_used"`
 	Duration   time.Duration `json:"duration"`
 	Error      string        `json:"error,omitempty"`
+	// FinishReason is why the provider stopped generating, e.g. "stop",
+	// "length", or "content_filter". Empty if the provider did not say.
+	FinishReason string `json:"finish_reason,omitempty"`
+	// Provider identifies who served the job: the provider's on-chain
+	// address, or its URL when the address is unknown.
+	Provider string `json:"provider,omitempty"`
+	// Verifiability is the serving provider's registered verification
+	// mode, e.g. "TeeML". Empty if the provider was not listed with one.
+	Verifiability string `json:"verifiability,omitempty"`
+}
+
+// Truncated reports whether generation stopped at the token limit.
+func (r *JobResult) Truncated() bool {
+	return r.FinishReason == FinishReasonLength
 }
 
 // Model describes an available AI model on the 0G compute network.
@@ -50,6 +111,32 @@ type Model struct {
 	Provider    string `json:"provider"`
 	ServiceType string `json:"service_type,omitempty"`
 	URL         string `json:"url,omitempty"`
+	// InputPrice and OutputPrice are per-token prices in neuron, when the
+	// provider publishes them on-chain.
+	InputPrice  *big.Int `json:"input_price,omitempty"`
+	OutputPrice *big.Int `json:"output_price,omitempty"`
+	// Verifiability is how the provider's outputs can be verified, e.g.
+	// "TeeML"; empty for unverified providers.
+	Verifiability string `json:"verifiability,omitempty"`
+}
+
+// ListModelsResult is a model listing with the registry's total service
+// count. Truncated reports that the registry holds more services than were
+// returned. Stale reports that discovery failed and the listing is an
+// expired cache entry, CacheAge old; see BrokerConfig.MaxStaleModels.
+type ListModelsResult struct {
+	Models    []Model       `json:"models"`
+	Total     int           `json:"total"`
+	Truncated bool          `json:"truncated"`
+	Stale     bool          `json:"stale,omitempty"`
+	CacheAge  time.Duration `json:"cache_age,omitempty"`
+}
+
+func newListModelsResult(models []Model, total int) ListModelsResult {
+	if total < len(models) {
+		total = len(models)
+	}
+	return ListModelsResult{Models: models, Total: total, Truncated: total > len(models)}
 }
 
 // BrokerConfig holds configuration for the 0G Compute broker.
@@ -71,6 +158,70 @@ type BrokerConfig struct {
 	PollInterval time.Duration
 	// PollTimeout is the maximum time to wait for a job to complete.
 	PollTimeout time.Duration
+	// Clock drives polling, timeouts, and cache expiry. Nil uses real time.
+	Clock clock.Clock
+	// AuthTokenBuilder creates provider bearer tokens. Nil uses
+	// DefaultAuthToken (the 0G SDK "app-sk-" format). It is also used to
+	// refresh the token when a provider answers 401.
+	AuthTokenBuilder AuthTokenBuilder
+	// ClockSkewTolerance is how far the provider's clock may drift from
+	// ours before a 401 that rejects the token timestamp resyncs token
+	// timestamps to the response Date header. Zero uses
+	// DefaultClockSkewTolerance; negative disables the correction. Only
+	// the default token builder is corrected.
+	ClockSkewTolerance time.Duration
+	// Transport carries provider HTTP requests, e.g. through a proxy. Nil
+	// uses http.DefaultTransport.
+	Transport http.RoundTripper
+	// Debug logs provider HTTP requests and responses, including truncated
+	// bodies, at debug level. The Authorization header is redacted.
+	Debug bool
+	// MaxInputBytes caps JobRequest.Input; larger inputs are rejected before
+	// any provider call. Zero uses DefaultMaxInputBytes.
+	MaxInputBytes int
+	// MaxResponseBytes caps a provider's chat completion response. Larger
+	// responses fail with ErrResponseTooLarge. Zero uses
+	// DefaultMaxResponseBytes.
+	MaxResponseBytes int
+	// MaxListBytes caps the service listing response from Endpoint.
+	// Larger listings fail with ErrResponseTooLarge. Zero uses
+	// DefaultMaxListBytes.
+	MaxListBytes int
+	// ProviderSelection picks among providers serving the same model.
+	// Empty uses ProviderSelectionFirst.
+	ProviderSelection ProviderSelection
+	// Observer, if set, receives per-provider latency samples.
+	Observer Observer
+	// Tokenizer counts tokens for cost estimates and context-window checks.
+	// Nil uses ApproxTokenizer.
+	Tokenizer Tokenizer
+	// ContextWindows maps model IDs to their context size in tokens.
+	// Models without an entry are not checked.
+	ContextWindows map[string]int
+	// ModelDefaults maps model IDs to request defaults such as
+	// temperature, merged into each JobRequest for that model. Values set
+	// on the request take precedence.
+	ModelDefaults map[string]ModelParams
+	// AutoClampTokens lowers MaxTokens to fit the context window instead
+	// of failing with ErrContextOverflow.
+	AutoClampTokens bool
+	// RequireVerifiability, when set, routes jobs only to providers
+	// registered with this verifiability (compared case-insensitively),
+	// e.g. "TeeML". The fallback Endpoint and providers pinned by URL
+	// alone have no known verifiability and are refused. Empty accepts
+	// any provider.
+	RequireVerifiability string
+	// MaxStaleModels lets ListModels fall back to an expired cached
+	// listing, at most this long past ModelCacheTTL, when discovery fails.
+	// Such results are flagged Stale. Zero never serves stale listings.
+	MaxStaleModels time.Duration
+	// HedgeListModels queries the chain and Endpoint concurrently when
+	// listing models, taking whichever succeeds first and cancelling the
+	// other. False queries the chain first and falls back to Endpoint.
+	HedgeListModels bool
+	// MaxRetryAfter caps how long a 429 Retry-After wait may be before the
+	// request is retried. Zero uses 30s.
+	MaxRetryAfter time.Duration
 }
 
 // chatRequest is the OpenAI-compatible request format used by 0G serving.
@@ -78,7 +229,7 @@ type chatRequest struct {
 	Model       string        `json:"model"`
 	Messages    []chatMessage `json:"messages"`
 	MaxTokens   int           `json:"max_tokens,omitempty"`
-	Temperature float64       `json:"temperature,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
 }
 
 type chatMessage struct {
@@ -95,8 +246,9 @@ type chatResponse struct {
 }
 
 type chatChoice struct {
-	Message chatMessage `json:"message"`
-	Index   int         `json:"index"`
+	Message      chatMessage `json:"message"`
+	Index        int         `json:"index"`
+	FinishReason string      `json:"finish_reason,omitempty"`
 }
 
 type chatUsage struct {
@@ -108,4 +260,6 @@ type chatUsage struct {
 type chatRespError struct {
 	Message string `json:"message"`
 	Type    string `json:"type"`
+	// Code is a string in OpenAI responses but numeric for some providers.
+	Code json.RawMessage `json:"code,omitempty"`
 }
@@ -0,0 +1,110 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// pinned reports whether req names a specific provider.
+func (req JobRequest) pinned() bool {
+	return req.ProviderURL != "" || req.ProviderAddress != ""
+}
+
+// providerFor returns the provider req should be sent to. Pins are
+// resolved against the service listing and the URL always comes from the
+// listing, never from the request, so a task cannot point the broker, and
+// the session token minted for the pinned address, at an arbitrary host.
+//
+// A pinned ProviderURL must be the listed URL of the pinned address, or of
+// some listed provider when no address is given; otherwise the request
+// fails with ErrUnlistedProvider. Requests without a pin, or whose pinned
+// address alone is not listed, go through normal discovery. A pinned
+// provider must still meet RequireVerifiability.
+func (b *broker) providerFor(ctx context.Context, req JobRequest) (providerInfo, error) {
+	switch {
+	case req.ProviderAddress != "":
+		p, ok := b.lookupProvider(ctx, req.ModelID, func(m Model) bool {
+			return strings.EqualFold(m.Provider, req.ProviderAddress)
+		})
+		if !ok && req.ProviderURL == "" {
+			return b.resolveProvider(ctx, req.ModelID)
+		}
+		if req.ProviderURL != "" && (!ok || !sameURL(p.URL, req.ProviderURL)) {
+			return providerInfo{}, fmt.Errorf("pinned URL %s is not listed for provider %s: %w",
+				req.ProviderURL, req.ProviderAddress, ErrUnlistedProvider)
+		}
+		return b.checkPinned(p)
+	case req.ProviderURL != "":
+		p, ok := b.lookupProvider(ctx, req.ModelID, func(m Model) bool {
+			return sameURL(m.URL, req.ProviderURL)
+		})
+		if !ok {
+			return providerInfo{}, fmt.Errorf("pinned URL %s: %w", req.ProviderURL, ErrUnlistedProvider)
+		}
+		return b.checkPinned(p)
+	}
+	return b.resolveProvider(ctx, req.ModelID)
+}
+
+// sameURL reports whether two provider URLs name the same endpoint,
+// ignoring case and trailing slashes.
+func sameURL(a, b string) bool {
+	return strings.EqualFold(strings.TrimRight(a, "/"), strings.TrimRight(b, "/"))
+}
+
+// checkPinned refuses a pinned provider that does not meet
+// RequireVerifiability.
+func (b *broker) checkPinned(p providerInfo) (providerInfo, error) {
+	if !b.meetsVerifiability(p) {
+		return providerInfo{}, fmt.Errorf("pinned provider %s has verifiability %q, need %s: %w",
+			p.id(), p.Verifiability, b.cfg.RequireVerifiability, ErrUnverifiedProvider)
+	}
+	return p, nil
+}
+
+// meetsVerifiability reports whether p satisfies RequireVerifiability.
+func (b *broker) meetsVerifiability(p providerInfo) bool {
+	return b.cfg.RequireVerifiability == "" || strings.EqualFold(p.Verifiability, b.cfg.RequireVerifiability)
+}
+
+// verifiable filters candidates down to those meeting RequireVerifiability.
+func (b *broker) verifiable(candidates []providerInfo) []providerInfo {
+	if b.cfg.RequireVerifiability == "" {
+		return candidates
+	}
+	var out []providerInfo
+	for _, p := range candidates {
+		if b.meetsVerifiability(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// lookupProvider finds a listing entry accepted by match, preferring one
+// that serves modelID.
+func (b *broker) lookupProvider(ctx context.Context, modelID string, match func(Model) bool) (providerInfo, bool) {
+	models := b.cachedModels()
+	if models == nil {
+		var err error
+		if models, err = b.ListModels(ctx); err != nil {
+			return providerInfo{}, false
+		}
+	}
+	var found providerInfo
+	ok := false
+	for _, m := range models {
+		if m.URL == "" || !match(m) {
+			continue
+		}
+		p := providerInfo{URL: strings.TrimRight(m.URL, "/"), Address: m.Provider, InputPrice: m.InputPrice, OutputPrice: m.OutputPrice, Verifiability: m.Verifiability}
+		if m.ID == modelID {
+			return p, true
+		}
+		if !ok {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
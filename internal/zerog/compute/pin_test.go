@@ -0,0 +1,127 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+// chatServer answers chat completions with jobID and lists itself as
+// 0xdiscovered serving test-model, followed by any also entries.
+func chatServer(t *testing.T, jobID string, also ...map[string]string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/proxy/chat/completions":
+			json.NewEncoder(w).Encode(chatResponse{
+				ID:      jobID,
+				Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "ok"}}},
+				Model:   "test-model",
+			})
+		case "/api/services/list":
+			json.NewEncoder(w).Encode(append([]map[string]string{{
+				"providerAddress": "0xdiscovered",
+				"url":             srv.URL,
+				"model":           "test-model",
+			}}, also...))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSubmitJob_PinnedProviderURL(t *testing.T) {
+	pinned := chatServer(t, "job-pinned")
+	discovered := chatServer(t, "job-discovered",
+		map[string]string{"providerAddress": "0xpinned", "url": pinned.URL + "/", "model": "test-model"})
+
+	b := newTestBroker(t, &zgtest.MockBackend{}, discovered.URL)
+	jobID, err := b.SubmitJob(context.Background(), JobRequest{
+		ModelID:     "test-model",
+		Input:       "hi",
+		ProviderURL: pinned.URL,
+	})
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	if jobID != "job-pinned" {
+		t.Errorf("job went to %q, want pinned provider", jobID)
+	}
+}
+
+func TestSubmitJob_PinnedProviderUnreachableFallsBack(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL := dead.URL
+	dead.Close()
+	discovered := chatServer(t, "job-discovered",
+		map[string]string{"providerAddress": "0xdead", "url": deadURL, "model": "other-model"})
+
+	b := newTestBroker(t, &zgtest.MockBackend{}, discovered.URL)
+	jobID, err := b.SubmitJob(context.Background(), JobRequest{
+		ModelID:     "test-model",
+		Input:       "hi",
+		ProviderURL: deadURL,
+	})
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	if jobID != "job-discovered" {
+		t.Errorf("job went to %q, want discovery fallback", jobID)
+	}
+}
+
+func TestEstimateCost_PinnedProviderAddress(t *testing.T) {
+	srv := chatServer(t, "job-1")
+
+	b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL)
+	est, err := b.EstimateCost(context.Background(), JobRequest{
+		ModelID:         "test-model",
+		Input:           "hi",
+		ProviderAddress: "0xDISCOVERED",
+	})
+	if err != nil {
+		t.Fatalf("EstimateCost: %v", err)
+	}
+	if est.Provider != srv.URL {
+		t.Errorf("provider = %q, want %q", est.Provider, srv.URL)
+	}
+}
+
+func TestSubmitJob_RefusesUnlistedProviderURL(t *testing.T) {
+	discovered := chatServer(t, "job-discovered")
+	var hits atomic.Int32
+	rogue := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	t.Cleanup(rogue.Close)
+
+	b := newTestBroker(t, &zgtest.MockBackend{}, discovered.URL)
+	tests := []struct {
+		name string
+		req  JobRequest
+	}{
+		{"url only", JobRequest{ModelID: "test-model", Input: "hi", ProviderURL: rogue.URL}},
+		{"url not the listed one for address", JobRequest{
+			ModelID: "test-model", Input: "hi", ProviderAddress: "0xdiscovered", ProviderURL: rogue.URL,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := b.SubmitJob(context.Background(), tt.req); !errors.Is(err, ErrUnlistedProvider) {
+				t.Fatalf("SubmitJob error = %v, want ErrUnlistedProvider", err)
+			}
+		})
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("unlisted URL received %d requests", n)
+	}
+}
@@ -0,0 +1,35 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SubmitRaw is an escape hatch for provider features JobRequest does not
+// model, such as tools or logit_bias. It resolves a provider for modelID,
+// attaches session auth, and POSTs body to the chat completions endpoint
+// unchanged. None of JobRequest's checks apply: input limits, context
+// windows, and model defaults are skipped, and the body's "model" field is
+// not compared with modelID. The caller owns the body's correctness.
+//
+// The result is tracked like a SubmitJob result, so GetResult and
+// CancelJob accept its JobID until one of them collects it.
+func (b *broker) SubmitRaw(ctx context.Context, modelID string, body json.RawMessage) (*JobResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("compute: context cancelled before submit: %w", err)
+	}
+	if modelID == "" {
+		return nil, fmt.Errorf("compute: model ID is empty: %w", ErrInvalidModel)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("compute: raw request body is not valid JSON: %w", ErrInvalidInput)
+	}
+
+	provider, err := b.resolveProvider(ctx, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("compute: resolve provider for %s: %w", modelID, err)
+	}
+
+	return b.sendTracked(ctx, provider, body)
+}
@@ -0,0 +1,63 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestSubmitRaw_PostsBodyVerbatim(t *testing.T) {
+	raw := json.RawMessage(`{"model":"test-model","messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function","function":{"name":"lookup"}}],"logit_bias":{"50256":-100}}`)
+
+	var got []byte
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/proxy/chat/completions":
+			got, _ = io.ReadAll(r.Body)
+			json.NewEncoder(w).Encode(chatResponse{
+				ID:      "raw-1",
+				Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "called lookup"}, FinishReason: "tool_calls"}},
+				Usage:   chatUsage{TotalTokens: 12},
+				Model:   "test-model",
+			})
+		case "/api/services/list":
+			json.NewEncoder(w).Encode([]map[string]string{
+				{"providerAddress": "0xabc", "name": "Test", "serviceType": "chatbot", "url": srv.URL, "model": "test-model"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	b := newTestBroker(t, &zgtest.MockBackend{}, srv.URL)
+
+	result, err := b.SubmitRaw(context.Background(), "test-model", raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != string(raw) {
+		t.Errorf("provider received %s, want the body unchanged", got)
+	}
+	if result.JobID != "raw-1" || result.Output != "called lookup" || result.FinishReason != "tool_calls" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if cached, err := b.GetResult(context.Background(), "raw-1"); err != nil || cached.Output != result.Output {
+		t.Errorf("GetResult(raw-1) = %+v, %v", cached, err)
+	}
+}
+
+func TestSubmitRaw_RejectsInvalidJSON(t *testing.T) {
+	b := newTestBroker(t, &zgtest.MockBackend{}, "http://unused.invalid")
+	_, err := b.SubmitRaw(context.Background(), "test-model", json.RawMessage(`{"model":`))
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+}
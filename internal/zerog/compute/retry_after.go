@@ -0,0 +1,77 @@
+package compute
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRateLimitRetries bounds how many times a 429 with Retry-After is
+// retried for a single request.
+const maxRateLimitRetries = 2
+
+// defaultMaxRetryAfter caps a provider's Retry-After wait when
+// BrokerConfig.MaxRetryAfter is zero.
+const defaultMaxRetryAfter = 30 * time.Second
+
+// doWithRateLimitRetry executes the request via doWithAuthRetry and, when
+// the provider answers 429 with a Retry-After header, waits the requested
+// time (capped by cfg.MaxRetryAfter) and retries. Responses without
+// Retry-After are returned as-is for the caller to classify.
+func (b *broker) doWithRateLimitRetry(ctx context.Context, req *http.Request, body []byte, providerAddr string) (*http.Response, error) {
+	resp, err := b.doWithAuthRetry(ctx, req, body, providerAddr)
+	for attempt := 0; err == nil && attempt < maxRateLimitRetries; attempt++ {
+		if resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+		wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), b.clock.Now())
+		if !ok {
+			return resp, nil
+		}
+		if wait > b.cfg.MaxRetryAfter {
+			wait = b.cfg.MaxRetryAfter
+		}
+		resp.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("compute: context cancelled waiting out rate limit: %w", ctx.Err())
+		case <-b.clock.After(wait):
+		}
+
+		retryReq, reqErr := http.NewRequestWithContext(ctx, req.Method, req.URL.String(), bytes.NewReader(body))
+		if reqErr != nil {
+			return nil, fmt.Errorf("compute: create rate-limit retry request: %w", reqErr)
+		}
+		retryReq.Header = req.Header.Clone()
+		resp, err = b.doWithAuthRetry(ctx, retryReq, body, providerAddr)
+	}
+	return resp, err
+}
+
+// parseRetryAfter reads a Retry-After value given as delay-seconds or an
+// HTTP date. Past dates yield zero.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
@@ -0,0 +1,106 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"-1", 0, false},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSubmitJob_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/proxy/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(chatResponse{
+			ID:      "job-ok",
+			Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "ok"}}},
+		})
+	}))
+	defer srv.Close()
+
+	clk := clock.NewFake(time.Now())
+	b := NewBroker(BrokerConfig{Endpoint: srv.URL, Clock: clk}, &zgtest.MockBackend{}, nil)
+
+	type submitResult struct {
+		id  string
+		err error
+	}
+	done := make(chan submitResult, 1)
+	go func() {
+		id, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "m", Input: "hi"})
+		done <- submitResult{id, err}
+	}()
+
+	clk.BlockUntil(1)
+	if calls.Load() != 1 {
+		t.Fatalf("expected no retry before Retry-After elapses, got %d calls", calls.Load())
+	}
+	clk.Advance(3 * time.Second)
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("SubmitJob: %v", res.err)
+	}
+	if res.id != "job-ok" || calls.Load() != 2 {
+		t.Errorf("id=%q calls=%d, want job-ok after 2 calls", res.id, calls.Load())
+	}
+}
+
+func TestSubmitJob_RateLimitedWithoutRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/proxy/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	b := NewBroker(BrokerConfig{Endpoint: srv.URL}, &zgtest.MockBackend{}, nil)
+	_, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "m", Input: "hi"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single call, got %d", calls.Load())
+	}
+}
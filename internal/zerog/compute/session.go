@@ -56,20 +56,24 @@ type sessionManager struct {
 	cachedToken    string
 	cachedProvider string
 	tokenExpiry    time.Time
-	setupDone      map[string]bool // provider → setup complete
+	setupDone      map[string]bool          // provider → setup complete
+	buildToken     AuthTokenBuilder         // nil: DefaultAuthToken, skew-corrected
+	clockOffsets   map[string]time.Duration // provider → its clock minus ours
 }
 
-func newSessionManager(key *ecdsa.PrivateKey, backend zerog.ChainBackend, chainID int64) *sessionManager {
+func newSessionManager(key *ecdsa.PrivateKey, backend zerog.ChainBackend, chainID int64, build AuthTokenBuilder) *sessionManager {
 	ledgerAddr := common.HexToAddress(ledgerManagerAddress)
 	servingAddr := common.HexToAddress(inferenceServingAddr)
 
 	return &sessionManager{
-		key:       key,
-		backend:   backend,
-		chainID:   chainID,
-		ledger:    bind.NewBoundContract(ledgerAddr, ledgerABI, backend, backend, backend),
-		serving:   bind.NewBoundContract(servingAddr, servingSessionABI, backend, backend, backend),
-		setupDone: make(map[string]bool),
+		key:          key,
+		backend:      backend,
+		chainID:      chainID,
+		ledger:       bind.NewBoundContract(ledgerAddr, ledgerABI, backend, backend, backend),
+		serving:      bind.NewBoundContract(servingAddr, servingSessionABI, backend, backend, backend),
+		setupDone:    make(map[string]bool),
+		buildToken:   build,
+		clockOffsets: make(map[string]time.Duration),
 	}
 }
 
@@ -81,6 +85,27 @@ func (s *sessionManager) invalidate() {
 	s.tokenExpiry = time.Time{}
 }
 
+// setClockOffset records how far a provider's clock is ahead of ours
+// (negative when behind). Tokens built by DefaultAuthToken for that
+// provider afterwards are timestamped in its time; other providers and
+// custom builders are unaffected.
+func (s *sessionManager) setClockOffset(providerAddress string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clockOffsets[providerAddress] = d
+}
+
+// currentToken returns the cached auth token if one is still valid, without
+// touching the chain. Returns "" when no usable token is cached.
+func (s *sessionManager) currentToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Now().After(s.tokenExpiry) {
+		return ""
+	}
+	return s.cachedToken
+}
+
 // EnsureSession creates the on-chain account and funds if needed, then returns
 // a valid auth token for the given provider.
 func (s *sessionManager) EnsureSession(ctx context.Context, providerAddress string) (string, error) {
@@ -105,7 +130,13 @@ func (s *sessionManager) EnsureSession(ctx context.Context, providerAddress stri
 		}
 	}
 
-	token, err := s.buildSessionToken(providerAddress)
+	var token string
+	var err error
+	if s.buildToken != nil {
+		token, err = s.buildToken(s.key, providerAddress)
+	} else {
+		token, err = authTokenAt(s.key, providerAddress, time.Now().Add(s.clockOffsets[providerAddress]))
+	}
 	if err != nil {
 		return "", err
 	}
@@ -248,12 +279,22 @@ func (s *sessionManager) ensureAcknowledged(ctx context.Context, userAddr, provi
 	return nil
 }
 
-// buildSessionToken creates a signed ephemeral session token matching
+// AuthTokenBuilder creates the bearer token sent to a provider, identified
+// by its on-chain address. Swap it to follow provider token-format changes.
+type AuthTokenBuilder func(key *ecdsa.PrivateKey, providerAddress string) (string, error)
+
+// DefaultAuthToken creates a signed ephemeral session token matching
 // the 0G TypeScript SDK format exactly.
 // Format: app-sk-<base64(JSON_message|EIP191_signature)>
-func (s *sessionManager) buildSessionToken(providerAddress string) (string, error) {
-	userAddr := zerog.AddressFromKey(s.key)
-	now := time.Now().UnixMilli()
+func DefaultAuthToken(key *ecdsa.PrivateKey, providerAddress string) (string, error) {
+	return authTokenAt(key, providerAddress, time.Now())
+}
+
+// authTokenAt builds a DefaultAuthToken timestamped at the given time, so
+// the session manager can correct for provider clock skew.
+func authTokenAt(key *ecdsa.PrivateKey, providerAddress string, at time.Time) (string, error) {
+	userAddr := zerog.AddressFromKey(key)
+	now := at.UnixMilli()
 
 	nonce, err := generateNonce()
 	if err != nil {
@@ -281,7 +322,7 @@ func (s *sessionManager) buildSessionToken(providerAddress string) (string, erro
 	// Sign using EIP-191 personal_sign: prefix + hash
 	// ethers.js signMessage does: sign(keccak256("\x19Ethereum Signed Message:\n32" + hash))
 	prefixedHash := signHash(messageHash)
-	sig, err := crypto.Sign(prefixedHash, s.key)
+	sig, err := crypto.Sign(prefixedHash, key)
 	if err != nil {
 		return "", fmt.Errorf("sign session token: %w", err)
 	}
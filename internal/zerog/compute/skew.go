@@ -0,0 +1,74 @@
+package compute
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// DefaultClockSkewTolerance is the skew tolerance used when
+// BrokerConfig.ClockSkewTolerance is zero. It covers the one-second
+// resolution of the Date header.
+const DefaultClockSkewTolerance = 2 * time.Second
+
+// maxRejectionBody bounds how much of a 401 body is inspected for a
+// timestamp complaint.
+const maxRejectionBody = 4 << 10
+
+// timestampHints are lowercase fragments providers use when rejecting a
+// token for its timestamp rather than its signature. A bare "expired" is
+// not one: tokens also expire in the ordinary way, which a clock offset
+// would not fix.
+var timestampHints = [][]byte{
+	[]byte("timestamp"),
+	[]byte("clock"),
+	[]byte("skew"),
+	[]byte("future"),
+}
+
+// isTimestampRejection reports whether a 401 body blames the token time.
+func isTimestampRejection(body []byte) bool {
+	lower := bytes.ToLower(body)
+	for _, hint := range timestampHints {
+		if bytes.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// serverClockOffset returns how far the server's clock, per the response
+// Date header, is ahead of now. ok is false when the header is missing or
+// malformed.
+func serverClockOffset(resp *http.Response, now time.Time) (offset time.Duration, ok bool) {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+	return date.Sub(now), true
+}
+
+// syncClockOnRejection inspects a 401 response from the provider at
+// providerAddr and, when it rejected the token timestamp and its clock is
+// outside the configured tolerance, shifts future token timestamps for that
+// provider to its clock. The response body is consumed.
+func (b *broker) syncClockOnRejection(resp *http.Response, providerAddr string) {
+	if b.cfg.ClockSkewTolerance < 0 {
+		return
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxRejectionBody))
+	if !isTimestampRejection(body) {
+		return
+	}
+	offset, ok := serverClockOffset(resp, time.Now())
+	if !ok || offset.Abs() <= b.cfg.ClockSkewTolerance {
+		return
+	}
+	slog.Warn("provider rejected token timestamp; adjusting for clock skew",
+		"provider", providerAddr,
+		"offset", offset.Round(time.Second),
+		"tolerance", b.cfg.ClockSkewTolerance)
+	b.session.setClockOffset(providerAddr, offset)
+}
@@ -0,0 +1,145 @@
+package compute
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+// tokenTime decodes the timestamp signed into a DefaultAuthToken header.
+func tokenTime(t *testing.T, header string) time.Time {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "Bearer app-sk-"))
+	if err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+	msg, _, _ := strings.Cut(string(raw), "|")
+	var tok sessionToken
+	if err := json.Unmarshal([]byte(msg), &tok); err != nil {
+		t.Fatalf("unmarshal token: %v", err)
+	}
+	return time.UnixMilli(tok.Timestamp)
+}
+
+// newSkewedServer simulates a provider whose clock runs ahead by skew and
+// which rejects tokens more than 30s off its own time.
+func newSkewedServer(t *testing.T, skew time.Duration, calls *int) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/proxy/chat/completions":
+			*calls++
+			serverNow := time.Now().Add(skew)
+			if d := serverNow.Sub(tokenTime(t, r.Header.Get("Authorization"))); d.Abs() > 30*time.Second {
+				w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"token timestamp expired"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(chatResponse{
+				ID:      "job-skew",
+				Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "ok"}}},
+			})
+		case "/api/services/list":
+			json.NewEncoder(w).Encode([]map[string]string{
+				{"providerAddress": "0xabc", "name": "Test", "url": srv.URL, "model": "test-model"},
+			})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSubmitJob_ClockSkewRetry(t *testing.T) {
+	calls := 0
+	srv := newSkewedServer(t, time.Hour, &calls)
+
+	key, _ := crypto.GenerateKey()
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               srv.URL,
+	}, &zgtest.MockBackend{}, key)
+
+	jobID, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "test-model", Input: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if jobID != "job-skew" {
+		t.Errorf("expected job-skew, got %s", jobID)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls (skew rejection + adjusted retry), got %d", calls)
+	}
+}
+
+func TestSubmitJob_ClockSkewCorrectionDisabled(t *testing.T) {
+	calls := 0
+	srv := newSkewedServer(t, time.Hour, &calls)
+
+	key, _ := crypto.GenerateKey()
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               srv.URL,
+		ClockSkewTolerance:     -1,
+	}, &zgtest.MockBackend{}, key)
+
+	if _, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "test-model", Input: "hi"}); err == nil {
+		t.Fatal("expected error when skew correction is disabled")
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls (rejection + unadjusted retry), got %d", calls)
+	}
+}
+
+func TestClockOffset_PerProvider(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	s := newSessionManager(key, &zgtest.MockBackend{}, 16602, nil)
+	s.setClockOffset("0xskewed", time.Hour)
+
+	token, err := s.EnsureSession(context.Background(), "0xother")
+	if err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	if d := time.Since(tokenTime(t, "Bearer "+token)); d.Abs() > time.Minute {
+		t.Errorf("other provider's token is off by %v; skew leaked across providers", d)
+	}
+
+	s.invalidate()
+	token, err = s.EnsureSession(context.Background(), "0xskewed")
+	if err != nil {
+		t.Fatalf("EnsureSession: %v", err)
+	}
+	if d := time.Until(tokenTime(t, "Bearer "+token)); (d - time.Hour).Abs() > time.Minute {
+		t.Errorf("skewed provider's token is %v ahead, want about 1h", d)
+	}
+}
+
+func TestIsTimestampRejection(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{`{"error":"token timestamp expired"}`, true},
+		{`{"error":"Token Expired"}`, false},
+		{`timestamp is in the future`, true},
+		{`{"error":"invalid signature"}`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := isTimestampRejection([]byte(tt.body)); got != tt.want {
+			t.Errorf("isTimestampRejection(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package compute
+
+// staleListing returns the expired cached listing when it is within
+// MaxStaleModels of expiry, flagged Stale, and notifies the observer.
+// cause is the discovery error that forced the fallback.
+func (b *broker) staleListing(cause error) (ListModelsResult, bool) {
+	if b.cfg.MaxStaleModels <= 0 {
+		return ListModelsResult{}, false
+	}
+
+	b.mu.RLock()
+	now := b.clock.Now()
+	if b.models == nil || now.After(b.modelsTTL.Add(b.cfg.MaxStaleModels)) {
+		b.mu.RUnlock()
+		return ListModelsResult{}, false
+	}
+	res := newListModelsResult(append([]Model(nil), b.models...), b.modelsTotal)
+	res.Stale = true
+	res.CacheAge = now.Sub(b.modelsTTL.Add(-ModelCacheTTL))
+	b.mu.RUnlock()
+
+	if o, ok := b.cfg.Observer.(StaleModelsObserver); ok {
+		o.ObserveStaleModels(res.CacheAge, cause)
+	}
+	return res, true
+}
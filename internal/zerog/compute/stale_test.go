@@ -0,0 +1,83 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+type staleRecorder struct {
+	age   time.Duration
+	cause error
+}
+
+func (r *staleRecorder) ObserveProviderLatency(string, time.Duration, time.Duration) {}
+
+func (r *staleRecorder) ObserveStaleModels(age time.Duration, cause error) {
+	r.age, r.cause = age, cause
+}
+
+func TestListModels_StaleAfterChainError(t *testing.T) {
+	rpcDown := errors.New("rpc down")
+	var fail atomic.Bool
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			if fail.Load() {
+				return nil, rpcDown
+			}
+			return encodedAllServices([]serviceTestData{
+				{Provider: common.HexToAddress("0xabc"), Name: "Qwen", URL: "https://p1.example.com", Model: "qwen-2.5-7b"},
+			}, 1), nil
+		},
+	}
+	clk := clock.NewFake(time.Unix(1771632000, 0))
+	obs := &staleRecorder{}
+	key, _ := crypto.GenerateKey()
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Clock:                  clk,
+		Observer:               obs,
+		MaxStaleModels:         10 * time.Minute,
+	}, backend, key)
+	ctx := context.Background()
+
+	fresh, err := b.ListModelsWithTotal(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.Stale || fresh.CacheAge != 0 {
+		t.Errorf("fresh listing flagged stale: %+v", fresh)
+	}
+
+	fail.Store(true)
+	clk.Advance(ModelCacheTTL + time.Minute)
+	stale, err := b.ListModelsWithTotal(ctx)
+	if err != nil {
+		t.Fatalf("expected the stale listing, got %v", err)
+	}
+	if !stale.Stale || stale.CacheAge != ModelCacheTTL+time.Minute {
+		t.Errorf("expected stale listing aged %v, got %+v", ModelCacheTTL+time.Minute, stale)
+	}
+	if len(stale.Models) != 1 {
+		t.Errorf("expected the cached model, got %+v", stale.Models)
+	}
+	if obs.age != stale.CacheAge || !errors.Is(obs.cause, rpcDown) {
+		t.Errorf("observer saw age %v, cause %v", obs.age, obs.cause)
+	}
+
+	// Past MaxStaleModels the discovery error surfaces.
+	clk.Advance(10 * time.Minute)
+	if _, err := b.ListModelsWithTotal(ctx); !errors.Is(err, rpcDown) {
+		t.Errorf("expected the chain error once too stale, got %v", err)
+	}
+}
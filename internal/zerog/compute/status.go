@@ -0,0 +1,36 @@
+package compute
+
+import "sync"
+
+// StatusCallback is told each time a job moves to a new JobStatus:
+// pending when SubmitJob accepts the request, running once it is sent to
+// a provider, then completed or failed. It runs on the submitting
+// goroutine and should return quickly.
+type StatusCallback func(status JobStatus)
+
+// newStatusReporter wraps cb so each status is reported at most once in
+// a row and nothing is reported after a terminal status. It returns nil
+// for a nil cb.
+func newStatusReporter(cb StatusCallback) StatusCallback {
+	if cb == nil {
+		return nil
+	}
+	var mu sync.Mutex
+	var last JobStatus
+	return func(status JobStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+		if status == last || last == JobStatusCompleted || last == JobStatusFailed {
+			return
+		}
+		last = status
+		cb(status)
+	}
+}
+
+// notify reports status to the request's StatusCallback, if any.
+func (r JobRequest) notify(status JobStatus) {
+	if r.StatusCallback != nil {
+		r.StatusCallback(status)
+	}
+}
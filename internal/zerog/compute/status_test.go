@@ -0,0 +1,131 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+// statusRecorder collects StatusCallback transitions for polling.
+type statusRecorder struct {
+	mu       sync.Mutex
+	statuses []JobStatus
+}
+
+func (r *statusRecorder) record(s JobStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, s)
+}
+
+func (r *statusRecorder) snapshot() []JobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.statuses)
+}
+
+// waitFor polls until the latest recorded status is want.
+func (r *statusRecorder) waitFor(t *testing.T, want JobStatus) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if got := r.snapshot(); len(got) > 0 && got[len(got)-1] == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for status %s, have %v", want, r.snapshot())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newStatusBroker(t *testing.T, status int, release <-chan struct{}) ComputeBroker {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/proxy/chat/completions":
+			<-release
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			json.NewEncoder(w).Encode(chatResponse{
+				ID:      "job-status",
+				Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "ok"}}},
+			})
+		case "/api/services/list":
+			json.NewEncoder(w).Encode([]map[string]string{{"url": srv.URL, "model": "test-model"}})
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	key, _ := crypto.GenerateKey()
+	return NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               srv.URL,
+	}, &zgtest.MockBackend{}, key)
+}
+
+func TestSubmitJob_StatusTransitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus []JobStatus
+	}{
+		{"completed", http.StatusOK, []JobStatus{JobStatusPending, JobStatusRunning, JobStatusCompleted}},
+		{"failed", http.StatusInternalServerError, []JobStatus{JobStatusPending, JobStatusRunning, JobStatusFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			b := newStatusBroker(t, tt.status, release)
+			rec := &statusRecorder{}
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := b.SubmitJob(context.Background(), JobRequest{
+					ModelID: "test-model", Input: "hi", StatusCallback: rec.record,
+				})
+				done <- err
+			}()
+
+			// The provider holds the request, so the job must be observed
+			// running before it finishes.
+			rec.waitFor(t, JobStatusRunning)
+			close(release)
+			err := <-done
+			if (err != nil) != (tt.status != http.StatusOK) {
+				t.Fatalf("unexpected error result: %v", err)
+			}
+			if got := rec.snapshot(); !slices.Equal(got, tt.wantStatus) {
+				t.Errorf("transitions = %v, want %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSubmitJob_StatusRejectedBeforeSend(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	b := newStatusBroker(t, http.StatusOK, release)
+	rec := &statusRecorder{}
+
+	if _, err := b.SubmitJob(context.Background(), JobRequest{StatusCallback: rec.record}); err == nil {
+		t.Fatal("expected validation error for empty request")
+	}
+	want := []JobStatus{JobStatusPending, JobStatusFailed}
+	if got := rec.snapshot(); !slices.Equal(got, want) {
+		t.Errorf("transitions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package compute
+
+import (
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// Tokenizer counts tokens the way a model's provider bills them. Plug in a
+// model-specific BPE implementation via BrokerConfig.Tokenizer for exact
+// counts.
+type Tokenizer interface {
+	CountTokens(model, text string) (int, error)
+}
+
+// approxCharsPerToken is the usual characters-per-token ratio for English
+// text with GPT-style BPE vocabularies.
+const approxCharsPerToken = 4
+
+// ApproxTokenizer estimates one token per four characters for any model.
+// It is the broker's default when no Tokenizer is configured.
+type ApproxTokenizer struct{}
+
+// CountTokens returns ceil(characters / 4).
+func (ApproxTokenizer) CountTokens(_, text string) (int, error) {
+	n := utf8.RuneCountInString(text)
+	return (n + approxCharsPerToken - 1) / approxCharsPerToken, nil
+}
+
+// fitContextWindow checks that the input plus MaxTokens fits the model's
+// configured context window. When it does not, MaxTokens is clamped to the
+// remaining space if cfg.AutoClampTokens is set; otherwise, or when the
+// input alone fills the window, ErrContextOverflow is returned. Models
+// without a known window, and tokenizer failures, skip the check.
+func (b *broker) fitContextWindow(req JobRequest) (JobRequest, error) {
+	window, ok := b.cfg.ContextWindows[req.ModelID]
+	if !ok || window <= 0 {
+		return req, nil
+	}
+	n, err := b.tokenizer().CountTokens(req.ModelID, req.Input)
+	if err != nil {
+		return req, nil
+	}
+	if n >= window {
+		return req, fmt.Errorf("compute: input is %d tokens, model %s context window is %d: %w",
+			n, req.ModelID, window, ErrContextOverflow)
+	}
+	if req.MaxTokens == 0 || n+req.MaxTokens <= window {
+		return req, nil
+	}
+	if !b.cfg.AutoClampTokens {
+		return req, fmt.Errorf("compute: %d input tokens plus max_tokens %d exceeds model %s context window %d: %w",
+			n, req.MaxTokens, req.ModelID, window, ErrContextOverflow)
+	}
+	slog.Warn("clamping max_tokens to fit model context window",
+		"model", req.ModelID, "input_tokens", n, "requested", req.MaxTokens, "clamped", window-n)
+	req.MaxTokens = window - n
+	return req, nil
+}
@@ -0,0 +1,50 @@
+package compute
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxModelIDLength bounds model IDs; real IDs such as
+// "meta-llama/Llama-3.3-70B-Instruct" are far shorter.
+const maxModelIDLength = 256
+
+// DefaultMaxInputBytes is the input size cap used when
+// BrokerConfig.MaxInputBytes is zero.
+const DefaultMaxInputBytes = 1 << 20
+
+// validateJobRequest rejects requests that cannot succeed at any provider,
+// before provider discovery runs. Inputs longer than maxInputBytes are
+// rejected.
+func validateJobRequest(req JobRequest, maxInputBytes int) error {
+	if err := validateModelID(req.ModelID); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Input) == "" {
+		return fmt.Errorf("compute: input is empty: %w", ErrInvalidInput)
+	}
+	if len(req.Input) > maxInputBytes {
+		return fmt.Errorf("compute: input is %d bytes, exceeds limit of %d: %w", len(req.Input), maxInputBytes, ErrInvalidInput)
+	}
+	return nil
+}
+
+// validateModelID accepts letters, digits, and the separators used by
+// provider model names ('-', '_', '.', ':', '/', '@').
+func validateModelID(id string) error {
+	if id == "" {
+		return fmt.Errorf("compute: model ID is empty: %w", ErrInvalidModel)
+	}
+	if len(id) > maxModelIDLength {
+		return fmt.Errorf("compute: model ID exceeds %d characters: %w", maxModelIDLength, ErrInvalidModel)
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("-_.:/@", r):
+		default:
+			return fmt.Errorf("compute: model ID %q contains invalid character %q: %w", id, r, ErrInvalidModel)
+		}
+	}
+	return nil
+}
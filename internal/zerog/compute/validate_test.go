@@ -0,0 +1,83 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestValidateJobRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  JobRequest
+		want error
+	}{
+		{"valid", JobRequest{ModelID: "meta-llama/Llama-3.3-70B-Instruct", Input: "hi"}, nil},
+		{"valid tag", JobRequest{ModelID: "qwen2.5:7b@v1", Input: "hi"}, nil},
+		{"empty model", JobRequest{Input: "hi"}, ErrInvalidModel},
+		{"whitespace in model", JobRequest{ModelID: "bad model", Input: "hi"}, ErrInvalidModel},
+		{"control char in model", JobRequest{ModelID: "bad\nmodel", Input: "hi"}, ErrInvalidModel},
+		{"model too long", JobRequest{ModelID: strings.Repeat("m", maxModelIDLength+1), Input: "hi"}, ErrInvalidModel},
+		{"empty input", JobRequest{ModelID: "m", Input: "  "}, ErrInvalidInput},
+		{"input at limit", JobRequest{ModelID: "m", Input: strings.Repeat("a", 64)}, nil},
+		{"input over limit", JobRequest{ModelID: "m", Input: strings.Repeat("a", 65)}, ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJobRequest(tt.req, 64)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSubmitJob_RejectsInvalidModelBeforeDiscovery(t *testing.T) {
+	called := false
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			called = true
+			return nil, nil
+		},
+	}
+	b := newTestBroker(t, backend, "")
+
+	_, err := b.SubmitJob(context.Background(), JobRequest{Input: "hello"})
+	if !errors.Is(err, ErrInvalidModel) {
+		t.Fatalf("expected ErrInvalidModel, got %v", err)
+	}
+	if called {
+		t.Error("expected no provider discovery for invalid request")
+	}
+}
+
+func TestSubmitJob_RejectsOversizeInputBeforeHTTP(t *testing.T) {
+	called := false
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			called = true
+			return nil, nil
+		},
+	}
+	b := newTestBroker(t, backend, "")
+
+	input := strings.Repeat("a", DefaultMaxInputBytes+1)
+	_, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "m", Input: input})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+	if called {
+		t.Error("expected no provider discovery for oversize input")
+	}
+}
@@ -0,0 +1,102 @@
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func newVerifiabilityBroker(t *testing.T, services []serviceTestData, require string) ComputeBroker {
+	t.Helper()
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			return encodedAllServices(services, len(services)), nil
+		},
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+		Endpoint:               "http://fallback.invalid",
+		RequireVerifiability:   require,
+	}, backend, key)
+}
+
+func TestResolveProvider_RequireVerifiability(t *testing.T) {
+	var teeHit bool
+	tee := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		teeHit = true
+		json.NewEncoder(w).Encode(chatResponse{
+			ID:      "tee-job",
+			Choices: []chatChoice{{Message: chatMessage{Role: "assistant", Content: "ok"}}},
+		})
+	}))
+	defer tee.Close()
+
+	services := []serviceTestData{
+		{Provider: common.HexToAddress("0x01"), Name: "plain", URL: "http://plain.invalid", Model: "qwen"},
+		{Provider: common.HexToAddress("0x02"), Name: "tee", URL: tee.URL, Model: "qwen", Verifiability: "TeeML"},
+		{Provider: common.HexToAddress("0x03"), Name: "plain-only", URL: "http://plain.invalid", Model: "llama"},
+	}
+	b := newVerifiabilityBroker(t, services, "teeml").(*broker)
+
+	p, err := b.resolveProvider(context.Background(), "qwen")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.URL != tee.URL || p.Verifiability != "TeeML" {
+		t.Errorf("expected the TEE provider, got %+v", p)
+	}
+
+	jobID, err := b.SubmitJob(context.Background(), JobRequest{ModelID: "qwen", Input: "hi"})
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	if !teeHit {
+		t.Error("job was not routed to the TEE provider")
+	}
+	res, _ := b.GetResult(context.Background(), jobID)
+	if res.Verifiability != "TeeML" {
+		t.Errorf("expected result verifiability TeeML, got %q", res.Verifiability)
+	}
+
+	// A model served only by unverified providers fails rather than
+	// falling back to the endpoint.
+	if _, err := b.resolveProvider(context.Background(), "llama"); !errors.Is(err, ErrUnverifiedProvider) {
+		t.Errorf("expected ErrUnverifiedProvider, got %v", err)
+	}
+
+	// Pinning an unverified provider is refused too.
+	_, err = b.providerFor(context.Background(), JobRequest{ModelID: "qwen", ProviderAddress: common.HexToAddress("0x01").Hex()})
+	if !errors.Is(err, ErrUnverifiedProvider) {
+		t.Errorf("expected pinned unverified provider to be refused, got %v", err)
+	}
+}
+
+func TestResolveProvider_NoRequirement(t *testing.T) {
+	services := []serviceTestData{
+		{Provider: common.HexToAddress("0x01"), Name: "plain", URL: "http://plain.invalid", Model: "qwen"},
+		{Provider: common.HexToAddress("0x02"), Name: "tee", URL: "http://tee.invalid", Model: "qwen", Verifiability: "TeeML"},
+	}
+	b := newVerifiabilityBroker(t, services, "").(*broker)
+
+	p, err := b.resolveProvider(context.Background(), "qwen")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.URL != "http://plain.invalid" {
+		t.Errorf("expected the first listed provider, got %+v", p)
+	}
+}
@@ -0,0 +1,25 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+)
+
+// WarmCache refreshes the model cache without waiting for it to expire.
+// Concurrent calls are serialized; readers keep the previous listing until
+// the new one is stored. On failure the existing cache is left as is.
+func (b *broker) WarmCache(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("compute: context cancelled: %w", err)
+	}
+
+	b.warmMu.Lock()
+	defer b.warmMu.Unlock()
+
+	models, total, err := b.list(ctx)
+	if err != nil {
+		return fmt.Errorf("compute: warm model cache: %w", err)
+	}
+	b.cacheListing(models, total)
+	return nil
+}
@@ -0,0 +1,61 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestWarmCache_ServesListModelsFromCache(t *testing.T) {
+	var calls atomic.Int32
+	var fail atomic.Bool
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			calls.Add(1)
+			if fail.Load() {
+				return nil, errors.New("rpc down")
+			}
+			return encodedAllServices([]serviceTestData{
+				{Provider: common.HexToAddress("0xabc"), Name: "Qwen", URL: "https://p1.example.com", Model: "qwen-2.5-7b"},
+			}, 1), nil
+		},
+	}
+	key, _ := crypto.GenerateKey()
+	b := NewBroker(BrokerConfig{
+		ChainID:                16602,
+		ServingContractAddress: "0x0000000000000000000000000000000000000001",
+	}, backend, key)
+
+	if err := b.WarmCache(context.Background()); err != nil {
+		t.Fatalf("WarmCache: %v", err)
+	}
+	models, err := b.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if len(models) != 1 || models[0].ID != "qwen-2.5-7b" {
+		t.Fatalf("unexpected models %+v", models)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected ListModels to use the warmed cache, got %d chain calls", n)
+	}
+
+	// A failed re-warm keeps the cached listing.
+	fail.Store(true)
+	if err := b.WarmCache(context.Background()); err == nil {
+		t.Fatal("expected WarmCache to report the chain failure")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected WarmCache to bypass the cache, got %d chain calls", n)
+	}
+	if _, err := b.ListModels(context.Background()); err != nil {
+		t.Errorf("expected the earlier listing to survive a failed warm: %v", err)
+	}
+}
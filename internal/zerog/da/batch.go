@@ -0,0 +1,24 @@
+package da
+
+import (
+	"context"
+	"fmt"
+)
+
+// PublishBatch publishes events in order and stops at the first failure,
+// including cancellation during a retry backoff. It always returns the
+// submissions accepted so far, in event order, so callers can record what
+// reached DA. On failure the error wraps both ErrBatchIncomplete and the
+// underlying cause.
+func PublishBatch(ctx context.Context, pub AuditPublisher, events []AuditEvent) ([]Submission, error) {
+	subs := make([]Submission, 0, len(events))
+	for i, event := range events {
+		sub, err := pub.PublishWithReceipt(ctx, event)
+		if err != nil {
+			return subs, fmt.Errorf("da: batch stopped at event %d of %d with %d published: %w: %w",
+				i+1, len(events), len(subs), ErrBatchIncomplete, err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
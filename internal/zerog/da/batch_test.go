@@ -0,0 +1,100 @@
+package da
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestPublishBatch_Success(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &zgtest.MockBackend{
+		ReceiptFn: func(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+			return daReceipt(), nil
+		},
+	}
+	p := NewPublisher(PublisherConfig{ChainID: 16602, DAContractAddress: "0xtest"}, backend, zerog.NewLocalSigner(key))
+
+	events := []AuditEvent{
+		{Type: EventTypeTaskReceived, TaskID: "t1", Timestamp: time.Now()},
+		{Type: EventTypeJobCompleted, TaskID: "t1", Timestamp: time.Now()},
+	}
+	subs, err := PublishBatch(context.Background(), p, events)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(subs) != 2 || subs[0].EventType != EventTypeTaskReceived || subs[1].EventType != EventTypeJobCompleted {
+		t.Errorf("unexpected submissions: %+v", subs)
+	}
+}
+
+func TestPublishBatch_CancelledMidRetry(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sends atomic.Int32
+	backend := &zgtest.MockBackend{
+		SendTxFn: func(_ context.Context, _ *types.Transaction) error {
+			if sends.Add(1) > 1 {
+				return errors.New("temporary failure")
+			}
+			return nil
+		},
+		ReceiptFn: func(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+			return daReceipt(), nil
+		},
+	}
+	clk := clock.NewFake(time.Now())
+	p := NewPublisher(PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xtest",
+		MaxRetries:        3,
+		Clock:             clk,
+	}, backend, zerog.NewLocalSigner(key))
+
+	events := []AuditEvent{
+		{Type: EventTypeTaskReceived, TaskID: "t1", Timestamp: time.Now()},
+		{Type: EventTypeJobCompleted, TaskID: "t1", Timestamp: time.Now()},
+		{Type: EventTypeResultReport, TaskID: "t1", Timestamp: time.Now()},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	type batchResult struct {
+		subs []Submission
+		err  error
+	}
+	done := make(chan batchResult, 1)
+	go func() {
+		subs, err := PublishBatch(ctx, p, events)
+		done <- batchResult{subs, err}
+	}()
+
+	// The second event fails and enters backoff; cancel while it waits.
+	clk.BlockUntil(1)
+	cancel()
+
+	res := <-done
+	if !errors.Is(res.err, ErrBatchIncomplete) {
+		t.Fatalf("expected ErrBatchIncomplete, got %v", res.err)
+	}
+	if !errors.Is(res.err, context.Canceled) {
+		t.Errorf("expected context.Canceled in error chain, got %v", res.err)
+	}
+	if len(res.subs) != 1 || res.subs[0].EventType != EventTypeTaskReceived {
+		t.Errorf("expected only the first event published, got %+v", res.subs)
+	}
+}
@@ -0,0 +1,50 @@
+package da
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// gzipMagic prefixes every gzip stream. Serialized events are JSON objects
+// starting with '{', so the prefix unambiguously marks a compressed blob.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compressBlob gzips data, returning the original bytes when compression
+// does not reduce the size.
+func compressBlob(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, fmt.Errorf("da: gzip writer: %w", err)
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("da: gzip write: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("da: gzip close: %w", err)
+	}
+	if buf.Len() >= len(data) {
+		return data, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBlob returns the serialized event JSON from a DA blob, inflating it
+// if it was compressed. Uncompressed blobs are returned unchanged.
+func DecodeBlob(blob []byte) ([]byte, error) {
+	if !bytes.HasPrefix(blob, gzipMagic) {
+		return blob, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, fmt.Errorf("da: open compressed blob: %w", err)
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("da: inflate blob: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,125 @@
+package da
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestCompressBlob_RealisticBatch(t *testing.T) {
+	base := time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC)
+	var batch []AuditEvent
+	for i := 0; i < 50; i++ {
+		batch = append(batch, AuditEvent{
+			Type:       EventTypeJobCompleted,
+			AgentID:    "inference-agent-1",
+			TaskID:     fmt.Sprintf("task-%04d", i),
+			JobID:      fmt.Sprintf("chatcmpl-%08d", i),
+			OutputHash: fmt.Sprintf("%064x", i),
+			StorageRef: fmt.Sprintf("%064x", i*7),
+			INFTRef:    fmt.Sprint(100 + i),
+			Details:    map[string]string{"finish_reason": "stop"},
+			Namespace:  "inference-audit",
+			Timestamp:  base.Add(time.Duration(i) * time.Second),
+		})
+	}
+	raw, err := json.Marshal(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compressed, err := compressBlob(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(raw) {
+		t.Fatalf("expected compression to shrink batch: %d -> %d bytes", len(raw), len(compressed))
+	}
+	t.Logf("batch of %d events: %d -> %d bytes (%.0f%% saved)",
+		len(batch), len(raw), len(compressed), 100*(1-float64(len(compressed))/float64(len(raw))))
+
+	decoded, err := DecodeBlob(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, raw) {
+		t.Error("decoded blob does not match original")
+	}
+}
+
+func TestCompressBlob_SkipsWhenLarger(t *testing.T) {
+	raw := []byte(`{"type":"x"}`)
+	out, err := compressBlob(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, raw) {
+		t.Errorf("expected tiny blob to stay uncompressed, got %x", out)
+	}
+	decoded, err := DecodeBlob(out)
+	if err != nil || !bytes.Equal(decoded, raw) {
+		t.Errorf("expected uncompressed blob passed through, got %q, %v", decoded, err)
+	}
+}
+
+func TestPublish_Compressed(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent *types.Transaction
+	backend := &zgtest.MockBackend{
+		SendTxFn: func(_ context.Context, tx *types.Transaction) error {
+			sent = tx
+			return nil
+		},
+		ReceiptFn: func(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+			return daReceipt(), nil
+		},
+	}
+	p := NewPublisher(PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
+		Compress:          true,
+	}, backend, zerog.NewLocalSigner(key))
+
+	event := AuditEvent{
+		Type:    EventTypeJobCompleted,
+		AgentID: "agent-1",
+		Details: map[string]string{"note": string(bytes.Repeat([]byte("repetitive "), 40))},
+	}
+	if _, err := p.Publish(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args, err := daABI.Methods["submitOriginalData"].Inputs.Unpack(sent.Data()[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := args[0].([]byte)
+	if !bytes.HasPrefix(blob, gzipMagic) {
+		t.Fatal("expected compressed blob")
+	}
+	data, err := DecodeBlob(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got AuditEvent
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.AgentID != "agent-1" || got.Details["note"] != event.Details["note"] {
+		t.Errorf("round-tripped event mismatch: %+v", got)
+	}
+}
@@ -0,0 +1,49 @@
+package da
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// SubmissionEstimate projects the size and cost of publishing a set of
+// events, without submitting anything.
+type SubmissionEstimate struct {
+	// Events is the number of blobs that would be submitted, one per event.
+	Events int `json:"events"`
+	// Bytes is the total payload size across those blobs, after
+	// compression when the publisher compresses.
+	Bytes int `json:"bytes"`
+	// Cost is Bytes times PublisherConfig.PricePerByte, in wei. Nil when no
+	// price is configured.
+	Cost *big.Int `json:"cost,omitempty"`
+}
+
+func (p *publisher) EstimateSubmission(events []AuditEvent) (SubmissionEstimate, error) {
+	est := SubmissionEstimate{Events: len(events)}
+	for _, event := range events {
+		data, err := p.encode(event)
+		if err != nil {
+			return SubmissionEstimate{}, err
+		}
+		est.Bytes += len(data)
+	}
+	if p.cfg.PricePerByte != nil {
+		est.Cost = new(big.Int).Mul(big.NewInt(int64(est.Bytes)), p.cfg.PricePerByte)
+	}
+	return est, nil
+}
+
+// encode returns the blob PublishWithReceipt submits for event.
+func (p *publisher) encode(event AuditEvent) ([]byte, error) {
+	event.Namespace = p.namespaceFor(event)
+	data, err := serializeEvent(event)
+	if err != nil {
+		return nil, fmt.Errorf("da: serialize event %s: %w", event.Type, err)
+	}
+	if p.cfg.Compress {
+		if data, err = compressBlob(data); err != nil {
+			return nil, fmt.Errorf("da: compress event %s: %w", event.Type, err)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,78 @@
+package da
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestEstimateSubmission_MatchesPublishedBlobs(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	published := 0
+	backend := &zgtest.MockBackend{
+		SendTxFn: func(_ context.Context, tx *types.Transaction) error {
+			args, err := daABI.Methods["submitOriginalData"].Inputs.Unpack(tx.Data()[4:])
+			if err != nil {
+				return err
+			}
+			published += len(args[0].([]byte))
+			return nil
+		},
+		ReceiptFn: func(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+			return daReceipt(), nil
+		},
+	}
+	p := NewPublisher(PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
+		PerAgentNamespace: true,
+		Compress:          true,
+		PricePerByte:      big.NewInt(3),
+	}, backend, zerog.NewLocalSigner(key))
+
+	events := []AuditEvent{
+		{Type: EventTypeTaskReceived, AgentID: "agent-1", TaskID: "t1"},
+		{Type: EventTypeJobCompleted, AgentID: "agent-1", TaskID: "t1",
+			Details: map[string]string{"note": string(bytes.Repeat([]byte("repetitive "), 40))}},
+	}
+	est, err := p.EstimateSubmission(events)
+	if err != nil {
+		t.Fatalf("EstimateSubmission: %v", err)
+	}
+	if published != 0 {
+		t.Fatal("estimate must not submit anything")
+	}
+	if _, err := PublishBatch(context.Background(), p, events); err != nil {
+		t.Fatal(err)
+	}
+
+	if est.Events != 2 || est.Bytes != published {
+		t.Errorf("estimate = %d events/%d bytes, published %d bytes", est.Events, est.Bytes, published)
+	}
+	if want := big.NewInt(int64(3 * published)); est.Cost == nil || est.Cost.Cmp(want) != 0 {
+		t.Errorf("cost = %v, want %v", est.Cost, want)
+	}
+}
+
+func TestEstimateSubmission_NoPrice(t *testing.T) {
+	p := NewPublisher(PublisherConfig{}, &zgtest.MockBackend{}, nil)
+	est, err := p.EstimateSubmission([]AuditEvent{{Type: EventTypeAgentStarted, AgentID: "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Bytes == 0 || est.Cost != nil {
+		t.Errorf("estimate = %+v, want bytes and no cost", est)
+	}
+}
@@ -0,0 +1,105 @@
+package da
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+)
+
+// idempotencyKey identifies a DA blob. Attempts to submit the same blob
+// share one signed transaction, so the chain accepts it at most once.
+func idempotencyKey(blob []byte) string {
+	sum := sha256.Sum256(blob)
+	return hex.EncodeToString(sum[:])
+}
+
+// sendOnce returns the transaction submitting data. The first attempt for a
+// key signs and sends a new transaction and remembers it until it is mined.
+// Later attempts — retries after a lost response, or a republish of the
+// same event — rebroadcast that identical transaction instead of signing a
+// new one, so a retry cannot create a second submission for the same blob.
+// A node that already holds or has mined it counts as a successful send.
+// A definitive rejection, such as an underpriced transaction, forgets the
+// transaction so the next attempt signs a fresh one.
+func (p *publisher) sendOnce(ctx context.Context, key string, data []byte) (*types.Transaction, error) {
+	if v, ok := p.inflight.Load(key); ok {
+		tx := v.(*types.Transaction)
+		err := p.backend.SendTransaction(ctx, tx)
+		switch {
+		case err == nil, alreadyKnown(err):
+			return tx, nil
+		case nonceTooLow(err):
+			// Something with our nonce was mined: either this transaction,
+			// or another one, in which case this blob was never submitted.
+			if _, rerr := p.backend.TransactionReceipt(ctx, tx.Hash()); rerr == nil {
+				return tx, nil
+			}
+			p.inflight.Delete(key)
+		default:
+			if rejected(err) {
+				p.inflight.Delete(key)
+			}
+			return nil, fmt.Errorf("rebroadcast tx %s: %w", tx.Hash().Hex(), err)
+		}
+	}
+
+	opts := zerog.SignerTransactOpts(ctx, p.signer, p.cfg.ChainID)
+	opts.NoSend = true
+	tx, err := p.contract.Transact(opts, "submitOriginalData", data)
+	if err != nil {
+		return nil, fmt.Errorf("submit tx: %w", err)
+	}
+	if _, loaded := p.inflight.LoadOrStore(key, tx); loaded {
+		// A concurrent publish of the same blob signed first; use its tx.
+		return p.sendOnce(ctx, key, data)
+	}
+	if err := p.backend.SendTransaction(ctx, tx); err != nil {
+		if rejected(err) {
+			p.inflight.Delete(key)
+		}
+		return nil, fmt.Errorf("submit tx %s: %w", tx.Hash().Hex(), err)
+	}
+	return tx, nil
+}
+
+// alreadyKnown reports whether a send error means the node already holds
+// the transaction.
+func alreadyKnown(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// nonceTooLow reports whether a send error means the transaction's nonce
+// has already been used by a mined transaction.
+func nonceTooLow(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
+
+// rejectionReasons are send errors after which a node will never accept
+// the transaction as signed. Anything else, such as a timeout, may mean
+// the node took it.
+var rejectionReasons = []string{
+	"underpriced",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"fee cap less than block base fee",
+	"invalid",
+}
+
+// rejected reports whether a send error is a definitive rejection.
+func rejected(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, reason := range rejectionReasons {
+		if strings.Contains(msg, reason) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package da
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+// lossyChain accepts every transaction but loses the response to the first
+// send, as a flaky connection would. It answers rebroadcasts the way a node
+// does: "already known" while pending, "nonce too low" once mined.
+type lossyChain struct {
+	mined    bool
+	accepted map[common.Hash]int
+}
+
+func (c *lossyChain) backend() *zgtest.MockBackend {
+	return &zgtest.MockBackend{
+		SendTxFn: func(_ context.Context, tx *types.Transaction) error {
+			c.accepted[tx.Hash()]++
+			switch {
+			case len(c.accepted) == 1 && c.accepted[tx.Hash()] == 1:
+				return errors.New("i/o timeout")
+			case c.mined:
+				return errors.New("nonce too low")
+			default:
+				return errors.New("already known")
+			}
+		},
+		ReceiptFn: func(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+			if c.accepted[hash] == 0 {
+				return nil, ethereum.NotFound
+			}
+			r := daReceipt()
+			r.TxHash = hash
+			return r, nil
+		},
+	}
+}
+
+func TestPublish_RetryAfterLostResponseDoesNotDuplicate(t *testing.T) {
+	for _, mined := range []bool{false, true} {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain := &lossyChain{mined: mined, accepted: make(map[common.Hash]int)}
+		clk := clock.NewFake(time.Now())
+		p := NewPublisher(PublisherConfig{
+			ChainID:           16602,
+			DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
+			Clock:             clk,
+		}, chain.backend(), zerog.NewLocalSigner(key))
+
+		done := make(chan error, 1)
+		var subID string
+		go func() {
+			var err error
+			subID, err = p.Publish(context.Background(), AuditEvent{Type: EventTypeJobCompleted, TaskID: "t1"})
+			done <- err
+		}()
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+
+		if err := <-done; err != nil {
+			t.Fatalf("mined=%v: unexpected error: %v", mined, err)
+		}
+		if subID == "" {
+			t.Errorf("mined=%v: expected the original submission ID", mined)
+		}
+		if len(chain.accepted) != 1 {
+			t.Errorf("mined=%v: %d distinct transactions sent, want 1", mined, len(chain.accepted))
+		}
+	}
+}
+
+func TestPublish_RejectedSendSignsAFreshTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rejectedTx common.Hash
+	sent := make(map[common.Hash]bool)
+	backend := &zgtest.MockBackend{
+		// The first transaction is underpriced; the node refuses it on
+		// every broadcast.
+		SendTxFn: func(_ context.Context, tx *types.Transaction) error {
+			if len(sent) == 0 {
+				rejectedTx = tx.Hash()
+			}
+			sent[tx.Hash()] = true
+			if tx.Hash() == rejectedTx {
+				return errors.New("transaction underpriced")
+			}
+			return nil
+		},
+		ReceiptFn: func(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+			r := daReceipt()
+			r.TxHash = hash
+			return r, nil
+		},
+	}
+	clk := clock.NewFake(time.Now())
+	p := NewPublisher(PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
+		MaxRetries:        1,
+		Clock:             clk,
+	}, backend, zerog.NewLocalSigner(key))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Publish(context.Background(), AuditEvent{Type: EventTypeJobCompleted, TaskID: "t1"})
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("%d distinct transactions sent, want the rejected one and a re-signed one", len(sent))
+	}
+	if n := inflightCount(p.(*publisher)); n != 0 {
+		t.Errorf("%d transactions left in flight after publish", n)
+	}
+}
+
+// inflightCount returns the number of transactions p tracks as in flight.
+func inflightCount(p *publisher) int {
+	n := 0
+	p.inflight.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
@@ -0,0 +1,64 @@
+package da
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/lancekrogers/agent-inference/internal/atomicfile"
+)
+
+// taskIndex maps task IDs to the submission IDs published for them. When
+// path is set the index is loaded from and saved to that file; persistence
+// is best-effort and never blocks publishing.
+type taskIndex struct {
+	path string
+
+	mu    sync.Mutex
+	tasks map[string][]string
+}
+
+func newTaskIndex(path string) *taskIndex {
+	idx := &taskIndex{path: path, tasks: make(map[string][]string)}
+	if path == "" {
+		return idx
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		// A corrupt file starts a fresh index rather than failing startup.
+		_ = json.Unmarshal(data, &idx.tasks)
+		if idx.tasks == nil {
+			idx.tasks = make(map[string][]string)
+		}
+	}
+	return idx
+}
+
+// add records submissionID under taskID and persists the index.
+func (idx *taskIndex) add(taskID, submissionID string) {
+	if taskID == "" || submissionID == "" {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.tasks[taskID] = append(idx.tasks[taskID], submissionID)
+	if idx.path != "" {
+		_ = idx.saveLocked()
+	}
+}
+
+// lookup returns a copy of taskID's submission IDs in publish order.
+func (idx *taskIndex) lookup(taskID string) []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return slices.Clone(idx.tasks[taskID])
+}
+
+// saveLocked atomically writes the index.
+func (idx *taskIndex) saveLocked() error {
+	data, err := json.Marshal(idx.tasks)
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(idx.path, data)
+}
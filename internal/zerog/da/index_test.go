@@ -0,0 +1,63 @@
+package da
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestSubmissionsForTask_Persisted(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &zgtest.MockBackend{
+		ReceiptFn: func(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+			return daReceipt(), nil
+		},
+	}
+	cfg := PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
+		IndexFile:         filepath.Join(t.TempDir(), "da-index.json"),
+	}
+
+	p := NewPublisher(cfg, backend, zerog.NewLocalSigner(key))
+	var want []string
+	for _, typ := range []EventType{EventTypeTaskReceived, EventTypeJobCompleted} {
+		id, err := p.Publish(context.Background(), AuditEvent{
+			Type: typ, AgentID: "agent-1", TaskID: "task-1", Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("publish %s: %v", typ, err)
+		}
+		want = append(want, id)
+	}
+	if _, err := p.Publish(context.Background(), AuditEvent{
+		Type: EventTypeTaskReceived, AgentID: "agent-1", TaskID: "task-2", Timestamp: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh publisher reloads the index from disk.
+	reloaded := NewPublisher(cfg, backend, zerog.NewLocalSigner(key))
+	got, err := reloaded.SubmissionsForTask("task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("task-1 submissions = %v, want %v", got, want)
+	}
+	if got, _ := reloaded.SubmissionsForTask("unknown"); len(got) != 0 {
+		t.Errorf("unknown task submissions = %v, want none", got)
+	}
+}
@@ -2,7 +2,11 @@ package da
 
 import (
 	"errors"
+	"math/big"
 	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/zerog"
 )
 
 // Sentinel errors for DA operations.
@@ -11,6 +15,7 @@ var (
 	ErrNotAvailable      = errors.New("da: data not yet available")
 	ErrDANodeUnreachable = errors.New("da: DA node unreachable")
 	ErrSerializeFailed   = errors.New("da: event serialization failed")
+	ErrBatchIncomplete   = errors.New("da: batch publish incomplete")
 )
 
 // EventType identifies what kind of audit event occurred.
@@ -24,6 +29,8 @@ const (
 	EventTypeResultStored EventType = "result_stored"
 	EventTypeINFTMinted   EventType = "inft_minted"
 	EventTypeResultReport EventType = "result_reported"
+	EventTypeAgentStarted EventType = "agent_started"
+	EventTypeAgentStopped EventType = "agent_stopped"
 )
 
 // AuditEvent represents a single auditable action by the inference agent.
@@ -38,6 +45,9 @@ type AuditEvent struct {
 	INFTRef    string            `json:"inft_ref,omitempty"`
 	Details    map[string]string `json:"details,omitempty"`
 	Timestamp  time.Time         `json:"timestamp"`
+	// Namespace is the audit stream the event was published to. Set by the
+	// publisher; any caller-provided value is overwritten.
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // Submission tracks a DA submission for later verification.
@@ -63,8 +73,34 @@ type PublisherConfig struct {
 	PrivateKey string
 	// Namespace is the DA namespace for this agent's audit events.
 	Namespace string
+	// PerAgentNamespace appends the event's AgentID to Namespace
+	// (e.g. "inference-audit/<agentID>") so agents sharing a base namespace
+	// keep separate audit streams. Events without an AgentID use the
+	// shared base namespace.
+	PerAgentNamespace bool
 	// MaxRetries is the number of retry attempts for failed submissions.
 	MaxRetries int
+	// Compress gzips serialized events before submission when that makes
+	// them smaller. Readers use DecodeBlob to handle both forms.
+	Compress bool
+	// VerifyTimeout bounds each availability check made by Verify.
+	// Default: 10s.
+	VerifyTimeout time.Duration
+	// VerifyRetries is how many times Verify retries a check that failed to
+	// reach DA, with backoff from 250ms. A confirmed "not available" is
+	// never retried. Default: 2; negative disables retries.
+	VerifyRetries int
+	// PricePerByte is the DA submission price in wei per payload byte, used
+	// to project costs in EstimateSubmission. Nil leaves costs unset.
+	PricePerByte *big.Int
+	// IndexFile persists the task ID to submission ID index behind
+	// SubmissionsForTask across restarts. Empty keeps it in memory only.
+	IndexFile string
+	// Clock drives retry backoff and submission timestamps. Nil uses real time.
+	Clock clock.Clock
+	// TxLimiter, shared with the other chain clients, bounds concurrent
+	// in-flight transactions. Nil means no limit.
+	TxLimiter *zerog.TxLimiter
 
 	// Endpoint is a legacy field for backward compat with REST mode.
 	Endpoint string
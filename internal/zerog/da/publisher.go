@@ -7,10 +7,10 @@ package da
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -18,9 +18,22 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/retry"
 	"github.com/lancekrogers/agent-inference/internal/zerog"
 )
 
+const (
+	defaultVerifyTimeout = 10 * time.Second
+	defaultVerifyRetries = 2
+	// verifyBackoff is the first delay between verify attempts; it doubles
+	// after each failure.
+	verifyBackoff = 250 * time.Millisecond
+	// publishBackoff is the first delay between submission attempts; it
+	// doubles after each failure.
+	publishBackoff = time.Second
+)
+
 const daABIJSON = `[
   {
     "name": "submitOriginalData",
@@ -66,24 +79,47 @@ func mustParseABI(raw string) abi.ABI {
 // AuditPublisher posts inference audit events to 0G Data Availability.
 type AuditPublisher interface {
 	Publish(ctx context.Context, event AuditEvent) (string, error)
+	// PublishWithReceipt is like Publish but returns the full submission,
+	// including the block height needed for later verification.
+	PublishWithReceipt(ctx context.Context, event AuditEvent) (Submission, error)
 	Verify(ctx context.Context, submissionID string) (bool, error)
+	// SubmissionsForTask returns the IDs of every submission published for
+	// taskID by this publisher, oldest first.
+	SubmissionsForTask(taskID string) ([]string, error)
+	// EstimateSubmission reports how many bytes publishing events would
+	// submit, and what that costs when a price per byte is configured.
+	// Nothing is published.
+	EstimateSubmission(events []AuditEvent) (SubmissionEstimate, error)
+	// Close releases publisher resources. The publisher must not be used
+	// afterwards.
+	Close() error
 }
 
 type publisher struct {
 	cfg      PublisherConfig
 	backend  zerog.ChainBackend
 	contract *bind.BoundContract
-	key      *ecdsa.PrivateKey
+	signer   zerog.Signer
+	clock    clock.Clock
+	index    *taskIndex
+	inflight sync.Map // idempotency key → *types.Transaction awaiting a receipt
 }
 
 // NewPublisher creates a new AuditPublisher using the DA Entrance contract.
-func NewPublisher(cfg PublisherConfig, backend zerog.ChainBackend, key *ecdsa.PrivateKey) AuditPublisher {
+func NewPublisher(cfg PublisherConfig, backend zerog.ChainBackend, signer zerog.Signer) AuditPublisher {
 	if cfg.MaxRetries == 0 {
 		cfg.MaxRetries = 3
 	}
 	if cfg.Namespace == "" {
 		cfg.Namespace = "inference-audit"
 	}
+	if cfg.VerifyTimeout <= 0 {
+		cfg.VerifyTimeout = defaultVerifyTimeout
+	}
+	if cfg.VerifyRetries == 0 {
+		cfg.VerifyRetries = defaultVerifyRetries
+	}
+	cfg.VerifyRetries = max(cfg.VerifyRetries, 0)
 
 	contractAddr := common.HexToAddress(cfg.DAContractAddress)
 	bc := bind.NewBoundContract(contractAddr, daABI, backend, backend, backend)
@@ -92,28 +128,49 @@ func NewPublisher(cfg PublisherConfig, backend zerog.ChainBackend, key *ecdsa.Pr
 		cfg:      cfg,
 		backend:  backend,
 		contract: bc,
-		key:      key,
+		signer:   signer,
+		clock:    clock.OrReal(cfg.Clock),
+		index:    newTaskIndex(cfg.IndexFile),
 	}
 }
 
 func (p *publisher) Publish(ctx context.Context, event AuditEvent) (string, error) {
+	sub, err := p.PublishWithReceipt(ctx, event)
+	if err != nil {
+		return "", err
+	}
+	return sub.ID, nil
+}
+
+func (p *publisher) PublishWithReceipt(ctx context.Context, event AuditEvent) (Submission, error) {
 	if err := ctx.Err(); err != nil {
-		return "", fmt.Errorf("da: context cancelled before publish: %w", err)
+		return Submission{}, fmt.Errorf("da: context cancelled before publish: %w", err)
 	}
 
-	data, err := serializeEvent(event)
+	event.Namespace = p.namespaceFor(event)
+	data, err := p.encode(event)
 	if err != nil {
-		return "", fmt.Errorf("da: serialize event %s: %w", event.Type, err)
+		return Submission{}, err
 	}
 
-	subID, err := p.publishWithRetry(ctx, data)
+	sub, err := p.publishWithRetry(ctx, data)
 	if err != nil {
-		return "", fmt.Errorf("da: publish event %s: %w", event.Type, err)
+		return Submission{}, fmt.Errorf("da: publish event %s: %w", event.Type, err)
 	}
 
-	return subID, nil
+	sub.EventType = event.Type
+	sub.Namespace = event.Namespace
+	p.index.add(event.TaskID, sub.ID)
+	return sub, nil
+}
+
+func (p *publisher) SubmissionsForTask(taskID string) ([]string, error) {
+	return p.index.lookup(taskID), nil
 }
 
+// Verify reports whether submissionID's data is available. (false, nil)
+// means DA confirmed it absent; failing to reach DA is an error wrapping
+// ErrDANodeUnreachable, returned after VerifyRetries retries with backoff.
 func (p *publisher) Verify(ctx context.Context, submissionID string) (bool, error) {
 	if err := ctx.Err(); err != nil {
 		return false, fmt.Errorf("da: context cancelled before verify: %w", err)
@@ -122,9 +179,19 @@ func (p *publisher) Verify(ctx context.Context, submissionID string) (bool, erro
 	dataRoot := common.HexToHash(submissionID)
 
 	var results []interface{}
-	err := p.contract.Call(&bind.CallOpts{Context: ctx}, &results, "isDataAvailable", dataRoot)
+	err := retry.Do(ctx, retry.Policy{
+		MaxAttempts: p.cfg.VerifyRetries + 1,
+		BaseDelay:   verifyBackoff,
+		Clock:       p.clock,
+	}, func(ctx context.Context) error {
+		return p.callAvailable(ctx, dataRoot, &results)
+	})
+	if ctx.Err() != nil {
+		return false, fmt.Errorf("da: verify %s: %w", submissionID, ctx.Err())
+	}
 	if err != nil {
-		return false, fmt.Errorf("da: verify call for %s: %w", submissionID, err)
+		return false, fmt.Errorf("da: verify %s after %d attempts: %w: %w",
+			submissionID, p.cfg.VerifyRetries+1, ErrDANodeUnreachable, err)
 	}
 
 	if len(results) == 0 {
@@ -139,6 +206,22 @@ func (p *publisher) Verify(ctx context.Context, submissionID string) (bool, erro
 	return available, nil
 }
 
+// callAvailable makes one isDataAvailable call, bounded by VerifyTimeout.
+func (p *publisher) callAvailable(ctx context.Context, dataRoot common.Hash, results *[]interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.VerifyTimeout)
+	defer cancel()
+	*results = nil
+	return p.contract.Call(&bind.CallOpts{Context: ctx}, results, "isDataAvailable", dataRoot)
+}
+
+// namespaceFor returns the namespace an event is published under.
+func (p *publisher) namespaceFor(event AuditEvent) string {
+	if p.cfg.PerAgentNamespace && event.AgentID != "" {
+		return p.cfg.Namespace + "/" + event.AgentID
+	}
+	return p.cfg.Namespace
+}
+
 func serializeEvent(event AuditEvent) ([]byte, error) {
 	data, err := json.Marshal(event)
 	if err != nil {
@@ -147,57 +230,61 @@ func serializeEvent(event AuditEvent) ([]byte, error) {
 	return data, nil
 }
 
-func (p *publisher) publishWithRetry(ctx context.Context, data []byte) (string, error) {
-	var lastErr error
-	for attempt := 0; attempt <= p.cfg.MaxRetries; attempt++ {
-		if err := ctx.Err(); err != nil {
-			return "", fmt.Errorf("context cancelled on attempt %d: %w", attempt+1, err)
-		}
-
-		subID, err := p.submitToDA(ctx, data)
-		if err == nil {
-			return subID, nil
-		}
-		lastErr = err
-
-		if attempt < p.cfg.MaxRetries {
-			backoff := time.Duration(1<<uint(attempt)) * time.Second
-			select {
-			case <-ctx.Done():
-				return "", fmt.Errorf("context cancelled during backoff: %w", ctx.Err())
-			case <-time.After(backoff):
-			}
-		}
+func (p *publisher) publishWithRetry(ctx context.Context, data []byte) (Submission, error) {
+	var sub Submission
+	err := retry.Do(ctx, retry.Policy{
+		MaxAttempts: p.cfg.MaxRetries + 1,
+		BaseDelay:   publishBackoff,
+		Clock:       p.clock,
+	}, func(ctx context.Context) error {
+		var err error
+		sub, err = p.submitToDA(ctx, data)
+		return err
+	})
+	if ctx.Err() != nil {
+		return Submission{}, fmt.Errorf("context cancelled: %w", ctx.Err())
+	}
+	if err != nil {
+		return Submission{}, fmt.Errorf("all %d attempts failed: %w", p.cfg.MaxRetries+1, err)
 	}
-	return "", fmt.Errorf("all %d attempts failed: %w", p.cfg.MaxRetries+1, lastErr)
+	return sub, nil
 }
 
-func (p *publisher) submitToDA(ctx context.Context, data []byte) (string, error) {
-	opts, err := zerog.MakeTransactOpts(ctx, p.key, p.cfg.ChainID)
+func (p *publisher) submitToDA(ctx context.Context, data []byte) (Submission, error) {
+	release, err := p.cfg.TxLimiter.Acquire(ctx)
 	if err != nil {
-		return "", fmt.Errorf("create transact opts: %w", err)
+		return Submission{}, err
 	}
+	defer release()
 
-	tx, err := p.contract.Transact(opts, "submitOriginalData", data)
+	key := idempotencyKey(data)
+	tx, err := p.sendOnce(ctx, key, data)
 	if err != nil {
-		return "", fmt.Errorf("submit tx: %w", err)
+		return Submission{}, err
 	}
 
 	receipt, err := bind.WaitMined(ctx, p.backend, tx)
+	// Mined or given up on, the transaction is done with: a later
+	// submission of this blob signs anew.
+	p.inflight.Delete(key)
 	if err != nil {
-		return "", fmt.Errorf("wait for tx %s: %w", tx.Hash().Hex(), err)
+		return Submission{}, fmt.Errorf("wait for tx %s: %w", tx.Hash().Hex(), err)
 	}
 
 	if receipt.Status != types.ReceiptStatusSuccessful {
-		return "", fmt.Errorf("tx reverted: %w", ErrSubmissionFailed)
+		return Submission{}, fmt.Errorf("tx reverted: %w", ErrSubmissionFailed)
 	}
 
 	subID, err := parseDataSubmitEvent(receipt)
 	if err != nil {
-		return "", err
+		return Submission{}, err
 	}
 
-	return subID, nil
+	sub := Submission{ID: subID, SubmittedAt: p.clock.Now()}
+	if receipt.BlockNumber != nil {
+		sub.BlockHeight = receipt.BlockNumber.Uint64()
+	}
+	return sub, nil
 }
 
 func parseDataSubmitEvent(receipt *types.Receipt) (string, error) {
@@ -210,3 +297,7 @@ func parseDataSubmitEvent(receipt *types.Receipt) (string, error) {
 	}
 	return "", fmt.Errorf("da: DataSubmit event not found in receipt")
 }
+
+// Close is a no-op; the publisher holds no resources of its own. The chain
+// backend is owned by the caller.
+func (p *publisher) Close() error { return nil }
@@ -14,6 +14,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/zerog"
 	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
 )
 
@@ -27,7 +29,7 @@ func daReceipt() *types.Receipt {
 				Topics: []common.Hash{
 					eventSig,
 					common.BytesToHash(common.Address{}.Bytes()), // sender
-					dataRoot,                                     // dataRoot
+					dataRoot, // dataRoot
 				},
 				Data: common.LeftPadBytes(big.NewInt(1).Bytes(), 64), // epoch + quorumId
 			},
@@ -51,7 +53,7 @@ func TestPublish_Success(t *testing.T) {
 		ChainID:           16602,
 		DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
 		MaxRetries:        0,
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	subID, err := p.Publish(context.Background(), AuditEvent{
 		Type:      EventTypeJobCompleted,
@@ -87,16 +89,35 @@ func TestPublish_Retry(t *testing.T) {
 		},
 	}
 
+	clk := clock.NewFake(time.Now())
 	p := NewPublisher(PublisherConfig{
 		ChainID:           16602,
 		DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
 		MaxRetries:        3,
-	}, backend, key)
-
-	subID, err := p.Publish(context.Background(), AuditEvent{
-		Type:      EventTypeResultStored,
-		Timestamp: time.Now(),
-	})
+		Clock:             clk,
+	}, backend, zerog.NewLocalSigner(key))
+
+	type result struct {
+		id  string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		id, err := p.Publish(context.Background(), AuditEvent{
+			Type:      EventTypeResultStored,
+			Timestamp: time.Now(),
+		})
+		done <- result{id, err}
+	}()
+
+	// Two failures: back off 1s, then 2s.
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second} {
+		clk.BlockUntil(1)
+		clk.Advance(backoff)
+	}
+
+	res := <-done
+	subID, err := res.id, res.err
 	if err != nil {
 		t.Fatalf("unexpected error after retries: %v", err)
 	}
@@ -117,17 +138,27 @@ func TestPublish_AllRetriesFail(t *testing.T) {
 		},
 	}
 
+	clk := clock.NewFake(time.Now())
 	p := NewPublisher(PublisherConfig{
 		ChainID:           16602,
 		DAContractAddress: "0xtest",
 		MaxRetries:        1,
-	}, backend, key)
-
-	_, err = p.Publish(context.Background(), AuditEvent{
-		Type:      EventTypeJobFailed,
-		Timestamp: time.Now(),
-	})
-	if err == nil {
+		Clock:             clk,
+	}, backend, zerog.NewLocalSigner(key))
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := p.Publish(context.Background(), AuditEvent{
+			Type:      EventTypeJobFailed,
+			Timestamp: time.Now(),
+		})
+		errCh <- err
+	}()
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+
+	if err := <-errCh; err == nil {
 		t.Fatal("expected error after all retries fail")
 	}
 }
@@ -145,7 +176,7 @@ func TestPublish_ContextCancelled(t *testing.T) {
 	p := NewPublisher(PublisherConfig{
 		ChainID:           16602,
 		DAContractAddress: "0xtest",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err = p.Publish(ctx, AuditEvent{Type: EventTypeJobSubmitted, Timestamp: time.Now()})
 	if err == nil {
@@ -163,17 +194,29 @@ func TestPublish_ChainDown(t *testing.T) {
 		Err: ErrDANodeUnreachable,
 	}
 
+	clk := clock.NewFake(time.Now())
 	p := NewPublisher(PublisherConfig{
 		ChainID:           16602,
 		DAContractAddress: "0xtest",
-		MaxRetries:        0,
-	}, backend, key)
+		MaxRetries:        0, // defaults to 3
+		Clock:             clk,
+	}, backend, zerog.NewLocalSigner(key))
 
-	_, err = p.Publish(context.Background(), AuditEvent{
-		Type:      EventTypeJobSubmitted,
-		Timestamp: time.Now(),
-	})
-	if err == nil {
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := p.Publish(context.Background(), AuditEvent{
+			Type:      EventTypeJobSubmitted,
+			Timestamp: time.Now(),
+		})
+		errCh <- err
+	}()
+
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		clk.BlockUntil(1)
+		clk.Advance(backoff)
+	}
+
+	if err := <-errCh; err == nil {
 		t.Fatal("expected error for unreachable chain")
 	}
 }
@@ -197,7 +240,7 @@ func TestVerify_Available(t *testing.T) {
 	p := NewPublisher(PublisherConfig{
 		ChainID:           16602,
 		DAContractAddress: "0xtest",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	available, err := p.Verify(context.Background(), "0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890")
 	if err != nil {
@@ -226,7 +269,7 @@ func TestVerify_NotAvailable(t *testing.T) {
 	p := NewPublisher(PublisherConfig{
 		ChainID:           16602,
 		DAContractAddress: "0xtest",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	available, err := p.Verify(context.Background(), "0xdeadbeef")
 	if err != nil {
@@ -250,7 +293,7 @@ func TestVerify_ChainDown(t *testing.T) {
 	p := NewPublisher(PublisherConfig{
 		ChainID:           16602,
 		DAContractAddress: "0xtest",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err = p.Verify(context.Background(), "0xtest")
 	if err == nil {
@@ -312,3 +355,210 @@ func TestSerializeEvent_AllFields(t *testing.T) {
 		t.Errorf("expected cid-123, got %s", parsed.StorageRef)
 	}
 }
+
+func TestPublish_PerAgentNamespace(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		perAgent bool
+		agentID  string
+		want     string
+	}{
+		{"shared default", false, "agent-1", "inference-audit"},
+		{"per agent", true, "agent-1", "inference-audit/agent-1"},
+		{"per agent without ID", true, "", "inference-audit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent *types.Transaction
+			backend := &zgtest.MockBackend{
+				SendTxFn: func(_ context.Context, tx *types.Transaction) error {
+					sent = tx
+					return nil
+				},
+				ReceiptFn: func(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+					return daReceipt(), nil
+				},
+			}
+			p := NewPublisher(PublisherConfig{
+				ChainID:           16602,
+				DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
+				PerAgentNamespace: tt.perAgent,
+			}, backend, zerog.NewLocalSigner(key))
+
+			if _, err := p.Publish(context.Background(), AuditEvent{
+				Type:    EventTypeTaskReceived,
+				AgentID: tt.agentID,
+			}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			args, err := daABI.Methods["submitOriginalData"].Inputs.Unpack(sent.Data()[4:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var event AuditEvent
+			if err := json.Unmarshal(args[0].([]byte), &event); err != nil {
+				t.Fatal(err)
+			}
+			if event.Namespace != tt.want {
+				t.Errorf("expected namespace %q, got %q", tt.want, event.Namespace)
+			}
+		})
+	}
+}
+
+func TestPublishWithReceipt_BlockHeight(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &zgtest.MockBackend{
+		ReceiptFn: func(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+			r := daReceipt()
+			r.BlockNumber = big.NewInt(4242)
+			return r, nil
+		},
+	}
+	p := NewPublisher(PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xE75A073dA5bb7b0eC622170Fd268f35E675a957B",
+	}, backend, zerog.NewLocalSigner(key))
+
+	sub, err := p.PublishWithReceipt(context.Background(), AuditEvent{
+		Type:    EventTypeJobCompleted,
+		AgentID: "agent-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub.ID == "" {
+		t.Error("expected non-empty submission ID")
+	}
+	if sub.BlockHeight != 4242 {
+		t.Errorf("expected block height 4242, got %d", sub.BlockHeight)
+	}
+	if sub.EventType != EventTypeJobCompleted || sub.Namespace != "inference-audit" {
+		t.Errorf("unexpected submission metadata: %+v", sub)
+	}
+	if sub.SubmittedAt.IsZero() {
+		t.Error("expected SubmittedAt to be set")
+	}
+}
+
+func TestVerify_RetriesUnreachable(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boolType, _ := abi.NewType("bool", "", nil)
+	encoded, _ := abi.Arguments{{Type: boolType}}.Pack(true)
+
+	calls := 0
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("connection reset")
+			}
+			return encoded, nil
+		},
+	}
+
+	clk := clock.NewFake(time.Now())
+	p := NewPublisher(PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xtest",
+		Clock:             clk,
+	}, backend, zerog.NewLocalSigner(key))
+
+	done := make(chan error, 1)
+	var available bool
+	go func() {
+		var err error
+		available, err = p.Verify(context.Background(), "0xabc")
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	clk.Advance(verifyBackoff)
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error after retry: %v", err)
+	}
+	if !available || calls != 2 {
+		t.Errorf("available = %v after %d calls, want true after 2", available, calls)
+	}
+}
+
+func TestVerify_UnreachableVsAbsent(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			calls++
+			return nil, errors.New("connection refused")
+		},
+	}
+	p := NewPublisher(PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xtest",
+		VerifyRetries:     -1,
+	}, backend, zerog.NewLocalSigner(key))
+
+	available, err := p.Verify(context.Background(), "0xabc")
+	if !errors.Is(err, ErrDANodeUnreachable) || available {
+		t.Errorf("unreachable: got (%v, %v), want (false, ErrDANodeUnreachable)", available, err)
+	}
+	if calls != 1 {
+		t.Errorf("retries disabled: %d calls, want 1", calls)
+	}
+
+	// A definitive "not available" answer is returned without retrying.
+	boolType, _ := abi.NewType("bool", "", nil)
+	encoded, _ := abi.Arguments{{Type: boolType}}.Pack(false)
+	calls = 0
+	backend.CallFn = func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
+		calls++
+		return encoded, nil
+	}
+	p = NewPublisher(PublisherConfig{ChainID: 16602, DAContractAddress: "0xtest"}, backend, zerog.NewLocalSigner(key))
+	available, err = p.Verify(context.Background(), "0xabc")
+	if err != nil || available || calls != 1 {
+		t.Errorf("absent: got (%v, %v) after %d calls, want (false, nil) after 1", available, err, calls)
+	}
+}
+
+func TestVerify_PerCallTimeout(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &zgtest.MockBackend{
+		CallFn: func(ctx context.Context, _ ethereum.CallMsg) ([]byte, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	p := NewPublisher(PublisherConfig{
+		ChainID:           16602,
+		DAContractAddress: "0xtest",
+		VerifyTimeout:     20 * time.Millisecond,
+		VerifyRetries:     -1,
+	}, backend, zerog.NewLocalSigner(key))
+
+	_, err = p.Verify(context.Background(), "0xabc")
+	if !errors.Is(err, ErrDANodeUnreachable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected unreachable timeout error, got %v", err)
+	}
+}
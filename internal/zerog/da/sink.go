@@ -0,0 +1,136 @@
+package da
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// AuditSink receives a copy of every audit event the agent publishes, for
+// operators feeding their own log pipeline or SIEM.
+type AuditSink interface {
+	WriteEvent(ctx context.Context, event AuditEvent) error
+}
+
+// JSONLSink writes each event as one line of JSON. Files written by it can
+// be read back with ReadEvents and replayed through PublishBatch.
+type JSONLSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriterSink returns a sink writing to w, e.g. os.Stdout. Closing the
+// sink does not close w.
+func NewWriterSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{w: w}
+}
+
+// NewFileSink returns a sink appending to the file at path, creating it
+// with mode 0600 if needed.
+func NewFileSink(path string) (*JSONLSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("da: open audit file: %w", err)
+	}
+	return &JSONLSink{w: f, closer: f}, nil
+}
+
+func (s *JSONLSink) WriteEvent(_ context.Context, event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("da: sink: %w", ErrSerializeFailed)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(line); err != nil {
+		return fmt.Errorf("da: sink write: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file, if the sink opened one.
+func (s *JSONLSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// ReadEvents decodes the events written by a JSONLSink, in order.
+func ReadEvents(r io.Reader) ([]AuditEvent, error) {
+	var events []AuditEvent
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var event AuditEvent
+		if err := json.Unmarshal(sc.Bytes(), &event); err != nil {
+			return events, fmt.Errorf("da: audit log line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	if err := sc.Err(); err != nil {
+		return events, fmt.Errorf("da: read audit log: %w", err)
+	}
+	return events, nil
+}
+
+// WithSinks returns pub with every published event also written to sinks,
+// after the DA submission and whether or not it succeeded. Sink failures
+// are passed to onErr, which may be nil, and never fail the publish.
+// Closing the returned publisher closes pub and any sink that is an
+// io.Closer.
+func WithSinks(pub AuditPublisher, onErr func(AuditEvent, error), sinks ...AuditSink) AuditPublisher {
+	if len(sinks) == 0 {
+		return pub
+	}
+	return &teePublisher{AuditPublisher: pub, sinks: sinks, onErr: onErr}
+}
+
+type teePublisher struct {
+	AuditPublisher
+	sinks []AuditSink
+	onErr func(AuditEvent, error)
+}
+
+func (t *teePublisher) Publish(ctx context.Context, event AuditEvent) (string, error) {
+	id, err := t.AuditPublisher.Publish(ctx, event)
+	t.tee(ctx, event)
+	return id, err
+}
+
+func (t *teePublisher) PublishWithReceipt(ctx context.Context, event AuditEvent) (Submission, error) {
+	sub, err := t.AuditPublisher.PublishWithReceipt(ctx, event)
+	t.tee(ctx, event)
+	return sub, err
+}
+
+func (t *teePublisher) tee(ctx context.Context, event AuditEvent) {
+	// A cancelled publish still belongs in the local trail.
+	ctx = context.WithoutCancel(ctx)
+	for _, s := range t.sinks {
+		if err := s.WriteEvent(ctx, event); err != nil && t.onErr != nil {
+			t.onErr(event, err)
+		}
+	}
+}
+
+func (t *teePublisher) Close() error {
+	errs := []error{t.AuditPublisher.Close()}
+	for _, s := range t.sinks {
+		if c, ok := s.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package da
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stubPublisher fails or succeeds every publish; other methods are unused.
+type stubPublisher struct {
+	AuditPublisher
+	err    error
+	closed bool
+}
+
+func (s *stubPublisher) PublishWithReceipt(_ context.Context, _ AuditEvent) (Submission, error) {
+	return Submission{ID: "sub-1"}, s.err
+}
+
+func (s *stubPublisher) Close() error {
+	s.closed = true
+	return nil
+}
+
+type failingSink struct{}
+
+func (failingSink) WriteEvent(context.Context, AuditEvent) error { return errors.New("disk full") }
+
+func TestFileSink_TeesEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inner := &stubPublisher{}
+	var sinkErrs int
+	pub := WithSinks(inner, func(AuditEvent, error) { sinkErrs++ }, sink, failingSink{})
+
+	events := []AuditEvent{
+		{Type: EventTypeTaskReceived, AgentID: "agent-1", TaskID: "t1", Timestamp: time.Unix(1, 0).UTC()},
+		{Type: EventTypeJobCompleted, AgentID: "agent-1", TaskID: "t1", Timestamp: time.Unix(2, 0).UTC()},
+	}
+	sub, err := pub.PublishWithReceipt(context.Background(), events[0])
+	if err != nil || sub.ID != "sub-1" {
+		t.Fatalf("publish through sinks: %v, %v", sub, err)
+	}
+	// DA failure still reaches the sinks and is returned unchanged.
+	inner.err = ErrSubmissionFailed
+	if _, err := pub.PublishWithReceipt(context.Background(), events[1]); !errors.Is(err, ErrSubmissionFailed) {
+		t.Fatalf("expected DA error, got %v", err)
+	}
+	if sinkErrs != 2 {
+		t.Errorf("expected 2 sink errors reported, got %d", sinkErrs)
+	}
+
+	if err := pub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !inner.closed {
+		t.Error("inner publisher not closed")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ReadEvents(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events in file, got %d", len(got))
+	}
+	for i := range events {
+		if got[i].Type != events[i].Type || !got[i].Timestamp.Equal(events[i].Timestamp) {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], events[i])
+		}
+	}
+}
+
+func TestWithSinks_NoSinks(t *testing.T) {
+	inner := &stubPublisher{}
+	if pub := WithSinks(inner, nil); pub != AuditPublisher(inner) {
+		t.Error("expected the publisher unchanged without sinks")
+	}
+}
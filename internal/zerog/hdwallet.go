@@ -0,0 +1,95 @@
+package zerog
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DefaultDerivationPath is the standard Ethereum BIP-44 path for the first account.
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+// LoadKeyFromMnemonic derives an ECDSA private key from a BIP-39 mnemonic
+// and BIP-32 derivation path. An empty path uses DefaultDerivationPath.
+//
+// The mnemonic is used as-is after whitespace normalization; word-list
+// checksum validation is not performed, and no BIP-39 passphrase is applied.
+func LoadKeyFromMnemonic(mnemonic, path string) (*ecdsa.PrivateKey, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("zerog: empty mnemonic")
+	}
+	if path == "" {
+		path = DefaultDerivationPath
+	}
+
+	derivation, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("zerog: invalid derivation path %q: %w", path, err)
+	}
+
+	seed, err := pbkdf2.Key(sha512.New, strings.Join(words, " "), []byte("mnemonic"), 2048, 64)
+	if err != nil {
+		return nil, fmt.Errorf("zerog: derive seed: %w", err)
+	}
+
+	key, chainCode := splitHMAC([]byte("Bitcoin seed"), seed)
+	for _, index := range derivation {
+		key, chainCode, err = deriveChild(key, chainCode, index)
+		if err != nil {
+			return nil, fmt.Errorf("zerog: derive %s: %w", path, err)
+		}
+	}
+
+	priv, err := crypto.ToECDSA(key)
+	if err != nil {
+		return nil, fmt.Errorf("zerog: invalid derived key: %w", err)
+	}
+	return priv, nil
+}
+
+// deriveChild performs BIP-32 private-parent to private-child derivation.
+func deriveChild(parentKey, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0x00}, parentKey...)
+	} else {
+		parent, err := crypto.ToECDSA(parentKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&parent.PublicKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	il, ir := splitHMAC(chainCode, data)
+
+	n := crypto.S256().Params().N
+	ilNum := new(big.Int).SetBytes(il)
+	if ilNum.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child at index %d", index)
+	}
+
+	child := ilNum.Add(ilNum, new(big.Int).SetBytes(parentKey))
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid child at index %d", index)
+	}
+
+	return child.FillBytes(make([]byte, 32)), ir, nil
+}
+
+func splitHMAC(key, data []byte) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
@@ -0,0 +1,38 @@
+package zerog
+
+import "testing"
+
+// Well-known development mnemonic used by Hardhat and Foundry.
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestLoadKeyFromMnemonic_KnownVectors(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{"m/44'/60'/0'/0/0", "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{"m/44'/60'/0'/0/1", "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			key, err := LoadKeyFromMnemonic(testMnemonic, tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := AddressFromKey(key).Hex(); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadKeyFromMnemonic_Invalid(t *testing.T) {
+	if _, err := LoadKeyFromMnemonic("   ", ""); err == nil {
+		t.Error("expected error for empty mnemonic")
+	}
+	if _, err := LoadKeyFromMnemonic(testMnemonic, "not/a/path"); err == nil {
+		t.Error("expected error for invalid derivation path")
+	}
+}
@@ -0,0 +1,159 @@
+package zerog
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ErrCertificatePin is returned for TLS connections whose leaf certificate
+// public key matches none of HTTPConfig.PinnedSPKI.
+var ErrCertificatePin = errors.New("zerog: certificate does not match a pinned public key")
+
+// HTTPConfig configures the transport shared by every outbound HTTP
+// client: provider calls, the storage node, and chain RPC.
+type HTTPConfig struct {
+	// ProxyURL routes all requests through this proxy (http, https, or
+	// socks5). Empty honors HTTP_PROXY, HTTPS_PROXY, and NO_PROXY.
+	ProxyURL string
+	// CAFile is a PEM bundle of extra root CAs trusted alongside the
+	// system pool, e.g. for a TLS-inspecting corporate proxy.
+	CAFile string
+	// UserAgent is sent on every request that does not set its own, so
+	// provider and indexer operators can identify the agent's traffic.
+	// Empty leaves Go's default. See UserAgent.
+	UserAgent string
+	// PinnedSPKI, when non-empty, restricts TLS connections to servers
+	// whose leaf certificate's SubjectPublicKeyInfo has one of these
+	// SHA-256 hashes, base64-encoded with an optional "sha256/" prefix.
+	// Pins apply to every TLS host the agent reaches, including chain RPC,
+	// on top of normal CA verification.
+	PinnedSPKI []string
+}
+
+func (cfg HTTPConfig) isZero() bool {
+	return cfg.ProxyURL == "" && cfg.CAFile == "" && cfg.UserAgent == "" && len(cfg.PinnedSPKI) == 0
+}
+
+// UserAgent returns the default User-Agent, "agent-inference/<version>",
+// with the agent ID appended as a comment when agentID is non-empty. An
+// empty version reads as "dev".
+func UserAgent(version, agentID string) string {
+	if version == "" {
+		version = "dev"
+	}
+	ua := "agent-inference/" + version
+	if agentID != "" {
+		ua += " (agent " + agentID + ")"
+	}
+	return ua
+}
+
+// NewHTTPTransport builds the shared transport for cfg. A zero cfg returns
+// nil, which clients treat as http.DefaultTransport; that already honors
+// the proxy environment variables.
+func NewHTTPTransport(cfg HTTPConfig) (http.RoundTripper, error) {
+	if cfg.isZero() {
+		return nil, nil
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("zerog: invalid proxy URL: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("zerog: unsupported proxy scheme %q", u.Scheme)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("zerog: read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("zerog: no certificates found in CA file %s", cfg.CAFile)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	if len(cfg.PinnedSPKI) > 0 {
+		verify, err := pinVerifier(cfg.PinnedSPKI)
+		if err != nil {
+			return nil, err
+		}
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		t.TLSClientConfig.VerifyConnection = verify
+	}
+
+	if cfg.UserAgent != "" {
+		return &userAgentTransport{base: t, userAgent: cfg.UserAgent}, nil
+	}
+	return t, nil
+}
+
+// SPKIHash returns the pin for cert as accepted by HTTPConfig.PinnedSPKI.
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// pinVerifier returns a tls.Config.VerifyConnection callback accepting only
+// leaf certificates whose SPKI hash is in pins.
+func pinVerifier(pins []string) (func(tls.ConnectionState) error, error) {
+	set := make(map[string]bool, len(pins))
+	for _, p := range pins {
+		p = strings.TrimPrefix(strings.TrimSpace(p), "sha256/")
+		if b, err := base64.StdEncoding.DecodeString(p); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("zerog: invalid SPKI pin %q: want a base64 SHA-256 hash", p)
+		}
+		set[p] = true
+	}
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return fmt.Errorf("%w: %s presented no certificate", ErrCertificatePin, cs.ServerName)
+		}
+		if pin := SPKIHash(cs.PeerCertificates[0]); !set[pin] {
+			return fmt.Errorf("%w: %s has sha256/%s", ErrCertificatePin, cs.ServerName, pin)
+		}
+		return nil
+	}, nil
+}
+
+// userAgentTransport sets User-Agent on requests that lack one.
+type userAgentTransport struct {
+	base      *http.Transport
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// CloseIdleConnections lets http.Client.CloseIdleConnections reach the
+// wrapped transport.
+func (t *userAgentTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
@@ -0,0 +1,161 @@
+package zerog
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewHTTPTransport_ZeroConfig(t *testing.T) {
+	rt, err := NewHTTPTransport(HTTPConfig{})
+	if err != nil || rt != nil {
+		t.Fatalf("expected nil transport for zero config, got %v, %v", rt, err)
+	}
+}
+
+func TestNewHTTPTransport_ProxyURL(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	rt, err := NewHTTPTransport(HTTPConfig{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: rt}).Get("http://provider.invalid/v1/models")
+	if err != nil {
+		t.Fatalf("request via proxy failed: %v", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://provider.invalid/v1/models" {
+		t.Errorf("proxy saw %q", proxied)
+	}
+}
+
+func TestNewHTTPTransport_CAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rt, err := NewHTTPTransport(HTTPConfig{CAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected TLS to verify against CA file: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewHTTPTransport_Invalid(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a cert"), 0o600)
+
+	for name, cfg := range map[string]HTTPConfig{
+		"bad scheme": {ProxyURL: "ftp://proxy:21"},
+		"missing CA": {CAFile: filepath.Join(t.TempDir(), "nope.pem")},
+		"no certs":   {CAFile: empty},
+		"unparsable": {ProxyURL: "http://[::1"},
+	} {
+		if _, err := NewHTTPTransport(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNewHTTPTransport_UserAgent(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.UserAgent())
+	}))
+	defer srv.Close()
+
+	ua := UserAgent("v1.2.3", "agent-7")
+	rt, err := NewHTTPTransport(HTTPConfig{UserAgent: ua})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rt}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// A request that names itself keeps its own User-Agent.
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("User-Agent", "explicit/1")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if ua != "agent-inference/v1.2.3 (agent agent-7)" {
+		t.Errorf("unexpected default user agent %q", ua)
+	}
+	if len(got) != 2 || got[0] != ua || got[1] != "explicit/1" {
+		t.Errorf("server saw user agents %q", got)
+	}
+}
+
+func TestNewHTTPTransport_PinnedSPKI(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	otherKey := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name    string
+		pins    []string
+		wantErr bool
+	}{
+		{"matching pin", []string{otherKey, "sha256/" + SPKIHash(srv.Certificate())}, false},
+		{"non-matching pin", []string{otherKey}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, err := NewHTTPTransport(HTTPConfig{CAFile: caFile, PinnedSPKI: tt.pins})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+			if tt.wantErr {
+				if !errors.Is(err, ErrCertificatePin) {
+					t.Fatalf("expected ErrCertificatePin, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected pinned connection to succeed: %v", err)
+			}
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestNewHTTPTransport_InvalidPin(t *testing.T) {
+	if _, err := NewHTTPTransport(HTTPConfig{PinnedSPKI: []string{"not-a-hash"}}); err == nil {
+		t.Fatal("expected an error for a malformed pin")
+	}
+}
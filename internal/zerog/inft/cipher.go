@@ -0,0 +1,70 @@
+package inft
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	encryptionAlgorithm = "AES-256-GCM"
+	chachaAlgorithm     = "ChaCha20-Poly1305"
+	// compressionPrefix marks metadata gzipped before encryption.
+	compressionPrefix   = "gzip+"
+	compressedAlgorithm = compressionPrefix + encryptionAlgorithm
+)
+
+// metadataCiphers maps EncryptedMeta.Algorithm names to AEAD constructors.
+// All take a 32-byte key.
+var metadataCiphers = map[string]func(key []byte) (cipher.AEAD, error){
+	encryptionAlgorithm: newAESGCM,
+	chachaAlgorithm:     chacha20poly1305.New,
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SupportedAlgorithm reports whether name is a metadata encryption
+// algorithm this package can produce and read.
+func SupportedAlgorithm(name string) bool {
+	_, ok := metadataCiphers[name]
+	return ok
+}
+
+// metadataCipher returns the AEAD for an EncryptedMeta.Algorithm value and
+// whether the plaintext was compressed. An empty algorithm is AES-256-GCM,
+// as written before the field was populated.
+func metadataCipher(algorithm string, key []byte) (cipher.AEAD, bool, error) {
+	name, compressed := strings.CutPrefix(algorithm, compressionPrefix)
+	if name == "" && !compressed {
+		name = encryptionAlgorithm
+	}
+	newAEAD, ok := metadataCiphers[name]
+	if !ok {
+		return nil, false, fmt.Errorf("inft: unsupported algorithm %q (supported: %s): %w",
+			algorithm, strings.Join(supportedAlgorithms(), ", "), ErrEncryptionFailed)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, false, fmt.Errorf("inft: failed to create %s cipher: %w", name, ErrEncryptionFailed)
+	}
+	return aead, compressed, nil
+}
+
+func supportedAlgorithms() []string {
+	names := make([]string, 0, len(metadataCiphers))
+	for name := range metadataCiphers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,73 +1,142 @@
 package inft
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/hkdf"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 )
 
-const encryptionAlgorithm = "AES-256-GCM"
+// keyDerivationInfo is the HKDF context string for per-iNFT metadata keys.
+const keyDerivationInfo = "agent-inference/inft-metadata/v1"
 
-// encryptMetadata encrypts a metadata map using AES-256-GCM.
-// The key must be exactly 32 bytes for AES-256.
-func encryptMetadata(key []byte, keyID string, meta map[string]string) (*EncryptedMeta, error) {
-	if len(key) != 32 {
-		return nil, fmt.Errorf("inft: encryption key must be 32 bytes, got %d: %w", len(key), ErrEncryptionFailed)
+// deriveMetadataKey derives a 32-byte per-iNFT key from the master key and
+// salt with HKDF-SHA256.
+func deriveMetadataKey(master, salt []byte) ([]byte, error) {
+	key, err := hkdf.Key(sha256.New, master, salt, keyDerivationInfo, 32)
+	if err != nil {
+		return nil, fmt.Errorf("inft: derive metadata key: %w", ErrEncryptionFailed)
 	}
+	return key, nil
+}
 
-	plaintext, err := json.Marshal(meta)
-	if err != nil {
-		return nil, fmt.Errorf("inft: failed to serialize metadata: %w", err)
+// keySalt returns the HKDF salt for a mint: the job ID, or random bytes
+// when the job has none.
+func keySalt(jobID string) ([]byte, error) {
+	if jobID != "" {
+		return []byte(jobID), nil
+	}
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("inft: generate key salt: %w", ErrEncryptionFailed)
 	}
+	return salt, nil
+}
+
+// maxMetadataBytes bounds decompressed metadata so a hostile blob cannot
+// inflate without limit.
+const maxMetadataBytes = 16 << 20
 
-	block, err := aes.NewCipher(key)
+// aadContextJobID marks metadata bound to its MintRequest.InferenceJobID.
+const aadContextJobID = "inference_job_id"
+
+// encryptMetadata encrypts a metadata map with the named AEAD algorithm;
+// empty means AES-256-GCM. The key must be exactly 32 bytes. aad, if non-nil, is
+// authenticated but not encrypted; the same aad must be passed to
+// decryptMetadata, which binds the ciphertext to that context. If compress
+// is set the JSON is gzipped first, but only when that makes it smaller;
+// Algorithm records which was done.
+//
+// With recipients, key is ignored: the metadata is sealed with a fresh
+// random key, which is stored ECIES-wrapped for each recipient so any of
+// them can decrypt it with DecryptMetadataFor.
+func encryptMetadata(key []byte, keyID, algorithm string, meta map[string]string, aad []byte, compress bool, recipients []*ecdsa.PublicKey) (*EncryptedMeta, error) {
+	var wrapped [][]byte
+	if len(recipients) > 0 {
+		var err error
+		if key, wrapped, err = wrapDataKey(recipients); err != nil {
+			return nil, err
+		}
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("inft: encryption key must be 32 bytes, got %d: %w", len(key), ErrEncryptionFailed)
+	}
+	if algorithm == "" {
+		algorithm = encryptionAlgorithm
+	}
+	aead, _, err := metadataCipher(algorithm, key)
 	if err != nil {
-		return nil, fmt.Errorf("inft: failed to create cipher: %w", ErrEncryptionFailed)
+		return nil, err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	plaintext, err := json.Marshal(meta)
 	if err != nil {
-		return nil, fmt.Errorf("inft: failed to create GCM: %w", ErrEncryptionFailed)
+		return nil, fmt.Errorf("inft: failed to serialize metadata: %w", err)
+	}
+	if compress {
+		packed, err := gzipMetadata(plaintext)
+		if err != nil {
+			return nil, err
+		}
+		if len(packed) < len(plaintext) {
+			plaintext, algorithm = packed, compressionPrefix+algorithm
+		}
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
+	nonce := make([]byte, aead.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, fmt.Errorf("inft: failed to generate nonce: %w", ErrEncryptionFailed)
 	}
 
-	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+	ciphertext := aead.Seal(nil, nonce, plaintext, aad)
 
 	return &EncryptedMeta{
-		Ciphertext: ciphertext,
-		Nonce:      nonce,
-		KeyID:      keyID,
-		Algorithm:  encryptionAlgorithm,
+		Ciphertext:  ciphertext,
+		Nonce:       nonce,
+		KeyID:       keyID,
+		Algorithm:   algorithm,
+		WrappedKeys: wrapped,
 	}, nil
 }
 
-// decryptMetadata decrypts AES-256-GCM encrypted metadata.
-func decryptMetadata(key []byte, enc *EncryptedMeta) (map[string]string, error) {
+// decryptMetadata decrypts metadata with the algorithm recorded in
+// enc.Algorithm, failing clearly on unknown ones. It fails if aad
+// differs from the value used at encryption. key is the master key; if enc
+// carries a KeySalt the per-iNFT key is re-derived from it.
+func decryptMetadata(key []byte, enc *EncryptedMeta, aad []byte) (map[string]string, error) {
 	if len(key) != 32 {
 		return nil, fmt.Errorf("inft: decryption key must be 32 bytes, got %d: %w", len(key), ErrEncryptionFailed)
 	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, fmt.Errorf("inft: failed to create cipher: %w", ErrEncryptionFailed)
+	if len(enc.KeySalt) > 0 {
+		derived, err := deriveMetadataKey(key, enc.KeySalt)
+		if err != nil {
+			return nil, err
+		}
+		key = derived
 	}
-
-	gcm, err := cipher.NewGCM(block)
+	aead, compressed, err := metadataCipher(enc.Algorithm, key)
 	if err != nil {
-		return nil, fmt.Errorf("inft: failed to create GCM: %w", ErrEncryptionFailed)
+		return nil, err
+	}
+	if len(enc.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("inft: %d-byte nonce, %s needs %d: %w",
+			len(enc.Nonce), enc.Algorithm, aead.NonceSize(), ErrEncryptionFailed)
 	}
 
-	plaintext, err := gcm.Open(nil, enc.Nonce, enc.Ciphertext, nil)
+	plaintext, err := aead.Open(nil, enc.Nonce, enc.Ciphertext, aad)
 	if err != nil {
 		return nil, fmt.Errorf("inft: decryption failed: %w", ErrEncryptionFailed)
 	}
+	if compressed {
+		if plaintext, err = gunzipMetadata(plaintext); err != nil {
+			return nil, err
+		}
+	}
 
 	var meta map[string]string
 	if err := json.Unmarshal(plaintext, &meta); err != nil {
@@ -76,3 +145,36 @@ func decryptMetadata(key []byte, enc *EncryptedMeta) (map[string]string, error)
 
 	return meta, nil
 }
+
+// gzipMetadata compresses serialized metadata.
+func gzipMetadata(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, fmt.Errorf("inft: gzip writer: %w", err)
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("inft: gzip write: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("inft: gzip close: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzipMetadata inflates decrypted metadata, up to maxMetadataBytes.
+func gunzipMetadata(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("inft: open compressed metadata: %w", err)
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxMetadataBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("inft: inflate metadata: %w", err)
+	}
+	if len(out) > maxMetadataBytes {
+		return nil, fmt.Errorf("inft: metadata exceeds %d bytes when inflated", maxMetadataBytes)
+	}
+	return out, nil
+}
@@ -1,8 +1,16 @@
 package inft
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 func TestEncryptMetadata_Roundtrip(t *testing.T) {
@@ -18,7 +26,7 @@ func TestEncryptMetadata_Roundtrip(t *testing.T) {
 		"duration": "1.5s",
 	}
 
-	encrypted, err := encryptMetadata(key, "key-1", meta)
+	encrypted, err := encryptMetadata(key, "key-1", "", meta, nil, false, nil)
 	if err != nil {
 		t.Fatalf("encrypt failed: %v", err)
 	}
@@ -36,7 +44,7 @@ func TestEncryptMetadata_Roundtrip(t *testing.T) {
 		t.Error("nonce is empty")
 	}
 
-	decrypted, err := decryptMetadata(key, encrypted)
+	decrypted, err := decryptMetadata(key, encrypted, nil)
 	if err != nil {
 		t.Fatalf("decrypt failed: %v", err)
 	}
@@ -54,12 +62,12 @@ func TestEncryptMetadata_EmptyMap(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	encrypted, err := encryptMetadata(key, "key-1", map[string]string{})
+	encrypted, err := encryptMetadata(key, "key-1", "", map[string]string{}, nil, false, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	decrypted, err := decryptMetadata(key, encrypted)
+	decrypted, err := decryptMetadata(key, encrypted, nil)
 	if err != nil {
 		t.Fatalf("decrypt failed: %v", err)
 	}
@@ -81,7 +89,7 @@ func TestEncryptMetadata_InvalidKeySize(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			key := make([]byte, tt.keySize)
-			_, err := encryptMetadata(key, "key-1", map[string]string{"k": "v"})
+			_, err := encryptMetadata(key, "key-1", "", map[string]string{"k": "v"}, nil, false, nil)
 			if err == nil {
 				t.Error("expected error for invalid key size")
 			}
@@ -95,13 +103,263 @@ func TestDecryptMetadata_WrongKey(t *testing.T) {
 	rand.Read(key1)
 	rand.Read(key2)
 
-	encrypted, err := encryptMetadata(key1, "key-1", map[string]string{"secret": "data"})
+	encrypted, err := encryptMetadata(key1, "key-1", "", map[string]string{"secret": "data"}, nil, false, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	_, err = decryptMetadata(key2, encrypted)
+	_, err = decryptMetadata(key2, encrypted, nil)
 	if err == nil {
 		t.Error("expected error when decrypting with wrong key")
 	}
 }
+
+func TestEncryptMetadata_AADRoundtrip(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	encrypted, err := encryptMetadata(key, "key-1", "", map[string]string{"secret": "data"}, []byte("job-1"), false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := decryptMetadata(key, encrypted, []byte("job-1"))
+	if err != nil {
+		t.Fatalf("decrypt with matching AAD: %v", err)
+	}
+	if decrypted["secret"] != "data" {
+		t.Errorf("expected secret=data, got %q", decrypted["secret"])
+	}
+}
+
+func TestDecryptMetadata_WrongAAD(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	encrypted, err := encryptMetadata(key, "key-1", "", map[string]string{"secret": "data"}, []byte("job-1"), false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, aad := range map[string][]byte{"other": []byte("job-2"), "missing": nil} {
+		if _, err := decryptMetadata(key, encrypted, aad); !errors.Is(err, ErrEncryptionFailed) {
+			t.Errorf("%s AAD: expected ErrEncryptionFailed, got %v", name, err)
+		}
+	}
+}
+
+func TestEncryptMetadata_Compressed(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	large := map[string]string{"result": strings.Repeat("the quick brown fox ", 500)}
+	plain, err := encryptMetadata(key, "key-1", "", large, nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := encryptMetadata(key, "key-1", "", large, nil, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packed.Algorithm != compressedAlgorithm {
+		t.Errorf("algorithm = %q, want %q", packed.Algorithm, compressedAlgorithm)
+	}
+	if len(packed.Ciphertext) >= len(plain.Ciphertext) {
+		t.Errorf("compressed ciphertext %d bytes, plain %d", len(packed.Ciphertext), len(plain.Ciphertext))
+	}
+	decrypted, err := decryptMetadata(key, packed, nil)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if decrypted["result"] != large["result"] {
+		t.Error("compressed round trip mismatch")
+	}
+
+	// Tiny metadata grows under gzip, so it stays uncompressed.
+	small, err := encryptMetadata(key, "key-1", "", map[string]string{"k": "v"}, nil, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.Algorithm != encryptionAlgorithm {
+		t.Errorf("small metadata algorithm = %q, want %q", small.Algorithm, encryptionAlgorithm)
+	}
+}
+
+func TestDecryptMetadata_DerivedKey(t *testing.T) {
+	master := make([]byte, 32)
+	rand.Read(master)
+	m := &minter{cfg: MinterConfig{EncryptionKey: master, DeriveKeys: true}}
+
+	key1, salt1, err := m.metadataKey("job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, _, err := m.metadataKey("job-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key1, master) || bytes.Equal(key1, key2) {
+		t.Fatal("expected distinct per-job keys different from the master key")
+	}
+
+	encrypted, err := encryptMetadata(key1, "key-1", "", map[string]string{"secret": "data"}, nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted.KeySalt = salt1
+
+	decrypted, err := decryptMetadata(master, encrypted, nil)
+	if err != nil {
+		t.Fatalf("decrypt with master key: %v", err)
+	}
+	if decrypted["secret"] != "data" {
+		t.Errorf("expected secret=data, got %q", decrypted["secret"])
+	}
+
+	// A derived key does not open other tokens' metadata.
+	encrypted.KeySalt = nil
+	if _, err := decryptMetadata(key2, encrypted, nil); err == nil {
+		t.Error("expected another job's key to fail")
+	}
+}
+
+func TestEncryptMetadata_Algorithms(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	meta := map[string]string{"result": strings.Repeat("payload ", 200)}
+
+	for _, alg := range []string{encryptionAlgorithm, chachaAlgorithm} {
+		for _, compress := range []bool{false, true} {
+			enc, err := encryptMetadata(key, "key-1", alg, meta, []byte("job-1"), compress, nil)
+			if err != nil {
+				t.Fatalf("%s: encrypt: %v", alg, err)
+			}
+			want := alg
+			if compress {
+				want = compressionPrefix + alg
+			}
+			if enc.Algorithm != want {
+				t.Errorf("algorithm = %q, want %q", enc.Algorithm, want)
+			}
+			decrypted, err := decryptMetadata(key, enc, []byte("job-1"))
+			if err != nil {
+				t.Fatalf("%s: decrypt: %v", enc.Algorithm, err)
+			}
+			if decrypted["result"] != meta["result"] {
+				t.Errorf("%s: round trip mismatch", enc.Algorithm)
+			}
+		}
+	}
+}
+
+func TestMetadataCipher_UnknownAlgorithm(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	if _, err := encryptMetadata(key, "key-1", "DES", map[string]string{}, nil, false, nil); !errors.Is(err, ErrEncryptionFailed) {
+		t.Errorf("encrypt with DES: expected ErrEncryptionFailed, got %v", err)
+	}
+
+	enc, err := encryptMetadata(key, "key-1", "", map[string]string{"k": "v"}, nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.Algorithm = "gzip+XChaCha20"
+	_, err = decryptMetadata(key, enc, nil)
+	if !errors.Is(err, ErrEncryptionFailed) || !strings.Contains(err.Error(), "unsupported algorithm") {
+		t.Errorf("expected unsupported algorithm error, got %v", err)
+	}
+}
+
+func TestEncryptMetadata_MultipleRecipients(t *testing.T) {
+	operator, _ := crypto.GenerateKey()
+	auditor, _ := crypto.GenerateKey()
+	outsider, _ := crypto.GenerateKey()
+	meta := map[string]string{"result": "inference output data"}
+	master := make([]byte, 32)
+	rand.Read(master)
+
+	enc, err := encryptMetadata(master, "key-1", "", meta, []byte("job-1"), true,
+		[]*ecdsa.PublicKey{&operator.PublicKey, &auditor.PublicKey})
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if len(enc.WrappedKeys) != 2 {
+		t.Fatalf("expected 2 wrapped keys, got %d", len(enc.WrappedKeys))
+	}
+
+	// Survive the on-chain JSON round trip.
+	data, _ := json.Marshal(enc)
+	var stored EncryptedMeta
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, key := range map[string]*ecdsa.PrivateKey{"operator": operator, "auditor": auditor} {
+		got, err := DecryptMetadataFor(key, &stored, []byte("job-1"))
+		if err != nil {
+			t.Fatalf("%s: decrypt: %v", name, err)
+		}
+		if got["result"] != meta["result"] {
+			t.Errorf("%s: round trip mismatch: %v", name, got)
+		}
+	}
+
+	if _, err := DecryptMetadataFor(outsider, &stored, []byte("job-1")); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("outsider: expected ErrNotRecipient, got %v", err)
+	}
+	if _, err := decryptMetadata(master, &stored, []byte("job-1")); !errors.Is(err, ErrEncryptionFailed) {
+		t.Errorf("master key: expected ErrEncryptionFailed, got %v", err)
+	}
+	if _, err := DecryptMetadataFor(auditor, &stored, []byte("job-2")); !errors.Is(err, ErrEncryptionFailed) {
+		t.Errorf("wrong AAD: expected ErrEncryptionFailed, got %v", err)
+	}
+}
+
+func TestResealMetadata_DropsRecipient(t *testing.T) {
+	operator, _ := crypto.GenerateKey()
+	auditor, _ := crypto.GenerateKey()
+	meta := map[string]string{"result": "inference output data"}
+
+	enc, err := encryptMetadata(nil, "key-1", chachaAlgorithm, meta, []byte("job-1"), false,
+		[]*ecdsa.PublicKey{&operator.PublicKey, &auditor.PublicKey})
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	enc.AADContext = aadContextJobID
+
+	resealed, err := ResealMetadata(operator, enc, []byte("job-1"), []*ecdsa.PublicKey{&operator.PublicKey})
+	if err != nil {
+		t.Fatalf("reseal: %v", err)
+	}
+	if resealed.KeyID != "key-1" || resealed.Algorithm != chachaAlgorithm || resealed.AADContext != aadContextJobID {
+		t.Errorf("reseal changed key ID, algorithm, or AAD context: %+v", resealed)
+	}
+	if got, err := DecryptMetadataFor(operator, resealed, []byte("job-1")); err != nil || got["result"] != meta["result"] {
+		t.Errorf("operator: got %v, %v", got, err)
+	}
+	if _, err := DecryptMetadataFor(auditor, resealed, []byte("job-1")); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("dropped auditor: expected ErrNotRecipient, got %v", err)
+	}
+	if _, err := ResealMetadata(auditor, resealed, []byte("job-1"), []*ecdsa.PublicKey{&auditor.PublicKey}); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("non-recipient reseal: expected ErrNotRecipient, got %v", err)
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	a, _ := crypto.GenerateKey()
+	b, _ := crypto.GenerateKey()
+	list := hexutil.Encode(crypto.CompressPubkey(&a.PublicKey)) + ", " +
+		hexutil.Encode(crypto.FromECDSAPub(&b.PublicKey))[2:]
+
+	keys, err := ParseRecipients(list)
+	if err != nil {
+		t.Fatalf("ParseRecipients: %v", err)
+	}
+	if len(keys) != 2 || !keys[0].Equal(&a.PublicKey) || !keys[1].Equal(&b.PublicKey) {
+		t.Errorf("parsed keys do not match inputs")
+	}
+	if _, err := ParseRecipients("0x1234"); err == nil {
+		t.Error("expected an error for a short key")
+	}
+}
@@ -7,19 +7,23 @@ package inft
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/lancekrogers/agent-inference/internal/clock"
 	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/storage"
 )
 
 const contractABIJSON = `[
@@ -58,6 +62,17 @@ const contractABIJSON = `[
       {"name": "owner", "type": "address"}
     ]
   },
+  {
+    "name": "getEncryptedMetadata",
+    "type": "function",
+    "stateMutability": "view",
+    "inputs": [
+      {"name": "tokenId", "type": "uint256"}
+    ],
+    "outputs": [
+      {"name": "encryptedMeta", "type": "bytes"}
+    ]
+  },
   {
     "name": "Transfer",
     "type": "event",
@@ -84,18 +99,37 @@ type INFTMinter interface {
 	Mint(ctx context.Context, req MintRequest) (string, error)
 	UpdateMetadata(ctx context.Context, tokenID string, meta EncryptedMeta) error
 	GetStatus(ctx context.Context, tokenID string) (*INFTStatus, error)
+	// VerifyProvenance downloads the token's storage reference from store
+	// and checks its hash under alg, the storage hash algorithm the token
+	// was minted with, against the token's result hash. A mismatch
+	// returns false and an error wrapping ErrProvenanceMismatch. Tokens
+	// minted without a storage reference — output embedded in the
+	// encrypted metadata, or storage skipped after an upload failure —
+	// always fail with ErrNoStorageRef; check those by decrypting the
+	// metadata and hashing its "output" field instead.
+	VerifyProvenance(ctx context.Context, tokenID string, store storage.StorageClient, alg storage.HashAlgorithm) (bool, error)
+	// Close releases minter resources. The minter must not be used
+	// afterwards.
+	Close() error
 }
 
 type minter struct {
 	cfg      MinterConfig
 	backend  zerog.ChainBackend
 	contract *bind.BoundContract
-	key      *ecdsa.PrivateKey
+	signer   zerog.Signer
 	addr     common.Address
+	clock    clock.Clock
 }
 
 // NewMinter creates a new INFTMinter using go-ethereum to interact with 0G Chain.
-func NewMinter(cfg MinterConfig, backend zerog.ChainBackend, key *ecdsa.PrivateKey) INFTMinter {
+func NewMinter(cfg MinterConfig, backend zerog.ChainBackend, signer zerog.Signer) INFTMinter {
+	if cfg.ReceiptPollInterval == 0 {
+		cfg.ReceiptPollInterval = defaultReceiptPollInterval
+	}
+	if cfg.ReceiptTimeout == 0 {
+		cfg.ReceiptTimeout = defaultReceiptTimeout
+	}
 	contractAddr := common.HexToAddress(cfg.ContractAddress)
 	bc := bind.NewBoundContract(contractAddr, contractABI, backend, backend, backend)
 
@@ -103,8 +137,9 @@ func NewMinter(cfg MinterConfig, backend zerog.ChainBackend, key *ecdsa.PrivateK
 		cfg:      cfg,
 		backend:  backend,
 		contract: bc,
-		key:      key,
-		addr:     crypto.PubkeyToAddress(key.PublicKey),
+		signer:   signer,
+		addr:     signer.Address(),
+		clock:    clock.OrReal(cfg.Clock),
 	}
 }
 
@@ -113,23 +148,34 @@ func (m *minter) Mint(ctx context.Context, req MintRequest) (string, error) {
 		return "", fmt.Errorf("inft: context cancelled before mint: %w", err)
 	}
 
-	encrypted, err := encryptMetadata(m.cfg.EncryptionKey, m.cfg.EncryptionKeyID, req.PlaintextMeta)
+	var key, salt []byte
+	if len(m.cfg.Recipients) == 0 {
+		var err error
+		if key, salt, err = m.metadataKey(req.InferenceJobID); err != nil {
+			return "", fmt.Errorf("inft: metadata key for job %s: %w", req.InferenceJobID, err)
+		}
+	}
+	encrypted, err := encryptMetadata(key, m.cfg.EncryptionKeyID, m.cfg.EncryptionAlgorithm, req.PlaintextMeta, []byte(req.InferenceJobID), m.cfg.CompressMetadata, m.cfg.Recipients)
 	if err != nil {
 		return "", fmt.Errorf("inft: encrypt metadata for job %s: %w", req.InferenceJobID, err)
 	}
+	encrypted.AADContext = aadContextJobID
+	encrypted.KeySalt = salt
 
 	encBytes, err := json.Marshal(encrypted)
 	if err != nil {
 		return "", fmt.Errorf("inft: marshal encrypted metadata: %w", err)
 	}
 
-	var resultHash [32]byte
-	copy(resultHash[:], []byte(req.ResultHash))
+	resultHash := resultHashBytes(req.ResultHash)
 
-	opts, err := zerog.MakeTransactOpts(ctx, m.key, m.cfg.ChainID)
+	release, err := m.cfg.TxLimiter.Acquire(ctx)
 	if err != nil {
-		return "", fmt.Errorf("inft: create transact opts: %w", err)
+		return "", fmt.Errorf("inft: mint for job %s: %w", req.InferenceJobID, err)
 	}
+	defer release()
+
+	opts := zerog.SignerTransactOpts(ctx, m.signer, m.cfg.ChainID)
 
 	tx, err := m.contract.Transact(opts, "mint",
 		m.addr, req.Name, req.Description, encBytes, resultHash, req.StorageContentID)
@@ -137,13 +183,13 @@ func (m *minter) Mint(ctx context.Context, req MintRequest) (string, error) {
 		return "", fmt.Errorf("inft: mint tx for job %s: %w", req.InferenceJobID, err)
 	}
 
-	receipt, err := bind.WaitMined(ctx, m.backend, tx)
+	receipt, err := m.waitForReceipt(ctx, tx)
 	if err != nil {
 		return "", fmt.Errorf("inft: wait for mint tx %s: %w", tx.Hash().Hex(), err)
 	}
 
 	if receipt.Status != types.ReceiptStatusSuccessful {
-		return "", fmt.Errorf("inft: mint tx reverted for job %s: %w", req.InferenceJobID, ErrMintFailed)
+		return "", fmt.Errorf("inft: mint tx reverted for job %s: %w", req.InferenceJobID, m.revertError(ctx, tx, receipt))
 	}
 
 	tokenID, err := parseTransferEvent(receipt)
@@ -154,6 +200,36 @@ func (m *minter) Mint(ctx context.Context, req MintRequest) (string, error) {
 	return tokenID.String(), nil
 }
 
+// metadataKey returns the key to seal a job's metadata with and, when
+// DeriveKeys is set, the HKDF salt to record alongside it.
+func (m *minter) metadataKey(jobID string) ([]byte, []byte, error) {
+	if !m.cfg.DeriveKeys || len(m.cfg.EncryptionKey) != 32 {
+		return m.cfg.EncryptionKey, nil, nil
+	}
+	salt, err := keySalt(jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := deriveMetadataKey(m.cfg.EncryptionKey, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, salt, nil
+}
+
+// resultHashBytes converts a MintRequest.ResultHash to the contract's
+// bytes32. Hex-encoded 32-byte hashes are decoded; any other value is copied
+// as raw bytes, truncated to 32.
+func resultHashBytes(h string) [32]byte {
+	var out [32]byte
+	if b, err := hex.DecodeString(strings.TrimPrefix(h, "0x")); err == nil && len(b) == 32 {
+		copy(out[:], b)
+		return out
+	}
+	copy(out[:], []byte(h))
+	return out
+}
+
 func (m *minter) UpdateMetadata(ctx context.Context, tokenID string, meta EncryptedMeta) error {
 	if err := ctx.Err(); err != nil {
 		return fmt.Errorf("inft: context cancelled before update: %w", err)
@@ -169,23 +245,26 @@ func (m *minter) UpdateMetadata(ctx context.Context, tokenID string, meta Encryp
 		return fmt.Errorf("inft: marshal encrypted metadata: %w", err)
 	}
 
-	opts, err := zerog.MakeTransactOpts(ctx, m.key, m.cfg.ChainID)
+	release, err := m.cfg.TxLimiter.Acquire(ctx)
 	if err != nil {
-		return fmt.Errorf("inft: create transact opts: %w", err)
+		return fmt.Errorf("inft: update token %s: %w", tokenID, err)
 	}
+	defer release()
+
+	opts := zerog.SignerTransactOpts(ctx, m.signer, m.cfg.ChainID)
 
 	tx, err := m.contract.Transact(opts, "updateEncryptedMetadata", id, encBytes)
 	if err != nil {
 		return fmt.Errorf("inft: update tx for token %s: %w", tokenID, err)
 	}
 
-	receipt, err := bind.WaitMined(ctx, m.backend, tx)
+	receipt, err := m.waitForReceipt(ctx, tx)
 	if err != nil {
 		return fmt.Errorf("inft: wait for update tx %s: %w", tx.Hash().Hex(), err)
 	}
 
 	if receipt.Status != types.ReceiptStatusSuccessful {
-		return fmt.Errorf("inft: update tx reverted for token %s: %w", tokenID, ErrMintFailed)
+		return fmt.Errorf("inft: update tx reverted for token %s: %w", tokenID, m.revertError(ctx, tx, receipt))
 	}
 
 	return nil
@@ -216,12 +295,79 @@ func (m *minter) GetStatus(ctx context.Context, tokenID string) (*INFTStatus, er
 		return nil, fmt.Errorf("inft: token %s: %w", tokenID, ErrTokenNotFound)
 	}
 
-	return &INFTStatus{
+	status := &INFTStatus{
 		TokenID:         tokenID,
 		Owner:           owner.Hex(),
 		ChainID:         m.cfg.ChainID,
 		ContractAddress: m.cfg.ContractAddress,
-	}, nil
+	}
+
+	// Metadata hash and mint provenance are best-effort: the token exists
+	// (ownerOf succeeded) even if these auxiliary reads are unavailable.
+	if hash, err := m.metadataHash(ctx, id); err == nil {
+		status.MetadataHash = hash
+	}
+	if txHash, mintedAt, err := m.mintProvenance(ctx, id); err == nil {
+		status.TxHash = txHash
+		status.MintedAt = mintedAt
+	}
+
+	return status, nil
+}
+
+// metadataHash reads the token's encrypted metadata and returns its keccak256 hash.
+func (m *minter) metadataHash(ctx context.Context, id *big.Int) (string, error) {
+	var results []interface{}
+	err := m.contract.Call(&bind.CallOpts{Context: ctx}, &results, "getEncryptedMetadata", id)
+	if err != nil {
+		return "", fmt.Errorf("inft: read metadata for token %s: %w", id, err)
+	}
+	if len(results) == 0 {
+		return "", fmt.Errorf("inft: empty metadata for token %s", id)
+	}
+
+	encMeta, ok := results[0].([]byte)
+	if !ok {
+		return "", fmt.Errorf("inft: unexpected metadata type %T", results[0])
+	}
+	return crypto.Keccak256Hash(encMeta).Hex(), nil
+}
+
+// mintProvenance locates the token's mint Transfer event (from the zero
+// address) and returns the mint transaction hash and block timestamp.
+func (m *minter) mintProvenance(ctx context.Context, id *big.Int) (string, time.Time, error) {
+	mintLog, err := m.mintLog(ctx, id)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	header, err := m.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(mintLog.BlockNumber))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("inft: read mint block %d: %w", mintLog.BlockNumber, err)
+	}
+
+	return mintLog.TxHash.Hex(), time.Unix(int64(header.Time), 0).UTC(), nil
+}
+
+// mintLog returns the token's mint Transfer event (from the zero address).
+func (m *minter) mintLog(ctx context.Context, id *big.Int) (types.Log, error) {
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{common.HexToAddress(m.cfg.ContractAddress)},
+		Topics: [][]common.Hash{
+			{contractABI.Events["Transfer"].ID},
+			{common.Hash{}},
+			nil,
+			{common.BigToHash(id)},
+		},
+	}
+
+	logs, err := m.backend.FilterLogs(ctx, query)
+	if err != nil {
+		return types.Log{}, fmt.Errorf("inft: filter mint logs for token %s: %w", id, err)
+	}
+	if len(logs) == 0 {
+		return types.Log{}, fmt.Errorf("inft: mint event for token %s: %w", id, ErrTokenNotFound)
+	}
+	return logs[0], nil
 }
 
 // parseTransferEvent extracts the tokenID from the Transfer(address,address,uint256) event.
@@ -235,3 +381,7 @@ func parseTransferEvent(receipt *types.Receipt) (*big.Int, error) {
 	}
 	return nil, fmt.Errorf("inft: Transfer event not found in receipt")
 }
+
+// Close is a no-op; the minter holds no resources of its own. The chain
+// backend is owned by the caller.
+func (m *minter) Close() error { return nil }
@@ -1,18 +1,24 @@
 package inft
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"crypto/rand"
+	"errors"
 	"math/big"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/lancekrogers/agent-inference/internal/zerog"
 	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
 )
 
@@ -61,7 +67,7 @@ func TestMint_Success(t *testing.T) {
 		ContractAddress: "0x1234567890abcdef1234567890abcdef12345678",
 		EncryptionKey:   encKey,
 		EncryptionKeyID: "key-1",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	tokenID, err := m.Mint(context.Background(), MintRequest{
 		Name:           "Test iNFT",
@@ -90,7 +96,7 @@ func TestMint_ChainUnreachable(t *testing.T) {
 		ContractAddress: "0x1234567890abcdef1234567890abcdef12345678",
 		EncryptionKey:   encKey,
 		EncryptionKeyID: "key-1",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err := m.Mint(context.Background(), MintRequest{
 		Name:          "Test",
@@ -118,7 +124,7 @@ func TestMint_TxReverted(t *testing.T) {
 		ContractAddress: "0x1234567890abcdef1234567890abcdef12345678",
 		EncryptionKey:   encKey,
 		EncryptionKeyID: "key-1",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err := m.Mint(context.Background(), MintRequest{
 		Name:          "Test",
@@ -129,6 +135,62 @@ func TestMint_TxReverted(t *testing.T) {
 	}
 }
 
+// revertDataError mimics the JSON-RPC error geth returns for a reverted
+// eth_call, carrying the ABI-encoded Error(string) payload.
+type revertDataError struct{ data string }
+
+func (e revertDataError) Error() string          { return "execution reverted" }
+func (e revertDataError) ErrorData() interface{} { return e.data }
+
+func encodeRevert(t *testing.T, reason string) []byte {
+	t.Helper()
+	strType, _ := abi.NewType("string", "", nil)
+	packed, err := abi.Arguments{{Type: strType}}.Pack(reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(crypto.Keccak256([]byte("Error(string)"))[:4], packed...)
+}
+
+func TestMint_RevertReason(t *testing.T) {
+	key, encKey := testKey(t)
+	const reason = "ERC721: token already minted"
+
+	backend := &zgtest.MockBackend{
+		ReceiptFn: func(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+			return &types.Receipt{
+				Status:      types.ReceiptStatusFailed,
+				TxHash:      txHash,
+				BlockNumber: big.NewInt(77),
+			}, nil
+		},
+		CallFn: func(_ context.Context, call ethereum.CallMsg) ([]byte, error) {
+			if len(call.Data) < 4 || !bytes.Equal(call.Data[:4], contractABI.Methods["mint"].ID) {
+				t.Errorf("replayed call is not mint: %x", call.Data)
+			}
+			return nil, revertDataError{data: hexutil.Encode(encodeRevert(t, reason))}
+		},
+	}
+
+	m := NewMinter(MinterConfig{
+		ChainID:         16602,
+		ContractAddress: "0x1234567890abcdef1234567890abcdef12345678",
+		EncryptionKey:   encKey,
+		EncryptionKeyID: "key-1",
+	}, backend, zerog.NewLocalSigner(key))
+
+	_, err := m.Mint(context.Background(), MintRequest{
+		Name:          "Test",
+		PlaintextMeta: map[string]string{"k": "v"},
+	})
+	if !errors.Is(err, ErrMintFailed) {
+		t.Fatalf("expected ErrMintFailed, got %v", err)
+	}
+	if !strings.Contains(err.Error(), reason) {
+		t.Errorf("error %q does not include revert reason %q", err, reason)
+	}
+}
+
 func TestMint_ContextCancelled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -141,7 +203,7 @@ func TestMint_ContextCancelled(t *testing.T) {
 		ContractAddress: "0x1234567890abcdef1234567890abcdef12345678",
 		EncryptionKey:   encKey,
 		EncryptionKeyID: "key-1",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err := m.Mint(ctx, MintRequest{
 		Name:          "Test",
@@ -167,7 +229,7 @@ func TestUpdateMetadata_Success(t *testing.T) {
 	m := NewMinter(MinterConfig{
 		ChainID:         16602,
 		ContractAddress: "0x1234567890abcdef1234567890abcdef12345678",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	err := m.UpdateMetadata(context.Background(), "1", EncryptedMeta{
 		Ciphertext: []byte("encrypted"),
@@ -183,21 +245,34 @@ func TestUpdateMetadata_Success(t *testing.T) {
 func TestGetStatus_Success(t *testing.T) {
 	key, _ := testKey(t)
 	testAddr := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	mintTx := common.HexToHash("0xfeed")
+	encMeta := []byte(`{"ciphertext":"abc"}`)
 
-	// ABI-encode an address return value
+	// ABI-encode the ownerOf and getEncryptedMetadata return values
 	addrType, _ := abi.NewType("address", "", nil)
-	encoded, _ := abi.Arguments{{Type: addrType}}.Pack(testAddr)
+	ownerEncoded, _ := abi.Arguments{{Type: addrType}}.Pack(testAddr)
+	bytesType, _ := abi.NewType("bytes", "", nil)
+	metaEncoded, _ := abi.Arguments{{Type: bytesType}}.Pack(encMeta)
 
 	backend := &zgtest.MockBackend{
-		CallFn: func(_ context.Context, _ ethereum.CallMsg) ([]byte, error) {
-			return encoded, nil
+		CallFn: func(_ context.Context, call ethereum.CallMsg) ([]byte, error) {
+			if bytes.Equal(call.Data[:4], contractABI.Methods["getEncryptedMetadata"].ID) {
+				return metaEncoded, nil
+			}
+			return ownerEncoded, nil
+		},
+		FilterLogsFn: func(_ context.Context, _ ethereum.FilterQuery) ([]types.Log, error) {
+			return []types.Log{{BlockNumber: 7, TxHash: mintTx}}, nil
+		},
+		HeaderFn: func(_ context.Context, _ *big.Int) (*types.Header, error) {
+			return &types.Header{Number: big.NewInt(7), Time: 1771632000}, nil
 		},
 	}
 
 	m := NewMinter(MinterConfig{
 		ChainID:         16602,
 		ContractAddress: "0xcontract",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	status, err := m.GetStatus(context.Background(), "1")
 	if err != nil {
@@ -209,6 +284,18 @@ func TestGetStatus_Success(t *testing.T) {
 	if status.ChainID != 16602 {
 		t.Errorf("expected chain 16602, got %d", status.ChainID)
 	}
+	if status.Owner != testAddr.Hex() {
+		t.Errorf("expected owner %s, got %s", testAddr.Hex(), status.Owner)
+	}
+	if status.MetadataHash != crypto.Keccak256Hash(encMeta).Hex() {
+		t.Errorf("unexpected metadata hash %s", status.MetadataHash)
+	}
+	if status.TxHash != mintTx.Hex() {
+		t.Errorf("expected tx hash %s, got %s", mintTx.Hex(), status.TxHash)
+	}
+	if !status.MintedAt.Equal(time.Unix(1771632000, 0)) {
+		t.Errorf("unexpected minted-at %v", status.MintedAt)
+	}
 }
 
 func TestGetStatus_TokenNotFound(t *testing.T) {
@@ -227,10 +314,58 @@ func TestGetStatus_TokenNotFound(t *testing.T) {
 	m := NewMinter(MinterConfig{
 		ChainID:         16602,
 		ContractAddress: "0xcontract",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err := m.GetStatus(context.Background(), "999")
 	if err == nil {
 		t.Fatal("expected error for missing token")
 	}
 }
+
+func TestGetStatus_ABIEncodedCalldata(t *testing.T) {
+	key, _ := testKey(t)
+
+	addrType, _ := abi.NewType("address", "", nil)
+	encoded, _ := abi.Arguments{{Type: addrType}}.Pack(common.HexToAddress("0xabc"))
+
+	want, err := contractABI.Pack("ownerOf", big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+	backend := &zgtest.MockBackend{
+		CallFn: func(_ context.Context, call ethereum.CallMsg) ([]byte, error) {
+			if got == nil {
+				got = call.Data
+			}
+			return encoded, nil
+		},
+	}
+
+	m := NewMinter(MinterConfig{ChainID: 16602, ContractAddress: "0xcontract"}, backend, zerog.NewLocalSigner(key))
+	if _, err := m.GetStatus(context.Background(), "42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Selector (4 bytes) + one 32-byte uint256 word.
+	if len(got) != 36 {
+		t.Fatalf("expected 36 bytes of calldata, got %d", len(got))
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("calldata mismatch:\n got  %x\n want %x", got, want)
+	}
+}
+
+func TestResultHashBytes(t *testing.T) {
+	sum := crypto.Keccak256([]byte("output"))
+	got := resultHashBytes("0x" + common.Bytes2Hex(sum))
+	if !bytes.Equal(got[:], sum) {
+		t.Errorf("expected hex hash to be decoded, got %x", got)
+	}
+
+	raw := resultHashBytes("abc123")
+	if string(raw[:6]) != "abc123" {
+		t.Errorf("expected non-hex value copied as raw bytes, got %x", raw)
+	}
+}
@@ -1,17 +1,25 @@
 package inft
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/zerog"
 )
 
 // Sentinel errors for iNFT operations.
 var (
-	ErrMintFailed       = errors.New("inft: minting transaction failed")
-	ErrTokenNotFound    = errors.New("inft: token not found")
-	ErrEncryptionFailed = errors.New("inft: metadata encryption failed")
-	ErrChainUnreachable = errors.New("inft: 0G Chain RPC unreachable")
-	ErrInsufficientGas  = errors.New("inft: insufficient gas for transaction")
+	ErrMintFailed         = errors.New("inft: minting transaction failed")
+	ErrTokenNotFound      = errors.New("inft: token not found")
+	ErrEncryptionFailed   = errors.New("inft: metadata encryption failed")
+	ErrChainUnreachable   = errors.New("inft: 0G Chain RPC unreachable")
+	ErrInsufficientGas    = errors.New("inft: insufficient gas for transaction")
+	ErrReceiptTimeout     = errors.New("inft: timed out waiting for transaction receipt")
+	ErrProvenanceMismatch = errors.New("inft: stored content does not match token result hash")
+	ErrNoStorageRef       = errors.New("inft: token has no storage reference")
+	ErrNotRecipient       = errors.New("inft: key is not a metadata recipient")
 )
 
 // MintRequest contains the parameters for minting a new iNFT.
@@ -30,6 +38,18 @@ type EncryptedMeta struct {
 	Nonce      []byte `json:"nonce"`
 	KeyID      string `json:"key_id"`
 	Algorithm  string `json:"algorithm"`
+	// AADContext names the value bound to the ciphertext as GCM additional
+	// authenticated data, e.g. "inference_job_id". The value itself is not
+	// stored; decryptors must supply it. Empty means no AAD.
+	AADContext string `json:"aad_context,omitempty"`
+	// KeySalt, when set, means the ciphertext was sealed with a per-iNFT
+	// key derived from the master key by HKDF-SHA256 with this salt.
+	// Empty means the master key was used directly.
+	KeySalt []byte `json:"key_salt,omitempty"`
+	// WrappedKeys, when set, hold the random key the ciphertext was sealed
+	// with, ECIES-encrypted once per recipient public key. Any recipient's
+	// private key decrypts the metadata; the master key does not.
+	WrappedKeys [][]byte `json:"wrapped_keys,omitempty"`
 }
 
 // INFTStatus describes the current state of a minted iNFT.
@@ -57,4 +77,34 @@ type MinterConfig struct {
 	EncryptionKey []byte
 	// EncryptionKeyID identifies the key for rotation tracking.
 	EncryptionKeyID string
+	// EncryptionAlgorithm is the AEAD used for new metadata:
+	// "AES-256-GCM" (default) or "ChaCha20-Poly1305". Decryption follows
+	// each token's recorded Algorithm.
+	EncryptionAlgorithm string
+	// CompressMetadata gzips metadata before encryption when that makes it
+	// smaller, recorded as Algorithm "gzip+<algorithm>".
+	CompressMetadata bool
+	// Recipients, when set, seal each iNFT's metadata with a random key
+	// wrapped by ECIES for every listed secp256k1 public key, so each
+	// holder of a matching private key — e.g. the operator and an auditor
+	// — can decrypt it with DecryptMetadataFor. EncryptionKey and
+	// DeriveKeys are then unused for new tokens. ResealMetadata plus
+	// UpdateMetadata drops a recipient from future reads only: the earlier
+	// ciphertext stays in chain history, readable with their key.
+	Recipients []*ecdsa.PublicKey
+	// DeriveKeys seals each iNFT's metadata with its own key, derived from
+	// EncryptionKey by HKDF with the job ID as salt. Tokens minted without
+	// it keep decrypting with EncryptionKey.
+	DeriveKeys bool
+	// ReceiptPollInterval is the first delay between receipt polls; it
+	// doubles after each miss up to 4s. Default: 500ms.
+	ReceiptPollInterval time.Duration
+	// ReceiptTimeout bounds how long to wait for a transaction to be mined.
+	// Default: 2m.
+	ReceiptTimeout time.Duration
+	// Clock drives receipt polling. Nil uses real time.
+	Clock clock.Clock
+	// TxLimiter, shared with the other chain clients, bounds concurrent
+	// in-flight transactions. Nil means no limit.
+	TxLimiter *zerog.TxLimiter
 }
@@ -0,0 +1,45 @@
+package inft
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	defaultReceiptPollInterval = 500 * time.Millisecond
+	maxReceiptPollInterval     = 4 * time.Second
+	defaultReceiptTimeout      = 2 * time.Minute
+)
+
+// waitForReceipt polls for tx's receipt with exponential backoff, starting
+// at cfg.ReceiptPollInterval and capped at maxReceiptPollInterval. Any mined
+// receipt is returned at once, including failed ones, so callers can report
+// reverts without waiting out the timeout. Lookup errors are treated as
+// "not mined yet" until cfg.ReceiptTimeout elapses.
+func (m *minter) waitForReceipt(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	deadline := m.clock.After(m.cfg.ReceiptTimeout)
+	interval := m.cfg.ReceiptPollInterval
+	var lastErr error
+	for {
+		receipt, err := m.backend.TransactionReceipt(ctx, tx.Hash())
+		if err == nil && receipt != nil {
+			return receipt, nil
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w after %s: %v", ErrReceiptTimeout, m.cfg.ReceiptTimeout, lastErr)
+			}
+			return nil, fmt.Errorf("%w after %s", ErrReceiptTimeout, m.cfg.ReceiptTimeout)
+		case <-m.clock.After(interval):
+		}
+		interval = min(interval*2, maxReceiptPollInterval)
+	}
+}
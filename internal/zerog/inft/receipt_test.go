@@ -0,0 +1,101 @@
+package inft
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/lancekrogers/agent-inference/internal/clock"
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+func TestWaitForReceipt_Backoff(t *testing.T) {
+	key, encKey := testKey(t)
+	start := time.Unix(1700000000, 0)
+	clk := clock.NewFake(start)
+
+	var mu sync.Mutex
+	var polls []time.Duration
+	backend := &zgtest.MockBackend{
+		ReceiptFn: func(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			polls = append(polls, clk.Now().Sub(start))
+			if len(polls) < 3 {
+				return nil, ethereum.NotFound
+			}
+			return &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: txHash}, nil
+		},
+	}
+	m := NewMinter(MinterConfig{EncryptionKey: encKey, Clock: clk}, backend, zerog.NewLocalSigner(key)).(*minter)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.waitForReceipt(context.Background(), types.NewTx(&types.LegacyTx{}))
+		done <- err
+	}()
+
+	clk.BlockUntil(2) // deadline + first poll delay
+	clk.Advance(500 * time.Millisecond)
+	clk.BlockUntil(2)
+	clk.Advance(time.Second)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("waitForReceipt: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waitForReceipt did not return")
+	}
+
+	want := []time.Duration{0, 500 * time.Millisecond, 1500 * time.Millisecond}
+	if len(polls) != len(want) {
+		t.Fatalf("polled at %v, want %v", polls, want)
+	}
+	for i := range want {
+		if polls[i] != want[i] {
+			t.Errorf("poll %d at %v, want %v", i, polls[i], want[i])
+		}
+	}
+}
+
+func TestWaitForReceipt_Timeout(t *testing.T) {
+	key, encKey := testKey(t)
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	backend := &zgtest.MockBackend{
+		ReceiptFn: func(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+			return nil, ethereum.NotFound
+		},
+	}
+	m := NewMinter(MinterConfig{
+		EncryptionKey:  encKey,
+		ReceiptTimeout: time.Second,
+		Clock:          clk,
+	}, backend, zerog.NewLocalSigner(key)).(*minter)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.waitForReceipt(context.Background(), types.NewTx(&types.LegacyTx{}))
+		done <- err
+	}()
+
+	clk.BlockUntil(2)
+	clk.Advance(time.Second)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrReceiptTimeout) {
+			t.Fatalf("expected ErrReceiptTimeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waitForReceipt did not time out")
+	}
+}
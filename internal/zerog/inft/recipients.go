@@ -0,0 +1,103 @@
+package inft
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+)
+
+// wrapDataKey generates a random 32-byte metadata key and ECIES-encrypts it
+// for each recipient, in order.
+func wrapDataKey(recipients []*ecdsa.PublicKey) ([]byte, [][]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, fmt.Errorf("inft: generate data key: %w", ErrEncryptionFailed)
+	}
+	wrapped := make([][]byte, len(recipients))
+	for i, pub := range recipients {
+		ct, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(pub), key, nil, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("inft: wrap data key for recipient %d: %w", i, ErrEncryptionFailed)
+		}
+		wrapped[i] = ct
+	}
+	return key, wrapped, nil
+}
+
+// DecryptMetadataFor decrypts multi-recipient metadata with a recipient's
+// private key, trying it against each wrapped key. aad is the value named
+// by enc.AADContext — for minted tokens, the MintRequest.InferenceJobID —
+// or nil if it is empty. Metadata without wrapped keys, or none that priv
+// opens, fails with ErrNotRecipient.
+func DecryptMetadataFor(priv *ecdsa.PrivateKey, enc *EncryptedMeta, aad []byte) (map[string]string, error) {
+	eciesKey := ecies.ImportECDSA(priv)
+	for _, wrapped := range enc.WrappedKeys {
+		key, err := eciesKey.Decrypt(wrapped, nil, nil)
+		if err != nil || len(key) != 32 {
+			continue
+		}
+		// The data key is used directly; KeySalt applies to master keys only.
+		unsalted := *enc
+		unsalted.KeySalt = nil
+		return decryptMetadata(key, &unsalted, aad)
+	}
+	return nil, fmt.Errorf("inft: %s: %w", crypto.PubkeyToAddress(priv.PublicKey).Hex(), ErrNotRecipient)
+}
+
+// ResealMetadata decrypts enc with a recipient's private key and seals the
+// same metadata afresh for recipients, keeping its key ID, algorithm,
+// compression, and AAD. Pass the result to INFTMinter.UpdateMetadata to
+// change who can read a token from then on. A dropped recipient keeps
+// whatever they already decrypted, and the old ciphertext stays readable
+// to them in the chain's history.
+func ResealMetadata(priv *ecdsa.PrivateKey, enc *EncryptedMeta, aad []byte, recipients []*ecdsa.PublicKey) (*EncryptedMeta, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("inft: reseal needs at least one recipient: %w", ErrEncryptionFailed)
+	}
+	meta, err := DecryptMetadataFor(priv, enc, aad)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, compressed := strings.CutPrefix(enc.Algorithm, compressionPrefix)
+	resealed, err := encryptMetadata(nil, enc.KeyID, algorithm, meta, aad, compressed, recipients)
+	if err != nil {
+		return nil, err
+	}
+	resealed.AADContext = enc.AADContext
+	return resealed, nil
+}
+
+// ParseRecipients parses a comma-separated list of hex secp256k1 public
+// keys, compressed (33 bytes) or uncompressed (65 bytes), with or without
+// a 0x prefix.
+func ParseRecipients(s string) ([]*ecdsa.PublicKey, error) {
+	var keys []*ecdsa.PublicKey
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		raw := common.FromHex(field)
+		var pub *ecdsa.PublicKey
+		var err error
+		switch len(raw) {
+		case 33:
+			pub, err = crypto.DecompressPubkey(raw)
+		case 65:
+			pub, err = crypto.UnmarshalPubkey(raw)
+		default:
+			err = fmt.Errorf("%d bytes, want 33 or 65", len(raw))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("inft: recipient public key %q: %w", field, err)
+		}
+		keys = append(keys, pub)
+	}
+	return keys, nil
+}
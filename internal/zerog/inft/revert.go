@@ -0,0 +1,57 @@
+package inft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// revertError wraps ErrMintFailed with the contract's revert reason, found
+// by replaying tx as an eth_call at the block it was mined in. If the reason
+// cannot be recovered the plain sentinel is returned.
+func (m *minter) revertError(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) error {
+	if reason := m.revertReason(ctx, tx, receipt); reason != "" {
+		return fmt.Errorf("%w: %s", ErrMintFailed, reason)
+	}
+	return ErrMintFailed
+}
+
+func (m *minter) revertReason(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) string {
+	msg := ethereum.CallMsg{
+		From:  m.addr,
+		To:    tx.To(),
+		Gas:   tx.Gas(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+	out, err := m.backend.CallContract(ctx, msg, receipt.BlockNumber)
+	if err == nil {
+		// Some nodes return the revert payload as the call result.
+		reason, _ := abi.UnpackRevert(out)
+		return reason
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if s, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(s); decodeErr == nil {
+				if reason, unpackErr := abi.UnpackRevert(data); unpackErr == nil {
+					return reason
+				}
+			}
+		}
+	}
+	// Without structured data, geth-style nodes still put the reason in the
+	// message as "execution reverted: <reason>".
+	if _, reason, ok := strings.Cut(err.Error(), "execution reverted: "); ok {
+		return reason
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package inft
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog/storage"
+)
+
+// VerifyProvenance recovers the result hash and storage reference the token
+// was minted with from its mint transaction's calldata, since the contract
+// exposes neither through a view function.
+func (m *minter) VerifyProvenance(ctx context.Context, tokenID string, store storage.StorageClient, alg storage.HashAlgorithm) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, fmt.Errorf("inft: context cancelled before verify: %w", err)
+	}
+
+	id, ok := new(big.Int).SetString(tokenID, 10)
+	if !ok {
+		return false, fmt.Errorf("inft: invalid token ID %q", tokenID)
+	}
+
+	resultHash, storageRef, err := m.mintArgs(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if storageRef == "" {
+		return false, fmt.Errorf("inft: verify token %s: %w", tokenID, ErrNoStorageRef)
+	}
+
+	data, err := store.Download(ctx, storageRef)
+	if err != nil {
+		return false, fmt.Errorf("inft: download %s for token %s: %w", storageRef, tokenID, err)
+	}
+
+	sum, err := storage.ContentID(alg, data)
+	if err != nil {
+		return false, fmt.Errorf("inft: hash %s for token %s: %w", storageRef, tokenID, err)
+	}
+	if !strings.EqualFold(sum, hex.EncodeToString(resultHash[:])) {
+		return false, fmt.Errorf("inft: token %s: %w: on-chain result hash 0x%x, storage content %s (%d bytes) hashes to 0x%s under %s",
+			tokenID, ErrProvenanceMismatch, resultHash, storageRef, len(data), sum, alg)
+	}
+	return true, nil
+}
+
+// mintArgs decodes the resultHash and storageRef arguments of the
+// transaction that minted the token.
+func (m *minter) mintArgs(ctx context.Context, id *big.Int) ([32]byte, string, error) {
+	mintLog, err := m.mintLog(ctx, id)
+	if err != nil {
+		return [32]byte{}, "", err
+	}
+
+	tx, _, err := m.backend.TransactionByHash(ctx, mintLog.TxHash)
+	if err != nil {
+		return [32]byte{}, "", fmt.Errorf("inft: read mint tx %s for token %s: %w", mintLog.TxHash.Hex(), id, err)
+	}
+
+	method := contractABI.Methods["mint"]
+	data := tx.Data()
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return [32]byte{}, "", fmt.Errorf("inft: mint tx %s for token %s is not a mint call", mintLog.TxHash.Hex(), id)
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return [32]byte{}, "", fmt.Errorf("inft: decode mint tx %s: %w", mintLog.TxHash.Hex(), err)
+	}
+	resultHash, ok := args[4].([32]byte)
+	if !ok {
+		return [32]byte{}, "", fmt.Errorf("inft: unexpected result hash type %T", args[4])
+	}
+	storageRef, ok := args[5].(string)
+	if !ok {
+		return [32]byte{}, "", fmt.Errorf("inft: unexpected storage ref type %T", args[5])
+	}
+	return resultHash, storageRef, nil
+}
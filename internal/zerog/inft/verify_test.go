@@ -0,0 +1,97 @@
+package inft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+	"github.com/lancekrogers/agent-inference/internal/zerog/storage"
+	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
+)
+
+// blobStore serves Download from an in-memory map; other methods are unused.
+type blobStore struct {
+	storage.StorageClient
+	blobs map[string][]byte
+}
+
+func (s blobStore) Download(_ context.Context, contentID string) ([]byte, error) {
+	data, ok := s.blobs[contentID]
+	if !ok {
+		return nil, fmt.Errorf("no content %s: %w", contentID, storage.ErrNotFound)
+	}
+	return data, nil
+}
+
+func TestVerifyProvenance(t *testing.T) {
+	key, _ := testKey(t)
+	output := []byte("the inference output")
+	mintTx := common.HexToHash("0xfeed")
+
+	tests := []struct {
+		name       string
+		alg        storage.HashAlgorithm
+		storageRef string
+		blobs      map[string][]byte
+		want       bool
+		wantErr    error
+	}{
+		{"matching content", storage.HashSHA256, "root-1", map[string][]byte{"root-1": output}, true, nil},
+		{"matching keccak256 content", storage.HashKeccak256, "root-1", map[string][]byte{"root-1": output}, true, nil},
+		{"tampered content", storage.HashSHA256, "root-1", map[string][]byte{"root-1": []byte("something else")}, false, ErrProvenanceMismatch},
+		{"inline result", storage.HashSHA256, "", nil, false, ErrNoStorageRef},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentID, err := storage.ContentID(tt.alg, output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sum [32]byte
+			copy(sum[:], common.FromHex(contentID))
+			calldata, err := contractABI.Pack("mint", common.Address{}, "name", "desc", []byte("{}"), sum, tt.storageRef)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backend := &zgtest.MockBackend{
+				FilterLogsFn: func(_ context.Context, _ ethereum.FilterQuery) ([]types.Log, error) {
+					return []types.Log{{BlockNumber: 7, TxHash: mintTx}}, nil
+				},
+				TxByHashFn: func(_ context.Context, h common.Hash) (*types.Transaction, error) {
+					if h != mintTx {
+						return nil, ethereum.NotFound
+					}
+					return types.NewTx(&types.LegacyTx{Data: calldata}), nil
+				},
+			}
+			m := NewMinter(MinterConfig{ChainID: 16602, ContractAddress: "0xcontract"}, backend, zerog.NewLocalSigner(key))
+
+			ok, err := m.VerifyProvenance(context.Background(), "1", blobStore{blobs: tt.blobs}, tt.alg)
+			if ok != tt.want {
+				t.Errorf("VerifyProvenance = %v, want %v", ok, tt.want)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyProvenance_TokenNotFound(t *testing.T) {
+	key, _ := testKey(t)
+	m := NewMinter(MinterConfig{ChainID: 16602, ContractAddress: "0xcontract"}, &zgtest.MockBackend{}, zerog.NewLocalSigner(key))
+
+	_, err := m.VerifyProvenance(context.Background(), "9", blobStore{}, storage.HashSHA256)
+	if !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected ErrTokenNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package zerog
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Signer signs 0G Chain transactions on behalf of a single account.
+// Implementations may hold the key locally or delegate to an external
+// signer so the private key never enters the agent process.
+type Signer interface {
+	// Address returns the account the signer signs for.
+	Address() common.Address
+	// SignTx returns a signed copy of tx for the given chain.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// LocalSigner signs with an in-process ECDSA private key.
+type LocalSigner struct {
+	key  *ecdsa.PrivateKey
+	addr common.Address
+}
+
+// NewLocalSigner wraps a private key as a Signer.
+func NewLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key, addr: AddressFromKey(key)}
+}
+
+// Address returns the key's Ethereum address.
+func (s *LocalSigner) Address() common.Address { return s.addr }
+
+// SignTx signs tx with the local key.
+func (s *LocalSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+	if err != nil {
+		return nil, fmt.Errorf("zerog: local sign: %w", err)
+	}
+	return signed, nil
+}
+
+// RemoteSigner delegates signing to a clef-compatible JSON-RPC signer
+// via account_signTransaction.
+type RemoteSigner struct {
+	client *rpc.Client
+	addr   common.Address
+}
+
+// NewRemoteSigner connects to an external signer at url that signs for addr.
+func NewRemoteSigner(ctx context.Context, url string, addr common.Address) (*RemoteSigner, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("zerog: dial remote signer %s: %w", url, err)
+	}
+	return &RemoteSigner{client: client, addr: addr}, nil
+}
+
+// Address returns the account the remote signer signs for.
+func (s *RemoteSigner) Address() common.Address { return s.addr }
+
+// SignTx asks the remote signer to sign tx and verifies the returned
+// transaction was signed by the expected account.
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	var result struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.client.CallContext(ctx, &result, "account_signTransaction", remoteTxArgs(s.addr, tx, chainID)); err != nil {
+		return nil, fmt.Errorf("zerog: remote sign: %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("zerog: decode remote-signed tx: %w", err)
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("zerog: recover remote signer: %w", err)
+	}
+	if sender != s.addr {
+		return nil, fmt.Errorf("zerog: remote signer signed as %s, expected %s", sender.Hex(), s.addr.Hex())
+	}
+	return signed, nil
+}
+
+// Close releases the remote signer connection.
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+// remoteTxArgs builds clef SendTxArgs for tx.
+func remoteTxArgs(from common.Address, tx *types.Transaction, chainID *big.Int) map[string]interface{} {
+	args := map[string]interface{}{
+		"from":    from,
+		"gas":     hexutil.Uint64(tx.Gas()),
+		"value":   (*hexutil.Big)(tx.Value()),
+		"nonce":   hexutil.Uint64(tx.Nonce()),
+		"input":   hexutil.Bytes(tx.Data()),
+		"chainId": (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		args["to"] = tx.To()
+	}
+	if tx.Type() == types.LegacyTxType {
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+	}
+	return args
+}
+
+// SignerTransactOpts creates transaction options that sign through signer.
+func SignerTransactOpts(ctx context.Context, signer Signer, chainID int64) *bind.TransactOpts {
+	id := big.NewInt(chainID)
+	return &bind.TransactOpts{
+		From:    signer.Address(),
+		Context: ctx,
+		Signer: func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if addr != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, tx, id)
+		},
+	}
+}
+
+// Compile-time interface compliance checks.
+var (
+	_ Signer = (*LocalSigner)(nil)
+	_ Signer = (*RemoteSigner)(nil)
+)
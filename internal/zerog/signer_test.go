@@ -0,0 +1,128 @@
+package zerog
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// clefServer emulates account_signTransaction for legacy transactions,
+// signing with signKey.
+func clefServer(t *testing.T, signKey *ecdsa.PrivateKey) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []struct {
+				To       common.Address `json:"to"`
+				Gas      hexutil.Uint64 `json:"gas"`
+				GasPrice *hexutil.Big   `json:"gasPrice"`
+				Value    *hexutil.Big   `json:"value"`
+				Nonce    hexutil.Uint64 `json:"nonce"`
+				Input    hexutil.Bytes  `json:"input"`
+				ChainID  *hexutil.Big   `json:"chainId"`
+			} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.Method != "account_signTransaction" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		args := req.Params[0]
+		tx := types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			To:       &args.To,
+			Value:    args.Value.ToInt(),
+			Gas:      uint64(args.Gas),
+			GasPrice: args.GasPrice.ToInt(),
+			Data:     args.Input,
+		})
+		signed, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), signKey)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		raw, _ := signed.MarshalBinary()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  map[string]interface{}{"raw": hexutil.Bytes(raw)},
+		})
+	}))
+}
+
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0x22E03a6A89B950F1c82ec5e74F8eCa321a105296")
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    3,
+		To:       &to,
+		Value:    big.NewInt(0),
+		Gas:      100000,
+		GasPrice: big.NewInt(1e9),
+		Data:     []byte{0xde, 0xad},
+	})
+}
+
+func TestLocalSigner_SignTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	s := NewLocalSigner(key)
+
+	signed, err := s.SignTx(context.Background(), testTx(), big.NewInt(16602))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(16602)), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != s.Address() {
+		t.Errorf("expected sender %s, got %s", s.Address().Hex(), sender.Hex())
+	}
+}
+
+func TestRemoteSigner_SignTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	srv := clefServer(t, key)
+	defer srv.Close()
+
+	s, err := NewRemoteSigner(context.Background(), srv.URL, AddressFromKey(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	signed, err := s.SignTx(context.Background(), testTx(), big.NewInt(16602))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signed.Nonce() != 3 || string(signed.Data()) != string([]byte{0xde, 0xad}) {
+		t.Error("remote-signed tx does not match the request")
+	}
+}
+
+func TestRemoteSigner_WrongAccount(t *testing.T) {
+	signKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	srv := clefServer(t, signKey)
+	defer srv.Close()
+
+	s, err := NewRemoteSigner(context.Background(), srv.URL, AddressFromKey(otherKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.SignTx(context.Background(), testTx(), big.NewInt(16602)); err == nil {
+		t.Fatal("expected error when remote signer signs as a different account")
+	}
+}
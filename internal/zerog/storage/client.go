@@ -14,10 +14,9 @@ package storage
 import (
 	"bytes"
 	"context"
-	"crypto/ecdsa"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -30,11 +29,19 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 
+	"github.com/lancekrogers/agent-inference/internal/retry"
 	"github.com/lancekrogers/agent-inference/internal/zerog"
 )
 
 const defaultChunkSize = 4 * 1024 * 1024 // 4MB
 
+const (
+	// nodeRetryDelay is the first delay between storage node attempts; it
+	// doubles after each failure up to maxNodeRetryDelay.
+	nodeRetryDelay    = 500 * time.Millisecond
+	maxNodeRetryDelay = 4 * time.Second
+)
+
 const flowABIJSON = `[
   {
     "name": "submit",
@@ -69,21 +76,34 @@ func mustParseABI(raw string) abi.ABI {
 // StorageClient persists and retrieves data from 0G decentralized storage.
 type StorageClient interface {
 	Upload(ctx context.Context, data []byte, meta Metadata) (string, error)
+	// UploadWithReceipt is Upload, also returning the Flow transaction
+	// that anchored the data root.
+	UploadWithReceipt(ctx context.Context, data []byte, meta Metadata) (UploadReceipt, error)
 	Download(ctx context.Context, contentID string) ([]byte, error)
 	List(ctx context.Context, prefix string) ([]Metadata, error)
+	// ListByTag returns the objects tagged tagKey=tagValue, filtered by the
+	// indexer. It does not apply a name prefix; callers wanting both can
+	// check Metadata.Name on the (already narrowed) result.
+	ListByTag(ctx context.Context, tagKey, tagValue string) ([]Metadata, error)
+	// ListMulti lists several prefixes at once and merges the results,
+	// deduplicated by content ID.
+	ListMulti(ctx context.Context, prefixes []string) ([]Metadata, error)
+	// Close releases idle node connections. The client must not be used
+	// afterwards.
+	Close() error
 }
 
 type client struct {
 	cfg        ClientConfig
 	backend    zerog.ChainBackend
 	contract   *bind.BoundContract
-	key        *ecdsa.PrivateKey
+	signer     zerog.Signer
 	httpClient *http.Client
 }
 
 // NewClient creates a new StorageClient connected to 0G Storage.
 // The backend and key are used for Flow contract interactions.
-func NewClient(cfg ClientConfig, backend zerog.ChainBackend, key *ecdsa.PrivateKey) StorageClient {
+func NewClient(cfg ClientConfig, backend zerog.ChainBackend, signer zerog.Signer) StorageClient {
 	if cfg.DefaultChunkSize == 0 {
 		cfg.DefaultChunkSize = defaultChunkSize
 	}
@@ -98,52 +118,105 @@ func NewClient(cfg ClientConfig, backend zerog.ChainBackend, key *ecdsa.PrivateK
 		cfg:      cfg,
 		backend:  backend,
 		contract: bc,
-		key:      key,
+		signer:   signer,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:   60 * time.Second,
+			Transport: cfg.Transport,
 		},
 	}
 }
 
-func (c *client) Upload(ctx context.Context, data []byte, meta Metadata) (string, error) {
-	if err := ctx.Err(); err != nil {
-		return "", fmt.Errorf("storage: context cancelled before upload: %w", err)
+// anchor submits the data root to the Flow contract and waits for the
+// receipt, holding a transaction slot for the duration.
+func (c *client) anchor(ctx context.Context, dataRoot [32]byte, size int64) (*types.Receipt, error) {
+	release, err := c.cfg.TxLimiter.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage: flow submit: %w", err)
 	}
+	defer release()
 
-	// Compute data root (SHA-256 of content)
-	hash := sha256.Sum256(data)
-	dataRoot := hash
+	opts := zerog.SignerTransactOpts(ctx, c.signer, c.cfg.ChainID)
+	tx, err := c.contract.Transact(opts, "submit", dataRoot, big.NewInt(size))
+	if err != nil {
+		return nil, fmt.Errorf("storage: flow submit tx: %w", err)
+	}
+	receipt, err := bind.WaitMined(ctx, c.backend, tx)
+	if err != nil {
+		return nil, fmt.Errorf("storage: wait for flow tx %s: %w", tx.Hash().Hex(), err)
+	}
+	return receipt, nil
+}
 
-	// Submit data root to Flow contract on-chain
-	opts, err := zerog.MakeTransactOpts(ctx, c.key, c.cfg.ChainID)
+func (c *client) Upload(ctx context.Context, data []byte, meta Metadata) (string, error) {
+	receipt, err := c.UploadWithReceipt(ctx, data, meta)
 	if err != nil {
-		return "", fmt.Errorf("storage: create transact opts: %w", err)
+		return "", err
+	}
+	return receipt.ContentID, nil
+}
+
+func (c *client) UploadWithReceipt(ctx context.Context, data []byte, meta Metadata) (UploadReceipt, error) {
+	if err := ctx.Err(); err != nil {
+		return UploadReceipt{}, fmt.Errorf("storage: context cancelled before upload: %w", err)
 	}
 
-	length := new(big.Int).SetInt64(int64(len(data)))
-	tx, err := c.contract.Transact(opts, "submit", dataRoot, length)
+	// Compute data root with the configured content-ID hash
+	dataRoot, err := c.cfg.HashAlgorithm.sum(data)
 	if err != nil {
-		return "", fmt.Errorf("storage: flow submit tx: %w", err)
+		return UploadReceipt{}, err
 	}
+	contentID := common.Bytes2Hex(dataRoot[:])
 
-	receipt, err := bind.WaitMined(ctx, c.backend, tx)
+	// Content-addressed dedup: identical bytes already stored need no new upload.
+	if c.cfg.SkipExisting && c.exists(ctx, contentID) {
+		return UploadReceipt{ContentID: contentID}, nil
+	}
+
+	// Submit data root to Flow contract on-chain
+	receipt, err := c.anchor(ctx, dataRoot, int64(len(data)))
 	if err != nil {
-		return "", fmt.Errorf("storage: wait for flow tx %s: %w", tx.Hash().Hex(), err)
+		return UploadReceipt{}, err
 	}
 	if receipt.Status != types.ReceiptStatusSuccessful {
-		return "", fmt.Errorf("storage: flow submit reverted: %w", ErrUploadFailed)
+		return UploadReceipt{}, fmt.Errorf("storage: flow submit reverted: %w", ErrUploadFailed)
+	}
+	if err := c.checkAnchored(receipt, dataRoot); err != nil {
+		return UploadReceipt{}, err
+	}
+	out := UploadReceipt{ContentID: contentID, TxHash: receipt.TxHash.Hex()}
+	if receipt.BlockNumber != nil {
+		out.BlockNumber = receipt.BlockNumber.Uint64()
 	}
-
-	contentID := common.Bytes2Hex(dataRoot[:])
 
 	// Upload data to storage node if endpoint is configured
 	if endpoint := c.cfg.storageEndpoint(); endpoint != "" {
-		if err := c.uploadToNode(ctx, data, meta, contentID); err != nil {
-			return "", fmt.Errorf("storage: node upload: %w", err)
+		err := retry.Do(ctx, c.nodePolicy(), func(ctx context.Context) error {
+			return c.uploadToNode(ctx, data, meta, contentID)
+		})
+		if err != nil {
+			return UploadReceipt{}, fmt.Errorf("storage: node upload: %w", err)
 		}
 	}
 
-	return contentID, nil
+	return out, nil
+}
+
+// checkAnchored verifies that any DataSubmit event the Flow contract emitted
+// in receipt carries dataRoot. Receipts without the event (nodes that prune
+// logs) are accepted on the strength of the successful status alone.
+func (c *client) checkAnchored(receipt *types.Receipt, dataRoot [32]byte) error {
+	event := flowABI.Events["DataSubmit"]
+	flowAddr := common.HexToAddress(c.cfg.FlowContractAddress)
+	for _, l := range receipt.Logs {
+		if l.Address != flowAddr || len(l.Topics) < 3 || l.Topics[0] != event.ID {
+			continue
+		}
+		if l.Topics[2] != common.Hash(dataRoot) {
+			return fmt.Errorf("storage: flow anchored root %s, want %s: %w",
+				l.Topics[2].Hex(), common.Hash(dataRoot).Hex(), ErrIntegrity)
+		}
+	}
+	return nil
 }
 
 func (c *client) Download(ctx context.Context, contentID string) ([]byte, error) {
@@ -157,7 +230,39 @@ func (c *client) Download(ctx context.Context, contentID string) ([]byte, error)
 	}
 
 	url := fmt.Sprintf("%s/api/storage/%s", endpoint, contentID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	var data []byte
+	err := retry.Do(ctx, c.nodePolicy(), func(ctx context.Context) error {
+		var err error
+		data, err = c.downloadOnce(ctx, url, contentID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.cfg.VerifyDownloads {
+		if err := c.verifyContent(contentID, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// verifyContent checks that data hashes to contentID under the configured
+// algorithm.
+func (c *client) verifyContent(contentID string, data []byte) error {
+	got, err := ContentID(c.cfg.HashAlgorithm, data)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(got, strings.TrimPrefix(contentID, "0x")) {
+		return fmt.Errorf("storage: content %s hashes to %s: %w", contentID, got, ErrIntegrity)
+	}
+	return nil
+}
+
+// downloadOnce makes a single download request to the storage node.
+func (c *client) downloadOnce(ctx context.Context, url, contentID string) ([]byte, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("storage: create download request: %w", err)
 	}
@@ -179,7 +284,30 @@ func (c *client) Download(ctx context.Context, contentID string) ([]byte, error)
 	return io.ReadAll(resp.Body)
 }
 
+// nodePolicy retries storage node requests that could not reach the node,
+// up to cfg.MaxRetries times. Uploads are safe to repeat because the node
+// keys content by its ID.
+func (c *client) nodePolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts: c.cfg.MaxRetries + 1,
+		BaseDelay:   nodeRetryDelay,
+		MaxDelay:    maxNodeRetryDelay,
+		Jitter:      true,
+		Retryable: func(err error) bool {
+			return errors.Is(err, ErrNodeDown)
+		},
+	}
+}
+
 func (c *client) List(ctx context.Context, prefix string) ([]Metadata, error) {
+	return c.list(ctx, listFilter{Prefix: prefix})
+}
+
+func (c *client) ListByTag(ctx context.Context, tagKey, tagValue string) ([]Metadata, error) {
+	return c.list(ctx, listFilter{Tags: map[string]string{tagKey: tagValue}})
+}
+
+func (c *client) list(ctx context.Context, f listFilter) ([]Metadata, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, fmt.Errorf("storage: context cancelled before list: %w", err)
 	}
@@ -189,8 +317,8 @@ func (c *client) List(ctx context.Context, prefix string) ([]Metadata, error) {
 		return nil, fmt.Errorf("storage: no storage node endpoint configured: %w", ErrNodeDown)
 	}
 
-	url := fmt.Sprintf("%s/api/storage?prefix=%s", endpoint, prefix)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	listURL := endpoint + "/api/storage?" + f.query().Encode()
+	req, err := c.newRequest(ctx, http.MethodGet, listURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("storage: create list request: %w", err)
 	}
@@ -218,6 +346,30 @@ func (c *client) List(ctx context.Context, prefix string) ([]Metadata, error) {
 	return listResp.Items, nil
 }
 
+// exists reports whether the storage node already holds contentID.
+// Any failure to check is treated as "not present" so the upload proceeds.
+func (c *client) exists(ctx context.Context, contentID string) bool {
+	endpoint := c.cfg.storageEndpoint()
+	if endpoint == "" {
+		return false
+	}
+
+	url := fmt.Sprintf("%s/api/storage/%s", endpoint, contentID)
+	req, err := c.newRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode == http.StatusOK
+}
+
 func (c *client) uploadToNode(ctx context.Context, data []byte, meta Metadata, contentID string) error {
 	payload := struct {
 		Data        string            `json:"data"`
@@ -239,7 +391,7 @@ func (c *client) uploadToNode(ctx context.Context, data []byte, meta Metadata, c
 	}
 
 	endpoint := c.cfg.storageEndpoint() + "/api/storage"
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	httpReq, err := c.newRequest(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("create upload request: %w", err)
 	}
@@ -257,3 +409,31 @@ func (c *client) uploadToNode(ctx context.Context, data []byte, meta Metadata, c
 	}
 	return nil
 }
+
+// newRequest builds an HTTP request with the configured custom headers and
+// bearer token applied.
+func (c *client) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	token := c.cfg.Token
+	if c.cfg.TokenProvider != nil {
+		if token, err = c.cfg.TokenProvider(ctx); err != nil {
+			return nil, fmt.Errorf("bearer token: %w", err)
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// Close drops idle storage node connections.
+func (c *client) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
@@ -5,14 +5,19 @@ import (
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/lancekrogers/agent-inference/internal/zerog"
 	"github.com/lancekrogers/agent-inference/internal/zerog/zgtest"
 )
 
@@ -47,7 +52,7 @@ func TestUpload_Success(t *testing.T) {
 		ChainID:             16602,
 		FlowContractAddress: "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296",
 		StorageNodeEndpoint: srv.URL,
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	data := []byte("hello world")
 	contentID, err := c.Upload(context.Background(), data, Metadata{Name: "test.txt"})
@@ -70,7 +75,7 @@ func TestUpload_ChainOnly(t *testing.T) {
 	c := NewClient(ClientConfig{
 		ChainID:             16602,
 		FlowContractAddress: "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	contentID, err := c.Upload(context.Background(), []byte("test data"), Metadata{Name: "test"})
 	if err != nil {
@@ -81,6 +86,80 @@ func TestUpload_ChainOnly(t *testing.T) {
 	}
 }
 
+func TestUpload_AnchoredRoot(t *testing.T) {
+	backend, key := testSetup(t)
+	flow := common.HexToAddress("0x22E03a6A89B950F1c82ec5e74F8eCa321a105296")
+	data := []byte("test data")
+
+	anchored := sha256.Sum256(data)
+	for _, tc := range []struct {
+		name    string
+		root    common.Hash
+		wantErr bool
+	}{
+		{"match", common.Hash(anchored), false},
+		{"mismatch", common.HexToHash("0xdead"), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			backend.ReceiptFn = func(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+				return &types.Receipt{
+					Status: types.ReceiptStatusSuccessful,
+					TxHash: txHash,
+					Logs: []*types.Log{{
+						Address: flow,
+						Topics:  []common.Hash{flowABI.Events["DataSubmit"].ID, {}, tc.root},
+					}},
+				}, nil
+			}
+			c := NewClient(ClientConfig{
+				ChainID:             16602,
+				FlowContractAddress: flow.Hex(),
+			}, backend, zerog.NewLocalSigner(key))
+
+			_, err := c.Upload(context.Background(), data, Metadata{Name: "test"})
+			if tc.wantErr != errors.Is(err, ErrIntegrity) {
+				t.Errorf("Upload error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestUploadWithReceipt_AnchorFields(t *testing.T) {
+	backend, key := testSetup(t)
+	backend.ReceiptFn = func(_ context.Context, txHash common.Hash) (*types.Receipt, error) {
+		return &types.Receipt{
+			Status:      types.ReceiptStatusSuccessful,
+			TxHash:      txHash,
+			BlockNumber: big.NewInt(4242),
+		}, nil
+	}
+	var sent common.Hash
+	backend.SendTxFn = func(_ context.Context, tx *types.Transaction) error {
+		sent = tx.Hash()
+		return nil
+	}
+	c := NewClient(ClientConfig{
+		ChainID:             16602,
+		FlowContractAddress: "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296",
+	}, backend, zerog.NewLocalSigner(key))
+
+	data := []byte("anchored data")
+	receipt, err := c.UploadWithReceipt(context.Background(), data, Metadata{Name: "test"})
+	if err != nil {
+		t.Fatalf("UploadWithReceipt: %v", err)
+	}
+	root := sha256.Sum256(data)
+	if receipt.ContentID != common.Bytes2Hex(root[:]) {
+		t.Errorf("ContentID = %s, want %x", receipt.ContentID, root)
+	}
+	if receipt.TxHash != sent.Hex() {
+		t.Errorf("TxHash = %s, want %s", receipt.TxHash, sent.Hex())
+	}
+	if receipt.BlockNumber != 4242 {
+		t.Errorf("BlockNumber = %d, want 4242", receipt.BlockNumber)
+	}
+}
+
 func TestUpload_ContextCancelled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -89,7 +168,7 @@ func TestUpload_ContextCancelled(t *testing.T) {
 	c := NewClient(ClientConfig{
 		ChainID:             16602,
 		FlowContractAddress: "0xtest",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err := c.Upload(ctx, []byte("data"), Metadata{Name: "test"})
 	if err == nil {
@@ -104,7 +183,7 @@ func TestUpload_ChainError(t *testing.T) {
 	c := NewClient(ClientConfig{
 		ChainID:             16602,
 		FlowContractAddress: "0xtest",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err := c.Upload(context.Background(), []byte("data"), Metadata{Name: "test"})
 	if err == nil {
@@ -124,7 +203,7 @@ func TestDownload_Success(t *testing.T) {
 	backend, key := testSetup(t)
 	c := NewClient(ClientConfig{
 		StorageNodeEndpoint: srv.URL,
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	data, err := c.Download(context.Background(), "cid-123")
 	if err != nil {
@@ -144,7 +223,7 @@ func TestDownload_NotFound(t *testing.T) {
 	backend, key := testSetup(t)
 	c := NewClient(ClientConfig{
 		StorageNodeEndpoint: srv.URL,
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err := c.Download(context.Background(), "cid-missing")
 	if err == nil {
@@ -159,7 +238,7 @@ func TestDownload_ContextCancelled(t *testing.T) {
 	backend, key := testSetup(t)
 	c := NewClient(ClientConfig{
 		StorageNodeEndpoint: "http://example.com",
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	_, err := c.Download(ctx, "cid-123")
 	if err == nil {
@@ -169,7 +248,7 @@ func TestDownload_ContextCancelled(t *testing.T) {
 
 func TestDownload_NoEndpoint(t *testing.T) {
 	backend, key := testSetup(t)
-	c := NewClient(ClientConfig{}, backend, key)
+	c := NewClient(ClientConfig{}, backend, zerog.NewLocalSigner(key))
 
 	_, err := c.Download(context.Background(), "cid-123")
 	if err == nil {
@@ -197,7 +276,7 @@ func TestList_WithResults(t *testing.T) {
 	backend, key := testSetup(t)
 	c := NewClient(ClientConfig{
 		StorageNodeEndpoint: srv.URL,
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	items, err := c.List(context.Background(), "inference/")
 	if err != nil {
@@ -211,6 +290,40 @@ func TestList_WithResults(t *testing.T) {
 	}
 }
 
+func TestListByTag_QueryParams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if got := q.Get("tag.model"); got != "llama 3/8b" {
+			t.Errorf("tag.model = %q, want %q", got, "llama 3/8b")
+		}
+		if q.Has("prefix") {
+			t.Errorf("unexpected prefix param %q", q.Get("prefix"))
+		}
+		resp := struct {
+			Items []Metadata `json:"items"`
+		}{
+			Items: []Metadata{
+				{ContentID: "cid-1", Name: "result-task-1", Tags: map[string]string{"model": "llama 3/8b"}},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	backend, key := testSetup(t)
+	c := NewClient(ClientConfig{
+		StorageNodeEndpoint: srv.URL,
+	}, backend, zerog.NewLocalSigner(key))
+
+	items, err := c.ListByTag(context.Background(), "model", "llama 3/8b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].ContentID != "cid-1" {
+		t.Errorf("unexpected items: %+v", items)
+	}
+}
+
 func TestList_Empty(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		resp := struct {
@@ -223,7 +336,7 @@ func TestList_Empty(t *testing.T) {
 	backend, key := testSetup(t)
 	c := NewClient(ClientConfig{
 		StorageNodeEndpoint: srv.URL,
-	}, backend, key)
+	}, backend, zerog.NewLocalSigner(key))
 
 	items, err := c.List(context.Background(), "empty/")
 	if err != nil {
@@ -236,10 +349,179 @@ func TestList_Empty(t *testing.T) {
 
 func TestList_NoEndpoint(t *testing.T) {
 	backend, key := testSetup(t)
-	c := NewClient(ClientConfig{}, backend, key)
+	c := NewClient(ClientConfig{}, backend, zerog.NewLocalSigner(key))
 
 	_, err := c.List(context.Background(), "test/")
 	if err == nil {
 		t.Fatal("expected error for missing endpoint")
 	}
 }
+
+func TestDownload_CustomHeaders(t *testing.T) {
+	var gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-Api-Key")
+		w.Write([]byte("stored data"))
+	}))
+	defer srv.Close()
+
+	backend, key := testSetup(t)
+	c := NewClient(ClientConfig{
+		StorageNodeEndpoint: srv.URL,
+		Headers:             map[string]string{"X-Api-Key": "secret"},
+	}, backend, zerog.NewLocalSigner(key))
+
+	if _, err := c.Download(context.Background(), "cid-123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotKey != "secret" {
+		t.Errorf("expected X-Api-Key header 'secret', got %q", gotKey)
+	}
+}
+
+func TestRequests_BearerToken(t *testing.T) {
+	var gotAuth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer srv.Close()
+
+	backend, key := testSetup(t)
+	c := NewClient(ClientConfig{
+		StorageNodeEndpoint: srv.URL,
+		Token:               "static-token",
+	}, backend, zerog.NewLocalSigner(key))
+	if _, err := c.Download(context.Background(), "cid-123"); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	n := 0
+	rotating := NewClient(ClientConfig{
+		StorageNodeEndpoint: srv.URL,
+		Token:               "ignored",
+		TokenProvider: func(context.Context) (string, error) {
+			n++
+			return fmt.Sprintf("rotated-%d", n), nil
+		},
+	}, backend, zerog.NewLocalSigner(key))
+	for range 2 {
+		if _, err := rotating.List(context.Background(), ""); err != nil {
+			t.Fatalf("List: %v", err)
+		}
+	}
+
+	want := []string{"Bearer static-token", "Bearer rotated-1", "Bearer rotated-2"}
+	if fmt.Sprint(gotAuth) != fmt.Sprint(want) {
+		t.Errorf("Authorization headers = %q, want %q", gotAuth, want)
+	}
+}
+
+func TestRequests_TokenProviderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent despite token failure")
+	}))
+	defer srv.Close()
+
+	backend, key := testSetup(t)
+	c := NewClient(ClientConfig{
+		StorageNodeEndpoint: srv.URL,
+		TokenProvider: func(context.Context) (string, error) {
+			return "", errors.New("vault sealed")
+		},
+	}, backend, zerog.NewLocalSigner(key))
+	if _, err := c.Download(context.Background(), "cid-123"); err == nil || !strings.Contains(err.Error(), "vault sealed") {
+		t.Fatalf("expected token provider error, got %v", err)
+	}
+}
+
+func TestUpload_SkipExisting(t *testing.T) {
+	backend, key := testSetup(t)
+
+	txCount := 0
+	backend.SendTxFn = func(_ context.Context, _ *types.Transaction) error {
+		txCount++
+		return nil
+	}
+
+	stored := map[string]bool{}
+	uploads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var payload struct {
+				ContentID string `json:"content_id"`
+			}
+			json.NewDecoder(r.Body).Decode(&payload)
+			stored[payload.ContentID] = true
+			uploads++
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			id := strings.TrimPrefix(r.URL.Path, "/api/storage/")
+			if !stored[id] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("stored"))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(ClientConfig{
+		ChainID:             16602,
+		FlowContractAddress: "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296",
+		StorageNodeEndpoint: srv.URL,
+		SkipExisting:        true,
+	}, backend, zerog.NewLocalSigner(key))
+
+	data := []byte("identical output")
+	first, err := c.Upload(context.Background(), data, Metadata{Name: "a"})
+	if err != nil {
+		t.Fatalf("first upload: %v", err)
+	}
+	second, err := c.Upload(context.Background(), data, Metadata{Name: "b"})
+	if err != nil {
+		t.Fatalf("second upload: %v", err)
+	}
+
+	if first != second {
+		t.Errorf("expected identical content IDs, got %s and %s", first, second)
+	}
+	if uploads != 1 {
+		t.Errorf("expected 1 node upload, got %d", uploads)
+	}
+	if txCount != 1 {
+		t.Errorf("expected 1 flow submission, got %d", txCount)
+	}
+}
+
+func TestUpload_ContentIDIndependentOfChunkSize(t *testing.T) {
+	backend, key := testSetup(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	data := []byte(strings.Repeat("x", 64))
+	ids := make(map[string]bool)
+	// Chunk sizes just above and just below the payload length.
+	for _, chunk := range []int64{65, 63} {
+		c := NewClient(ClientConfig{
+			ChainID:             16602,
+			FlowContractAddress: "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296",
+			StorageNodeEndpoint: srv.URL,
+			DefaultChunkSize:    chunk,
+		}, backend, zerog.NewLocalSigner(key))
+
+		id, err := c.Upload(context.Background(), data, Metadata{Name: "chunked.txt"})
+		if err != nil {
+			t.Fatalf("chunk size %d: unexpected error: %v", chunk, err)
+		}
+		ids[id] = true
+	}
+
+	hash := sha256.Sum256(data)
+	if len(ids) != 1 || !ids[common.Bytes2Hex(hash[:])] {
+		t.Errorf("expected a single full-payload SHA-256 content ID, got %v", ids)
+	}
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Codec is a reversible transformation applied to content before upload.
+type Codec interface {
+	// Name identifies the codec in the stored manifest.
+	Name() string
+	Encode(data []byte) ([]byte, error)
+	Decode(data []byte) ([]byte, error)
+}
+
+// codecMagic prefixes content written by UploadEncoded. It is followed by
+// a big-endian uint32 manifest length, the JSON manifest, and the payload.
+var codecMagic = []byte("ZGC1")
+
+// CodecTag is the Metadata.Tags key UploadEncoded sets to the codec chain,
+// so listings show how content is encoded without downloading it.
+const CodecTag = "codec"
+
+// codecManifest records the codecs applied to the payload, in order.
+type codecManifest struct {
+	Codecs []string `json:"codecs"`
+}
+
+// UploadEncoded applies codecs in order, prefixes a manifest describing the
+// chain, and uploads the result. DownloadDecoded reverses it.
+func UploadEncoded(ctx context.Context, c StorageClient, data []byte, meta Metadata, codecs ...Codec) (string, error) {
+	m := codecManifest{Codecs: make([]string, 0, len(codecs))}
+	for _, codec := range codecs {
+		var err error
+		if data, err = codec.Encode(data); err != nil {
+			return "", fmt.Errorf("storage: encode %s: %w", codec.Name(), err)
+		}
+		m.Codecs = append(m.Codecs, codec.Name())
+	}
+
+	header, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("storage: marshal codec manifest: %w", err)
+	}
+	var buf bytes.Buffer
+	buf.Write(codecMagic)
+	binary.Write(&buf, binary.BigEndian, uint32(len(header)))
+	buf.Write(header)
+	buf.Write(data)
+
+	tags := make(map[string]string, len(meta.Tags)+1)
+	for k, v := range meta.Tags {
+		tags[k] = v
+	}
+	tags[CodecTag] = strings.Join(m.Codecs, ",")
+	meta.Tags = tags
+
+	return c.Upload(ctx, buf.Bytes(), meta)
+}
+
+// DownloadDecoded downloads contentID and reverses the codec chain recorded
+// in its manifest, using the provided codecs matched by name. Content
+// without a manifest is returned unchanged.
+func DownloadDecoded(ctx context.Context, c StorageClient, contentID string, codecs ...Codec) ([]byte, error) {
+	raw, err := c.Download(ctx, contentID)
+	if err != nil {
+		return nil, err
+	}
+	m, payload, ok, err := splitManifest(raw)
+	if err != nil {
+		return nil, fmt.Errorf("storage: content %s: %w", contentID, err)
+	}
+	if !ok {
+		return raw, nil
+	}
+
+	byName := make(map[string]Codec, len(codecs))
+	for _, codec := range codecs {
+		byName[codec.Name()] = codec
+	}
+	for i := len(m.Codecs) - 1; i >= 0; i-- {
+		codec, found := byName[m.Codecs[i]]
+		if !found {
+			return nil, fmt.Errorf("storage: content %s needs codec %q: %w", contentID, m.Codecs[i], ErrUnknownCodec)
+		}
+		if payload, err = codec.Decode(payload); err != nil {
+			return nil, fmt.Errorf("storage: decode %s for content %s: %w", m.Codecs[i], contentID, err)
+		}
+	}
+	return payload, nil
+}
+
+// splitManifest separates the codec manifest from the payload. ok is false
+// when raw has no manifest.
+func splitManifest(raw []byte) (codecManifest, []byte, bool, error) {
+	var m codecManifest
+	if !bytes.HasPrefix(raw, codecMagic) {
+		return m, raw, false, nil
+	}
+	rest := raw[len(codecMagic):]
+	if len(rest) < 4 {
+		return m, nil, false, fmt.Errorf("truncated codec manifest: %w", ErrIntegrity)
+	}
+	n := binary.BigEndian.Uint32(rest)
+	rest = rest[4:]
+	if uint64(len(rest)) < uint64(n) {
+		return m, nil, false, fmt.Errorf("truncated codec manifest: %w", ErrIntegrity)
+	}
+	if err := json.Unmarshal(rest[:n], &m); err != nil {
+		return m, nil, false, fmt.Errorf("parse codec manifest: %w", ErrIntegrity)
+	}
+	return m, rest[n:], true, nil
+}
+
+// GzipCodec compresses content with gzip.
+type GzipCodec struct{}
+
+// Name returns "gzip".
+func (GzipCodec) Name() string { return "gzip" }
+
+// Encode gzips data.
+func (GzipCodec) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode gunzips data.
+func (GzipCodec) Decode(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// AESGCMCodec encrypts content with AES-256-GCM. The random nonce is
+// prepended to the ciphertext.
+type AESGCMCodec struct {
+	// Key must be 32 bytes.
+	Key []byte
+}
+
+// Name returns "aes-256-gcm".
+func (AESGCMCodec) Name() string { return "aes-256-gcm" }
+
+// Encode encrypts data.
+func (c AESGCMCodec) Encode(data []byte) ([]byte, error) {
+	gcm, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// Decode decrypts data produced by Encode.
+func (c AESGCMCodec) Decode(data []byte) ([]byte, error) {
+	gcm, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short: %w", ErrIntegrity)
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", ErrIntegrity)
+	}
+	return plaintext, nil
+}
+
+func (c AESGCMCodec) aead() (cipher.AEAD, error) {
+	if len(c.Key) != 32 {
+		return nil, fmt.Errorf("aes-256-gcm key must be 32 bytes, got %d", len(c.Key))
+	}
+	block, err := aes.NewCipher(c.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// memClient is an in-memory StorageClient for codec tests.
+type memClient struct {
+	objects map[string][]byte
+	meta    map[string]Metadata
+}
+
+func newMemClient() *memClient {
+	return &memClient{objects: make(map[string][]byte), meta: make(map[string]Metadata)}
+}
+
+func (m *memClient) Upload(_ context.Context, data []byte, meta Metadata) (string, error) {
+	id := meta.Name
+	m.objects[id] = data
+	m.meta[id] = meta
+	return id, nil
+}
+
+func (m *memClient) UploadWithReceipt(ctx context.Context, data []byte, meta Metadata) (UploadReceipt, error) {
+	id, err := m.Upload(ctx, data, meta)
+	return UploadReceipt{ContentID: id}, err
+}
+
+func (m *memClient) Download(_ context.Context, contentID string) ([]byte, error) {
+	data, ok := m.objects[contentID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *memClient) List(_ context.Context, _ string) ([]Metadata, error) { return nil, nil }
+func (m *memClient) ListByTag(_ context.Context, _, _ string) ([]Metadata, error) {
+	return nil, nil
+}
+func (m *memClient) ListMulti(_ context.Context, _ []string) ([]Metadata, error) {
+	return nil, nil
+}
+func (m *memClient) Close() error { return nil }
+
+func TestUploadEncoded_RoundTripCompressEncrypt(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	codecs := []Codec{GzipCodec{}, AESGCMCodec{Key: key}}
+	plaintext := []byte(strings.Repeat(`{"signal":"buy","confidence":0.9}`, 50))
+
+	c := newMemClient()
+	id, err := UploadEncoded(context.Background(), c, plaintext, Metadata{Name: "obj"}, codecs...)
+	if err != nil {
+		t.Fatalf("UploadEncoded: %v", err)
+	}
+	if bytes.Contains(c.objects[id], []byte("signal")) {
+		t.Error("stored bytes contain plaintext")
+	}
+	if got := c.meta[id].Tags[CodecTag]; got != "gzip,aes-256-gcm" {
+		t.Errorf("codec tag = %q", got)
+	}
+
+	got, err := DownloadDecoded(context.Background(), c, id, codecs...)
+	if err != nil {
+		t.Fatalf("DownloadDecoded: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Error("round trip mismatch")
+	}
+}
+
+func TestDownloadDecoded_MissingCodec(t *testing.T) {
+	key := make([]byte, 32)
+	c := newMemClient()
+	id, err := UploadEncoded(context.Background(), c, []byte("secret"), Metadata{Name: "obj"}, AESGCMCodec{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DownloadDecoded(context.Background(), c, id, GzipCodec{}); !errors.Is(err, ErrUnknownCodec) {
+		t.Fatalf("expected ErrUnknownCodec, got %v", err)
+	}
+}
+
+func TestDownloadDecoded_PlainContent(t *testing.T) {
+	c := newMemClient()
+	id, _ := c.Upload(context.Background(), []byte("plain"), Metadata{Name: "obj"})
+	got, err := DownloadDecoded(context.Background(), c, id)
+	if err != nil || string(got) != "plain" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+}
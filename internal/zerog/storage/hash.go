@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/blake2b"
+)
+
+// HashAlgorithm names the hash function content IDs are derived from. Every
+// supported algorithm yields 32 bytes, the size of the data root anchored
+// on the Flow contract.
+type HashAlgorithm string
+
+// Supported content-ID hash algorithms.
+const (
+	HashSHA256     HashAlgorithm = "sha256"
+	HashKeccak256  HashAlgorithm = "keccak256"
+	HashBlake2b256 HashAlgorithm = "blake2b-256"
+)
+
+// ErrUnknownHashAlgorithm is returned for a HashAlgorithm this package does
+// not implement.
+var ErrUnknownHashAlgorithm = errors.New("storage: unknown hash algorithm")
+
+// ParseHashAlgorithm validates s as a HashAlgorithm. Empty selects
+// HashSHA256.
+func ParseHashAlgorithm(s string) (HashAlgorithm, error) {
+	alg := HashAlgorithm(s)
+	if alg == "" {
+		return HashSHA256, nil
+	}
+	if _, err := alg.sum(nil); err != nil {
+		return "", err
+	}
+	return alg, nil
+}
+
+// sum hashes data into a data root. The zero value is HashSHA256.
+func (h HashAlgorithm) sum(data []byte) ([32]byte, error) {
+	switch h {
+	case "", HashSHA256:
+		return sha256.Sum256(data), nil
+	case HashKeccak256:
+		return crypto.Keccak256Hash(data), nil
+	case HashBlake2b256:
+		return blake2b.Sum256(data), nil
+	default:
+		return [32]byte{}, fmt.Errorf("%w: %q", ErrUnknownHashAlgorithm, string(h))
+	}
+}
+
+// ContentID returns the content ID data is stored under when hashed with
+// alg, so callers can address content before or without uploading it.
+func ContentID(alg HashAlgorithm, data []byte) (string, error) {
+	root, err := alg.sum(data)
+	if err != nil {
+		return "", err
+	}
+	return common.Bytes2Hex(root[:]), nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+)
+
+func TestUpload_HashAlgorithms(t *testing.T) {
+	data := []byte("hello world")
+	tests := []struct {
+		alg  HashAlgorithm
+		want string
+	}{
+		{"", "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"},
+		{HashSHA256, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"},
+		{HashKeccak256, "47173285a8d7341e5e972fc677286384f802f8ef42a5ec5f03bbfa254cb01fad"},
+		{HashBlake2b256, "256c83b297114d201b30179f3f0ef0cace9783622da5974326b436178aeef610"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.alg), func(t *testing.T) {
+			backend, key := testSetup(t)
+			c := NewClient(ClientConfig{
+				ChainID:             16602,
+				FlowContractAddress: "0x22E03a6A89B950F1c82ec5e74F8eCa321a105296",
+				HashAlgorithm:       tt.alg,
+			}, backend, zerog.NewLocalSigner(key))
+
+			got, err := c.Upload(context.Background(), data, Metadata{Name: "test"})
+			if err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("content ID = %s, want %s", got, tt.want)
+			}
+			if id, _ := ContentID(tt.alg, data); id != tt.want {
+				t.Errorf("ContentID = %s, want %s", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpload_UnknownHashAlgorithm(t *testing.T) {
+	backend, key := testSetup(t)
+	c := NewClient(ClientConfig{HashAlgorithm: "md5"}, backend, zerog.NewLocalSigner(key))
+
+	if _, err := c.Upload(context.Background(), []byte("x"), Metadata{}); !errors.Is(err, ErrUnknownHashAlgorithm) {
+		t.Fatalf("expected ErrUnknownHashAlgorithm, got %v", err)
+	}
+}
+
+func TestDownload_VerifiesWithConfiguredAlgorithm(t *testing.T) {
+	data := []byte("hello world")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	for _, alg := range []HashAlgorithm{HashSHA256, HashKeccak256, HashBlake2b256} {
+		t.Run(string(alg), func(t *testing.T) {
+			backend, key := testSetup(t)
+			c := NewClient(ClientConfig{
+				StorageNodeEndpoint: srv.URL,
+				HashAlgorithm:       alg,
+				VerifyDownloads:     true,
+			}, backend, zerog.NewLocalSigner(key))
+
+			id, _ := ContentID(alg, data)
+			if _, err := c.Download(context.Background(), id); err != nil {
+				t.Errorf("Download matching content: %v", err)
+			}
+			// An ID derived with a different algorithm must not verify.
+			other := HashSHA256
+			if alg == HashSHA256 {
+				other = HashKeccak256
+			}
+			wrong, _ := ContentID(other, data)
+			if _, err := c.Download(context.Background(), wrong); !errors.Is(err, ErrIntegrity) {
+				t.Errorf("Download mismatched content: expected ErrIntegrity, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseHashAlgorithm(t *testing.T) {
+	if alg, err := ParseHashAlgorithm(""); err != nil || alg != HashSHA256 {
+		t.Errorf("ParseHashAlgorithm(\"\") = %q, %v; want sha256", alg, err)
+	}
+	if alg, err := ParseHashAlgorithm("blake2b-256"); err != nil || alg != HashBlake2b256 {
+		t.Errorf("ParseHashAlgorithm(blake2b-256) = %q, %v", alg, err)
+	}
+	if _, err := ParseHashAlgorithm("sha1"); !errors.Is(err, ErrUnknownHashAlgorithm) {
+		t.Errorf("expected ErrUnknownHashAlgorithm, got %v", err)
+	}
+}
@@ -1,8 +1,13 @@
 package storage
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/url"
 	"time"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
 )
 
 // Sentinel errors for storage operations.
@@ -11,6 +16,7 @@ var (
 	ErrUploadFailed = errors.New("storage: upload failed")
 	ErrNodeDown     = errors.New("storage: storage node unreachable")
 	ErrIntegrity    = errors.New("storage: data integrity check failed")
+	ErrUnknownCodec = errors.New("storage: codec not available")
 )
 
 // Metadata describes a stored item on 0G Storage.
@@ -23,6 +29,35 @@ type Metadata struct {
 	Tags        map[string]string `json:"tags,omitempty"`
 }
 
+// UploadReceipt records where an upload was anchored on chain. TxHash and
+// BlockNumber are empty when no new anchor was submitted, as when
+// ClientConfig.SkipExisting finds the content already stored.
+type UploadReceipt struct {
+	ContentID   string `json:"content_id"`
+	TxHash      string `json:"tx_hash,omitempty"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+}
+
+// listFilter selects objects from the indexer. Both conditions must match:
+// the name starts with prefix and every tag is present with the same value.
+type listFilter struct {
+	Prefix string
+	Tags   map[string]string
+}
+
+// query encodes f as indexer query parameters, one tag.<key>=<value> per
+// tag.
+func (f listFilter) query() url.Values {
+	q := url.Values{}
+	if f.Prefix != "" || len(f.Tags) == 0 {
+		q.Set("prefix", f.Prefix)
+	}
+	for k, v := range f.Tags {
+		q.Set("tag."+k, v)
+	}
+	return q
+}
+
 // ClientConfig holds configuration for the 0G Storage client.
 type ClientConfig struct {
 	// ChainRPC is the 0G Chain JSON-RPC endpoint for Flow contract interaction.
@@ -38,8 +73,33 @@ type ClientConfig struct {
 	StorageNodeEndpoint string
 	// DefaultChunkSize is the chunk size for uploads (bytes). Defaults to 4MB.
 	DefaultChunkSize int64
-	// MaxRetries is the number of retry attempts for failed operations.
+	// MaxRetries is the number of retry attempts for node downloads and
+	// uploads that fail to reach the storage node. Defaults to 3.
 	MaxRetries int
+	// HashAlgorithm derives content IDs, and the data root anchored on the
+	// Flow contract, from uploaded bytes. Empty uses HashSHA256.
+	HashAlgorithm HashAlgorithm
+	// VerifyDownloads rehashes downloaded bytes with HashAlgorithm and
+	// fails with ErrIntegrity when they do not match the content ID.
+	VerifyDownloads bool
+	// SkipExisting checks the storage node for the content ID before
+	// uploading and returns it without re-uploading if already stored.
+	SkipExisting bool
+	// Headers are extra HTTP headers (e.g. gateway API keys) applied to
+	// every request sent to the storage node. Empty by default.
+	Headers map[string]string
+	// Token is a bearer token sent as the Authorization header on every
+	// storage node request, for hosted indexers that require one.
+	Token string
+	// TokenProvider, when set, supplies the bearer token per request instead
+	// of Token, so it can be rotated without rebuilding the client.
+	TokenProvider func(ctx context.Context) (string, error)
+	// Transport carries storage node requests, e.g. through a proxy. Nil
+	// uses http.DefaultTransport.
+	Transport http.RoundTripper
+	// TxLimiter, shared with the other chain clients, bounds concurrent
+	// in-flight transactions. Nil means no limit.
+	TxLimiter *zerog.TxLimiter
 
 	// Endpoint is a legacy field for backward compat with REST mode.
 	// If StorageNodeEndpoint is empty, falls back to Endpoint.
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// maxListConcurrency bounds the prefix queries ListMulti runs at once.
+const maxListConcurrency = 4
+
+// ListMulti lists every prefix concurrently, at most maxListConcurrency at
+// a time, and merges the results in prefix order. Objects matched by more
+// than one prefix appear once, keyed by content ID. If any query fails,
+// ListMulti returns all the failures joined and no results.
+func (c *client) ListMulti(ctx context.Context, prefixes []string) ([]Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("storage: context cancelled before list: %w", err)
+	}
+
+	results := make([][]Metadata, len(prefixes))
+	errs := make([]error, len(prefixes))
+	sem := make(chan struct{}, maxListConcurrency)
+	var wg sync.WaitGroup
+	for i, prefix := range prefixes {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = fmt.Errorf("storage: list %q: %w", prefix, ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			items, err := c.List(ctx, prefix)
+			if err != nil {
+				errs[i] = fmt.Errorf("storage: list %q: %w", prefix, err)
+				return
+			}
+			results[i] = items
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return mergeListings(results), nil
+}
+
+// mergeListings flattens listings in order, dropping repeated content IDs.
+func mergeListings(listings [][]Metadata) []Metadata {
+	seen := make(map[string]bool)
+	var merged []Metadata
+	for _, items := range listings {
+		for _, item := range items {
+			if item.ContentID != "" && seen[item.ContentID] {
+				continue
+			}
+			seen[item.ContentID] = true
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
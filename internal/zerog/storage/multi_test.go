@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lancekrogers/agent-inference/internal/zerog"
+)
+
+// prefixServer serves a fixed object set, filtered by the prefix query.
+func prefixServer(t *testing.T, objects []Metadata, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		prefix := r.URL.Query().Get("prefix")
+		if prefix == "broken/" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		items := []Metadata{}
+		for _, o := range objects {
+			if strings.HasPrefix(o.Name, prefix) {
+				items = append(items, o)
+			}
+		}
+		json.NewEncoder(w).Encode(struct {
+			Items []Metadata `json:"items"`
+		}{items})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestListMulti_OverlappingPrefixes(t *testing.T) {
+	var calls atomic.Int32
+	srv := prefixServer(t, []Metadata{
+		{ContentID: "cid-1", Name: "task-a/1"},
+		{ContentID: "cid-2", Name: "task-a/2"},
+		{ContentID: "cid-3", Name: "task-b/1"},
+	}, &calls)
+
+	backend, key := testSetup(t)
+	c := NewClient(ClientConfig{StorageNodeEndpoint: srv.URL}, backend, zerog.NewLocalSigner(key))
+
+	items, err := c.ListMulti(context.Background(), []string{"task-a/", "task-", "task-b/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, it := range items {
+		got = append(got, it.ContentID)
+	}
+	if strings.Join(got, ",") != "cid-1,cid-2,cid-3" {
+		t.Errorf("expected deduplicated cid-1,cid-2,cid-3, got %v", got)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected one query per prefix, got %d", calls.Load())
+	}
+}
+
+func TestListMulti_AggregatesErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := prefixServer(t, nil, &calls)
+
+	backend, key := testSetup(t)
+	c := NewClient(ClientConfig{StorageNodeEndpoint: srv.URL}, backend, zerog.NewLocalSigner(key))
+
+	items, err := c.ListMulti(context.Background(), []string{"ok/", "broken/"})
+	if err == nil {
+		t.Fatal("expected error when one prefix fails")
+	}
+	if !strings.Contains(err.Error(), `"broken/"`) {
+		t.Errorf("error should name the failing prefix: %v", err)
+	}
+	if items != nil {
+		t.Errorf("expected no results on error, got %v", items)
+	}
+}
+
+func TestListMulti_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	backend, key := testSetup(t)
+	c := NewClient(ClientConfig{StorageNodeEndpoint: "http://unused"}, backend, zerog.NewLocalSigner(key))
+	if _, err := c.ListMulti(ctx, []string{"a/"}); err == nil {
+		t.Fatal("expected error for cancelled context")
+	}
+}
@@ -0,0 +1,52 @@
+package zerog
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// TxLimiter caps how many on-chain transactions are in flight at once,
+// counted from send until the receipt arrives. One limiter is shared by
+// the iNFT, storage, and DA clients so concurrent tasks cannot flood the
+// RPC. A nil *TxLimiter imposes no limit.
+type TxLimiter struct {
+	sem      chan struct{}
+	inflight atomic.Int64
+}
+
+// NewTxLimiter returns a limiter allowing max concurrent transactions, or
+// nil (unlimited) when max is not positive.
+func NewTxLimiter(max int) *TxLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &TxLimiter{sem: make(chan struct{}, max)}
+}
+
+// Acquire blocks until a transaction slot is free or ctx ends. The
+// returned release must be called once the transaction's receipt has
+// been received or abandoned.
+func (l *TxLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("zerog: waiting for transaction slot: %w", ctx.Err())
+	}
+	l.inflight.Add(1)
+	return func() {
+		l.inflight.Add(-1)
+		<-l.sem
+	}, nil
+}
+
+// InFlight reports the number of transactions currently holding a slot.
+func (l *TxLimiter) InFlight() int64 {
+	if l == nil {
+		return 0
+	}
+	return l.inflight.Load()
+}
@@ -0,0 +1,74 @@
+package zerog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTxLimiter_BlocksAtLimit(t *testing.T) {
+	l := NewTxLimiter(2)
+	ctx := context.Background()
+
+	r1, _ := l.Acquire(ctx)
+	r2, _ := l.Acquire(ctx)
+	if got := l.InFlight(); got != 2 {
+		t.Fatalf("InFlight = %d, want 2", got)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		r3, err := l.Acquire(ctx)
+		if err == nil {
+			r3()
+		}
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("third transaction acquired a slot beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	r1()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("released slot was not handed to the waiter")
+	}
+	r2()
+	if got := l.InFlight(); got != 0 {
+		t.Errorf("InFlight = %d after release, want 0", got)
+	}
+}
+
+func TestTxLimiter_ContextCancelled(t *testing.T) {
+	l := NewTxLimiter(1)
+	release, _ := l.Acquire(context.Background())
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if got := l.InFlight(); got != 1 {
+		t.Errorf("InFlight = %d, want 1", got)
+	}
+}
+
+func TestTxLimiter_NilIsUnlimited(t *testing.T) {
+	l := NewTxLimiter(0)
+	if l != nil {
+		t.Fatal("expected nil limiter for max 0")
+	}
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if l.InFlight() != 0 {
+		t.Error("nil limiter should report zero in flight")
+	}
+}
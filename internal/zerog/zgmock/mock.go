@@ -4,6 +4,7 @@ package zgmock
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"time"
@@ -34,6 +35,7 @@ func (m *ComputeBroker) GetResult(_ context.Context, jobID string) (*compute.Job
 		ModelID:    "llama-3-8b",
 		TokensUsed: 80 + rand.Intn(400),
 		Duration:   time.Duration(40+rand.Intn(180)) * time.Millisecond,
+		Provider:   "0g-compute",
 	}, nil
 }
 
@@ -44,6 +46,33 @@ func (m *ComputeBroker) ListModels(_ context.Context) ([]compute.Model, error) {
 	}, nil
 }
 
+func (m *ComputeBroker) ListModelsWithTotal(ctx context.Context) (compute.ListModelsResult, error) {
+	models, err := m.ListModels(ctx)
+	return compute.ListModelsResult{Models: models, Total: len(models)}, err
+}
+
+func (m *ComputeBroker) CancelJob(_ context.Context, _ string) error { return nil }
+
+func (m *ComputeBroker) Close() error { return nil }
+
+func (m *ComputeBroker) EstimateCost(_ context.Context, req compute.JobRequest) (compute.CostEstimate, error) {
+	n, _ := compute.ApproxTokenizer{}.CountTokens(req.ModelID, req.Input)
+	return compute.CostEstimate{Provider: "0g-compute", InputTokens: n, MaxOutputTokens: req.MaxTokens}, nil
+}
+
+func (m *ComputeBroker) WarmCache(_ context.Context) error { return nil }
+
+func (m *ComputeBroker) SubmitRaw(_ context.Context, modelID string, _ json.RawMessage) (*compute.JobResult, error) {
+	return &compute.JobResult{
+		JobID:      "mock-raw-job",
+		Status:     "completed",
+		Output:     `{"result": "mock raw inference output"}`,
+		ModelID:    modelID,
+		TokensUsed: 80 + rand.Intn(400),
+		Provider:   "0g-compute",
+	}, nil
+}
+
 // StorageClient returns simulated storage operations.
 type StorageClient struct {
 	uploadCounter int
@@ -56,6 +85,13 @@ func (m *StorageClient) Upload(_ context.Context, _ []byte, _ storage.Metadata)
 	return fmt.Sprintf("mock-content-%d", m.uploadCounter), nil
 }
 
+func (m *StorageClient) UploadWithReceipt(ctx context.Context, data []byte, meta storage.Metadata) (storage.UploadReceipt, error) {
+	id, err := m.Upload(ctx, data, meta)
+	return storage.UploadReceipt{ContentID: id, TxHash: fmt.Sprintf("0xmock-tx-%d", m.uploadCounter)}, err
+}
+
+func (m *StorageClient) Close() error { return nil }
+
 func (m *StorageClient) Download(_ context.Context, _ string) ([]byte, error) {
 	return []byte(`{"mock": true}`), nil
 }
@@ -64,6 +100,14 @@ func (m *StorageClient) List(_ context.Context, _ string) ([]storage.Metadata, e
 	return nil, nil
 }
 
+func (m *StorageClient) ListByTag(_ context.Context, _, _ string) ([]storage.Metadata, error) {
+	return nil, nil
+}
+
+func (m *StorageClient) ListMulti(_ context.Context, _ []string) ([]storage.Metadata, error) {
+	return nil, nil
+}
+
 // INFTMinter returns simulated iNFT operations.
 type INFTMinter struct{}
 
@@ -73,6 +117,8 @@ func (m *INFTMinter) Mint(_ context.Context, _ inft.MintRequest) (string, error)
 	return "mock-inft-001", nil
 }
 
+func (m *INFTMinter) Close() error { return nil }
+
 func (m *INFTMinter) UpdateMetadata(_ context.Context, _ string, _ inft.EncryptedMeta) error {
 	return nil
 }
@@ -87,18 +133,59 @@ func (m *INFTMinter) GetStatus(_ context.Context, tokenID string) (*inft.INFTSta
 	}, nil
 }
 
+func (m *INFTMinter) VerifyProvenance(_ context.Context, _ string, _ storage.StorageClient, _ storage.HashAlgorithm) (bool, error) {
+	return true, nil
+}
+
 // AuditPublisher returns simulated DA operations.
 type AuditPublisher struct {
 	pubCounter int
+	byTask     map[string][]string
 }
 
 func NewAuditPublisher() da.AuditPublisher { return &AuditPublisher{} }
 
-func (m *AuditPublisher) Publish(_ context.Context, _ da.AuditEvent) (string, error) {
+func (m *AuditPublisher) Publish(_ context.Context, event da.AuditEvent) (string, error) {
 	m.pubCounter++
-	return fmt.Sprintf("mock-audit-%d", m.pubCounter), nil
+	id := fmt.Sprintf("mock-audit-%d", m.pubCounter)
+	if event.TaskID != "" {
+		if m.byTask == nil {
+			m.byTask = make(map[string][]string)
+		}
+		m.byTask[event.TaskID] = append(m.byTask[event.TaskID], id)
+	}
+	return id, nil
 }
 
+func (m *AuditPublisher) SubmissionsForTask(taskID string) ([]string, error) {
+	return append([]string(nil), m.byTask[taskID]...), nil
+}
+
+func (m *AuditPublisher) PublishWithReceipt(ctx context.Context, event da.AuditEvent) (da.Submission, error) {
+	id, _ := m.Publish(ctx, event)
+	return da.Submission{
+		ID:          id,
+		EventType:   event.Type,
+		Namespace:   "inference-audit",
+		BlockHeight: uint64(1000 + m.pubCounter),
+		SubmittedAt: time.Now(),
+	}, nil
+}
+
+func (m *AuditPublisher) EstimateSubmission(events []da.AuditEvent) (da.SubmissionEstimate, error) {
+	est := da.SubmissionEstimate{Events: len(events)}
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return da.SubmissionEstimate{}, err
+		}
+		est.Bytes += len(data)
+	}
+	return est, nil
+}
+
+func (m *AuditPublisher) Close() error { return nil }
+
 func (m *AuditPublisher) Verify(_ context.Context, _ string) (bool, error) {
 	return true, nil
 }
@@ -27,14 +27,33 @@ type MockBackend struct {
 	// ReceiptFn returns a transaction receipt. Nil = return default success receipt.
 	ReceiptFn func(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
 
+	// FilterLogsFn answers log queries. Nil = return no logs.
+	FilterLogsFn func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+
+	// TxByHashFn returns a mined transaction. Nil = ethereum.NotFound.
+	TxByHashFn func(ctx context.Context, txHash common.Hash) (*types.Transaction, error)
+
+	// HeaderFn returns block headers. Nil = return a default header at block 1.
+	HeaderFn func(ctx context.Context, number *big.Int) (*types.Header, error)
+
+	// CodeAtFn returns the code deployed at an address. Nil = return
+	// non-empty code for every address.
+	CodeAtFn func(ctx context.Context, addr common.Address) ([]byte, error)
+
+	// ChainIDFn reports the chain ID. Nil = return 16602 (Galileo testnet).
+	ChainIDFn func(ctx context.Context) (*big.Int, error)
+
 	// Err sets a global error returned by all methods.
 	Err error
 }
 
-func (m *MockBackend) CodeAt(_ context.Context, _ common.Address, _ *big.Int) ([]byte, error) {
+func (m *MockBackend) CodeAt(ctx context.Context, addr common.Address, _ *big.Int) ([]byte, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
+	if m.CodeAtFn != nil {
+		return m.CodeAtFn(ctx, addr)
+	}
 	return []byte{0x01}, nil
 }
 
@@ -48,10 +67,13 @@ func (m *MockBackend) CallContract(ctx context.Context, call ethereum.CallMsg, _
 	return nil, nil
 }
 
-func (m *MockBackend) HeaderByNumber(_ context.Context, _ *big.Int) (*types.Header, error) {
+func (m *MockBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
+	if m.HeaderFn != nil {
+		return m.HeaderFn(ctx, number)
+	}
 	return &types.Header{
 		Number:  big.NewInt(1),
 		BaseFee: big.NewInt(1e9),
@@ -103,10 +125,13 @@ func (m *MockBackend) SendTransaction(ctx context.Context, tx *types.Transaction
 	return nil
 }
 
-func (m *MockBackend) FilterLogs(_ context.Context, _ ethereum.FilterQuery) ([]types.Log, error) {
+func (m *MockBackend) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
+	if m.FilterLogsFn != nil {
+		return m.FilterLogsFn(ctx, q)
+	}
 	return nil, nil
 }
 
@@ -131,7 +156,28 @@ func (m *MockBackend) TransactionReceipt(ctx context.Context, txHash common.Hash
 	}, nil
 }
 
+func (m *MockBackend) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	if m.Err != nil {
+		return nil, false, m.Err
+	}
+	if m.TxByHashFn != nil {
+		tx, err := m.TxByHashFn(ctx, txHash)
+		return tx, false, err
+	}
+	return nil, false, ethereum.NotFound
+}
+
+func (m *MockBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	if m.ChainIDFn != nil {
+		return m.ChainIDFn(ctx)
+	}
+	return big.NewInt(16602), nil
+}
+
 type stubSub struct{}
 
 func (s *stubSub) Unsubscribe()      {}
-func (s *stubSub) Err() <-chan error  { return make(chan error) }
+func (s *stubSub) Err() <-chan error { return make(chan error) }